@@ -0,0 +1,75 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Operations reported in an AuditEntry.
+const (
+	AuditSet    = "set"
+	AuditMSet   = "mset"
+	AuditDelete = "delete"
+)
+
+// AuditEntry describes a mutation of a single key made through the Cache. The
+// value itself is never included, only its size, so audit entries are safe to
+// log or ship to an audit trail without exposing cached data.
+type AuditEntry struct {
+	// Operation is the mutation performed, one of the Audit constants.
+	Operation string
+
+	// Key is the key as provided to the Cache, before any prefixing or
+	// compaction.
+	Key string
+
+	// Size is the size in bytes of the value as stored in Redis. It is zero for
+	// deletes and for writes made while migrating between Encodings.
+	Size int
+
+	// Time is when the mutation completed according to the Clock of the Cache.
+	Time time.Time
+
+	// Actor is the actor carried by the context of the operation with
+	// ContextWithActor, or empty if the context doesn't carry one.
+	Actor string
+
+	// Err is the error returned to the caller, or nil if the mutation succeeded.
+	Err error
+}
+
+// AuditLogger is a function type invoked with an AuditEntry for every key
+// mutated through the Cache. It is called synchronously on the goroutine
+// performing the mutation, so it should return quickly.
+type AuditLogger func(entry AuditEntry)
+
+type actorKey struct{}
+
+// ContextWithActor returns a copy of ctx carrying the ID of the actor, such as
+// a user or service, performing operations with ctx. The actor is reported in
+// the AuditEntry of mutations made with the returned context.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, or an empty string if ctx
+// doesn't carry one.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// audit reports the mutation of key to the AuditLogger, if one is configured.
+func (c *Cache) audit(ctx context.Context, op string, key string, size int, err error) {
+	if c.auditFn == nil {
+		return
+	}
+	c.auditFn(AuditEntry{
+		Operation: op,
+		Key:       key,
+		Size:      size,
+		Time:      c.clock.Now(),
+		Actor:     ActorFromContext(ctx),
+		Err:       err,
+	})
+}
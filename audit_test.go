@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_WithAuditLogger(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var entries []AuditEntry
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rdb := New(client, WithClock(clock), WithAuditLogger(func(entry AuditEntry) {
+		entries = append(entries, entry)
+	}))
+
+	ctx := ContextWithActor(context.Background(), "user:42")
+	require.NoError(t, rdb.Set(ctx, "key", "secret value", 0))
+	require.Len(t, entries, 1)
+	raw, err := server.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, AuditEntry{
+		Operation: AuditSet,
+		Key:       "key",
+		Size:      len(raw),
+		Time:      clock.Now(),
+		Actor:     "user:42",
+	}, entries[0])
+
+	entries = nil
+	require.NoError(t, rdb.MSet(context.Background(), map[string]any{"a": 1, "b": 2}))
+	require.Len(t, entries, 2)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	for i, key := range []string{"a", "b"} {
+		assert.Equal(t, AuditMSet, entries[i].Operation)
+		assert.Equal(t, key, entries[i].Key)
+		assert.Greater(t, entries[i].Size, 0)
+		assert.Empty(t, entries[i].Actor)
+		assert.NoError(t, entries[i].Err)
+	}
+
+	entries = nil
+	require.NoError(t, rdb.Delete(ctx, "a", "b"))
+	require.Len(t, entries, 2)
+	for i, key := range []string{"a", "b"} {
+		assert.Equal(t, AuditEntry{
+			Operation: AuditDelete,
+			Key:       key,
+			Time:      clock.Now(),
+			Actor:     "user:42",
+		}, entries[i])
+	}
+
+	entries = nil
+	assert.Error(t, rdb.Set(ctx, "fn", func() {}, 0))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "fn", entries[0].Key)
+	assert.Error(t, entries[0].Err)
+}
+
+func TestWithAuditLogger_Nil(t *testing.T) {
+	assert.Panics(t, func() {
+		WithAuditLogger(nil)
+	})
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCache_BareMsgpack(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, BareMsgpack(), GZip(), WithWriteTimestamps())
+
+	type user struct {
+		Name string
+		Age  int
+	}
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "user", user{Name: "Bob", Age: 42}, 0))
+
+	// Values are stored exactly as marshalled by msgpack
+	raw, err := server.Get("user")
+	require.NoError(t, err)
+	expected, err := msgpack.Marshal(user{Name: "Bob", Age: 42})
+	require.NoError(t, err)
+	assert.Equal(t, expected, []byte(raw))
+
+	// Values written by other clients are read as is
+	foreign, err := msgpack.Marshal(map[string]any{"Name": "Alice", "Age": 7})
+	require.NoError(t, err)
+	require.NoError(t, server.Set("foreign", string(foreign)))
+	var u user
+	require.NoError(t, rdb.Get(ctx, "foreign", &u))
+	assert.Equal(t, user{Name: "Alice", Age: 7}, u)
+
+	assert.Error(t, rdb.SetWithMeta(ctx, "meta", "value", map[string]string{"k": "v"}, 0))
+	assert.Error(t, rdb.SetSoftHard(ctx, "soft", "value", time.Minute, time.Hour))
+}
+
+func TestCache_BareJSON(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, BareJSON(), LZ4())
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "key", map[string]int{"count": 1}, 0))
+	raw, err := server.Get("key")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"count":1}`, raw)
+
+	foreign, err := json.Marshal([]string{"a", "b"})
+	require.NoError(t, err)
+	require.NoError(t, server.Set("foreign", string(foreign)))
+	var s []string
+	require.NoError(t, rdb.Get(ctx, "foreign", &s))
+	assert.Equal(t, []string{"a", "b"}, s)
+}
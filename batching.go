@@ -0,0 +1,299 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// bufferedWrite is a SET buffered by the writeBatcher.
+type bufferedWrite struct {
+	redisKey  string
+	data      []byte
+	ttl       time.Duration
+	expiresAt time.Time // zero if the write has no TTL
+}
+
+// expired reports if the TTL of the write has elapsed.
+func (w bufferedWrite) expired(now time.Time) bool {
+	return !w.expiresAt.IsZero() && !now.Before(w.expiresAt)
+}
+
+// writeBatcher buffers writes and flushes them to Redis in a single pipeline once
+// maxEntries writes are buffered or maxDelay has elapsed.
+type writeBatcher struct {
+	maxEntries int
+	maxDelay   time.Duration
+	clock      Clock
+
+	mu       sync.Mutex
+	pending  []bufferedWrite
+	inflight []bufferedWrite // writes being flushed, still visible to reads
+	closed   bool
+
+	flushMu sync.Mutex // serializes flushes so writes are applied in order
+	full    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newWriteBatcher(maxEntries int, maxDelay time.Duration) *writeBatcher {
+	return &writeBatcher{
+		maxEntries: maxEntries,
+		maxDelay:   maxDelay,
+		clock:      systemClock{},
+		full:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+}
+
+// enqueue buffers the write. Returns false if the writeBatcher is closed and the
+// write should be sent to Redis directly.
+func (b *writeBatcher) enqueue(w bufferedWrite) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
+	if w.ttl > 0 {
+		w.expiresAt = b.clock.Now().Add(w.ttl)
+	}
+	b.pending = append(b.pending, w)
+	if len(b.pending) >= b.maxEntries {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// lookup returns the most recent buffered value for the key. Writes whose TTL
+// has elapsed are ignored, as they would have expired in Redis.
+func (b *writeBatcher) lookup(redisKey string) ([]byte, bool) {
+	now := b.clock.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, writes := range [][]bufferedWrite{b.pending, b.inflight} {
+		for i := len(writes) - 1; i >= 0; i-- {
+			if writes[i].redisKey == redisKey {
+				if writes[i].expired(now) {
+					return nil, false
+				}
+				return writes[i].data, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// has reports if a write to any of the keys is buffered.
+func (b *writeBatcher) has(redisKeys []string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, writes := range [][]bufferedWrite{b.pending, b.inflight} {
+		for _, w := range writes {
+			if slices.Contains(redisKeys, w.redisKey) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flush writes all buffered writes to Redis in a single pipeline, with the TTL
+// remaining for each write. Writes that fail with a connection error, or because
+// the context is done, are kept at the front of the buffer and retried on the
+// next flush. Writes rejected by Redis are dropped, as retrying them would fail
+// again, and writes whose TTL elapsed while buffered are dropped as well.
+func (b *writeBatcher) flush(ctx context.Context, client rueidis.Client) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	writes := b.pending
+	b.pending = nil
+	b.inflight = writes
+	b.mu.Unlock()
+
+	if len(writes) == 0 {
+		return nil
+	}
+
+	now := b.clock.Now()
+	cmds := make(rueidis.Commands, 0, len(writes))
+	sent := make([]bufferedWrite, 0, len(writes))
+	for _, w := range writes {
+		cmd := client.B().Set().Key(w.redisKey).Value(string(w.data))
+		if !w.expiresAt.IsZero() {
+			remaining := w.expiresAt.Sub(now)
+			if remaining < time.Millisecond {
+				continue
+			}
+			cmd.Px(remaining)
+		}
+		cmds = append(cmds, cmd.Build())
+		sent = append(sent, w)
+	}
+
+	var (
+		retried []bufferedWrite
+		errs    []error
+	)
+	if len(cmds) > 0 {
+		for i, res := range client.DoMulti(ctx, cmds...) {
+			err := res.Error()
+			if err == nil {
+				continue
+			}
+			errs = append(errs, err)
+			if connectionError(err) || ctx.Err() != nil {
+				retried = append(retried, sent[i])
+			}
+		}
+	}
+
+	b.mu.Lock()
+	b.inflight = nil
+	b.pending = append(retried, b.pending...)
+	b.mu.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("redis: %d of %d buffered writes failed, %d dropped: %w",
+			len(errs), len(writes), len(errs)-len(retried), errors.Join(errs...))
+	}
+	return nil
+}
+
+// run flushes the buffer whenever it is full or maxDelay elapses until close is
+// called.
+func (b *writeBatcher) run(c *Cache) {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.maxDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-b.full:
+		case <-ticker.C:
+		}
+		if err := b.flush(context.Background(), c.redis); err != nil {
+			slog.Error("Failed to flush buffered writes", slog.Any("err", err))
+		}
+	}
+}
+
+// close stops the background flusher and flushes any remaining writes. Writes
+// made after close are sent to Redis directly.
+func (b *writeBatcher) close(ctx context.Context, client rueidis.Client) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.done)
+	<-b.stopped
+	return b.flush(ctx, client)
+}
+
+// FlushWrites writes all writes buffered by WithWriteBatching to Redis without
+// waiting for the buffer to fill up or the delay to elapse. FlushWrites is a
+// no-op if write batching is not enabled.
+func (c *Cache) FlushWrites(ctx context.Context) error {
+	if c.writeBatch == nil {
+		return nil
+	}
+	return c.writeBatch.flush(ctx, c.redis)
+}
+
+// Close stops the background flusher started by WithWriteBatching and flushes
+// any buffered writes to Redis. Writes made after Close are sent to Redis
+// directly. Close also stops dispatching events to the callbacks registered with
+// OnHit and OnMiss. Close doesn't close the underlying Redis client, except for
+// the in-process store started by NewWithFallback which is released.
+//
+// Close is a no-op if neither write batching, callbacks, nor the in-memory store
+// are enabled.
+func (c *Cache) Close(ctx context.Context) error {
+	if c.events != nil {
+		c.events.close()
+	}
+	var err error
+	if c.writeBatch != nil {
+		err = c.writeBatch.close(ctx, c.redis)
+	}
+	if c.memory != nil {
+		c.memory.close()
+	}
+	return err
+}
+
+// flushBuffered flushes the write buffer if a write to any of the keys is
+// buffered, so a buffered write flushed later doesn't overwrite a mutation made
+// directly in Redis, preserving the order writes were made in.
+func (c *Cache) flushBuffered(ctx context.Context, redisKeys ...string) error {
+	if c.writeBatch == nil || !c.writeBatch.has(redisKeys) {
+		return nil
+	}
+	return c.FlushWrites(ctx)
+}
+
+// buffered returns the most recent value written to the key that is still
+// buffered by WithWriteBatching.
+func (c *Cache) buffered(redisKey string) ([]byte, bool) {
+	if c.writeBatch == nil {
+		return nil, false
+	}
+	return c.writeBatch.lookup(redisKey)
+}
+
+// mGetBuffered is like MGet but serves keys with buffered writes from the write
+// buffer and reads the remaining keys from Redis.
+func mGetBuffered[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R], error) {
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	resultMap := make(map[string]R)
+	remaining := make([]string, 0, len(keys))
+	for i, key := range keys {
+		data, ok := c.buffered(redisKeys[i])
+		if !ok {
+			remaining = append(remaining, key)
+			continue
+		}
+		var val R
+		err := c.recordRead(key, c.decode(ctx, key, data, &val))
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resultMap[key] = val
+	}
+	if len(remaining) == 0 {
+		return resultMap, nil
+	}
+
+	results, err := mGet[R](ctx, c, remaining...)
+	if err != nil {
+		return nil, err
+	}
+	for key, val := range results {
+		resultMap[key] = val
+	}
+	return resultMap, nil
+}
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCache_WithWriteBatching(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithWriteBatching(3, time.Hour), WithClock(&fakeClock{now: time.Now()}))
+
+	assert.NoError(t, rdb.Set(context.Background(), "key1", "value1", 0))
+	assert.NoError(t, rdb.Set(context.Background(), "key1", "value2", time.Minute))
+	assert.False(t, server.Exists("key1"))
+
+	// Reads see buffered writes
+	var val string
+	source, err := rdb.GetWithSource(context.Background(), "key1", &val)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceWriteBuffer, source)
+	assert.Equal(t, "value2", val)
+
+	stored, _ := msgpack.Marshal("stored")
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("key2").Value(string(stored)).Build()).Error())
+	res, err := MGet[string](context.Background(), rdb, "key1", "key2", "key3")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"key1": "value2", "key2": "stored"}, res)
+
+	values, err := MGetValues[string](context.Background(), rdb, "key3", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"value2"}, values)
+
+	// Reaching maxEntries triggers a flush
+	assert.NoError(t, rdb.Set(context.Background(), "key3", "value3", 0))
+	assert.Eventually(t, func() bool {
+		return server.Exists("key3")
+	}, time.Second, 10*time.Millisecond)
+
+	source, err = rdb.GetWithSource(context.Background(), "key1", &val)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceRedis, source)
+	assert.Equal(t, "value2", val)
+	assert.Equal(t, time.Minute, server.TTL("key1"))
+
+	// Delete flushes buffered writes so the key isn't recreated
+	assert.NoError(t, rdb.Set(context.Background(), "key4", "value4", 0))
+	assert.NoError(t, rdb.Delete(context.Background(), "key4"))
+
+	// Deleting other keys leaves buffered writes in the buffer
+	assert.NoError(t, rdb.Set(context.Background(), "key5", "value5", 0))
+	assert.NoError(t, rdb.Delete(context.Background(), "key2"))
+	_, err = rdb.DeleteWithResult(context.Background(), "key3")
+	assert.NoError(t, err)
+	assert.False(t, server.Exists("key5"))
+	_, err = rdb.DeleteWithResult(context.Background(), "key5")
+	assert.NoError(t, err)
+	assert.NoError(t, rdb.Close(context.Background()))
+	assert.False(t, server.Exists("key4"))
+	assert.False(t, server.Exists("key5"))
+}
+
+func TestCache_WithWriteBatching_TTL(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	rdb := New(client, WithWriteBatching(100, time.Hour), WithClock(clock))
+	defer rdb.Close(ctx)
+
+	// Sub-second TTLs are written with millisecond precision
+	assert.NoError(t, rdb.Set(ctx, "short", "value", 500*time.Millisecond))
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.True(t, server.Exists("short"))
+	assert.Equal(t, 500*time.Millisecond, server.TTL("short"))
+
+	// Buffered writes expire with their TTL and are never written
+	assert.NoError(t, rdb.Set(ctx, "expired", "value", time.Minute))
+	clock.Advance(time.Minute)
+	var val string
+	assert.ErrorIs(t, rdb.Get(ctx, "expired", &val), ErrKeyNotFound)
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.False(t, server.Exists("expired"))
+
+	// The TTL remaining is written
+	assert.NoError(t, rdb.Set(ctx, "remaining", "value", time.Minute))
+	clock.Advance(20 * time.Second)
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.Equal(t, 40*time.Second, server.TTL("remaining"))
+}
+
+func TestCache_WithWriteBatching_RedisError(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithWriteBatching(100, time.Hour))
+	defer rdb.Close(ctx)
+
+	// Writes rejected by Redis are dropped rather than retried forever
+	assert.NoError(t, rdb.Set(ctx, "key", "value", 0))
+	server.SetError("ERR rejected")
+	assert.Error(t, rdb.FlushWrites(ctx))
+	server.SetError("")
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.False(t, server.Exists("key"))
+	var val string
+	assert.ErrorIs(t, rdb.Get(ctx, "key", &val), ErrKeyNotFound)
+}
+
+func TestCache_WithWriteBatching_Ordering(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithWriteBatching(100, time.Hour))
+	defer rdb.Close(ctx)
+
+	// Mutations bypassing the buffer flush earlier buffered writes to the key so
+	// they aren't overwritten once the buffer is flushed
+	var val string
+	assert.NoError(t, rdb.Set(ctx, "mset", "v1", 0))
+	assert.NoError(t, rdb.MSet(ctx, map[string]any{"mset": "v2"}))
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.NoError(t, rdb.Get(ctx, "mset", &val))
+	assert.Equal(t, "v2", val)
+
+	assert.NoError(t, rdb.Set(ctx, "setnx", "v1", 0))
+	ok, err := rdb.SetNX(ctx, "setnx", "v2", 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, rdb.Get(ctx, "setnx", &val))
+	assert.Equal(t, "v1", val)
+
+	assert.NoError(t, rdb.Set(ctx, "expire", "v1", 0))
+	ok, err = rdb.Expire(ctx, "expire", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.Equal(t, time.Minute, server.TTL("expire"))
+
+	assert.NoError(t, rdb.Set(ctx, "upsert", "v1", 0))
+	assert.NoError(t, Upsert(ctx, rdb, "upsert", "v2", func(found bool, oldVal, newVal string) string {
+		return oldVal + newVal
+	}, 0))
+	assert.NoError(t, rdb.FlushWrites(ctx))
+	assert.NoError(t, rdb.Get(ctx, "upsert", &val))
+	assert.Equal(t, "v1v2", val)
+}
+
+func TestCache_WithWriteBatching_Delay(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithWriteBatching(100, 20*time.Millisecond))
+	defer rdb.Close(context.Background())
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.Eventually(t, func() bool {
+		return server.Exists("key")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCache_WithWriteBatching_Close(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithWriteBatching(100, time.Hour))
+
+	assert.NoError(t, rdb.Set(context.Background(), "key1", "value1", 0))
+	assert.NoError(t, rdb.Close(context.Background()))
+	assert.True(t, server.Exists("key1"))
+
+	// Writes after Close go to Redis directly
+	assert.NoError(t, rdb.Set(context.Background(), "key2", "value2", 0))
+	assert.True(t, server.Exists("key2"))
+	assert.NoError(t, rdb.Close(context.Background()))
+}
@@ -0,0 +1,372 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// Outcome is the result of an operation on a single key of a batch.
+type Outcome uint8
+
+const (
+	// OutcomeOK indicates the operation succeeded for the key.
+	OutcomeOK Outcome = iota
+
+	// OutcomeNotFound indicates the key didn't exist.
+	OutcomeNotFound
+
+	// OutcomeError indicates the operation failed for the key.
+	OutcomeError
+)
+
+// String returns a human-readable name of the Outcome.
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeOK:
+		return "ok"
+	case OutcomeNotFound:
+		return "not-found"
+	case OutcomeError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// BatchResult is the outcome of a batch operation for each key, allowing callers
+// to react to each key individually, such as retrying only the keys that failed.
+//
+// Only keys that weren't successful are tracked, so a batch where every key
+// succeeds doesn't allocate. The zero-value is an empty successful batch.
+type BatchResult struct {
+	size     int
+	errs     map[string]error
+	notFound map[string]struct{}
+}
+
+// Len returns the number of keys in the batch.
+func (r BatchResult) Len() int {
+	return r.size
+}
+
+// Outcome returns the Outcome of the operation for the key. Keys that weren't
+// part of the batch are reported as OutcomeOK.
+func (r BatchResult) Outcome(key string) Outcome {
+	if _, ok := r.errs[key]; ok {
+		return OutcomeError
+	}
+	if _, ok := r.notFound[key]; ok {
+		return OutcomeNotFound
+	}
+	return OutcomeOK
+}
+
+// Err returns the error of the operation for the key, or nil if the operation
+// didn't fail for the key.
+func (r BatchResult) Err(key string) error {
+	return r.errs[key]
+}
+
+// Failed returns the keys the operation failed for in sorted order.
+func (r BatchResult) Failed() []string {
+	return sortedKeys(r.errs)
+}
+
+// NotFound returns the keys that didn't exist in sorted order.
+func (r BatchResult) NotFound() []string {
+	return sortedKeys(r.notFound)
+}
+
+// HasErrors reports if the operation failed for any key.
+func (r BatchResult) HasErrors() bool {
+	return len(r.errs) > 0
+}
+
+// fail records the operation failed for the key.
+func (r *BatchResult) fail(key string, err error) {
+	if r.errs == nil {
+		r.errs = make(map[string]error)
+	}
+	r.errs[key] = err
+}
+
+// missing records the key didn't exist.
+func (r *BatchResult) missing(key string) {
+	if r.notFound == nil {
+		r.notFound = make(map[string]struct{})
+	}
+	r.notFound[key] = struct{}{}
+}
+
+// err returns an error summarizing the keys the operation failed for, or nil if
+// the operation didn't fail for any key.
+func (r BatchResult) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(r.errs))
+	for _, key := range r.Failed() {
+		errs = append(errs, fmt.Errorf("key %s: %w", key, r.errs[key]))
+	}
+	return fmt.Errorf("%d of %d keys failed: %w", len(r.errs), r.size, errors.Join(errs...))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MGetWithResult retrieves multiple entries from the Cache like MGet, but reports
+// the outcome for each key in a BatchResult instead of failing the entire batch.
+// Keys that don't exist are reported as OutcomeNotFound, and keys that fail to be
+// read or decoded are reported as OutcomeError and omitted from the MultiResult.
+//
+// Each key is read with a GET in a single pipeline, so the keys don't need to
+// hash to the same slot when using Redis Cluster. The error returned summarizes
+// the keys that failed, and is nil if no key failed.
+func MGetWithResult[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R], BatchResult, error) {
+	var (
+		values = make(MultiResult[R], len(keys))
+		result = BatchResult{size: len(keys)}
+	)
+	if len(keys) == 0 {
+		return values, result, nil
+	}
+
+	record := func(key string, val R, err error) {
+		switch {
+		case errors.Is(err, ErrKeyNotFound):
+			result.missing(key)
+		case err != nil:
+			result.fail(key, err)
+		default:
+			values[key] = val
+		}
+	}
+
+	// The target Encoding may be stored in a different slot while migrating, so
+	// each key is read with Get instead.
+	if c.migration != nil {
+		for _, key := range keys {
+			var val R
+			record(key, val, c.Get(ctx, key, &val))
+		}
+		return values, result, result.err()
+	}
+
+	redisKeys := make([]string, 0, len(keys))
+	readKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		redisKey, err := c.key(ctx, key)
+		if err != nil {
+			result.fail(key, err)
+			continue
+		}
+		redisKeys = append(redisKeys, redisKey)
+		readKeys = append(readKeys, key)
+	}
+	if len(redisKeys) == 0 {
+		return values, result, result.err()
+	}
+
+	for i, res := range c.getPipelined(ctx, redisKeys) {
+		key := readKeys[i]
+		data, ok := c.buffered(redisKeys[i])
+		if !ok {
+			var err error
+			data, err = res.AsBytes()
+			if errors.Is(err, rueidis.Nil) {
+				c.hooksMixin.miss(key)
+				result.missing(key)
+				continue
+			}
+			if err != nil {
+				result.fail(key, fmt.Errorf("redis: %w", err))
+				continue
+			}
+		}
+		var val R
+		record(key, val, c.recordRead(key, c.decode(ctx, key, data, &val)))
+	}
+	return values, result, result.err()
+}
+
+// MSetWithResult adds multiple entries into the cache, or overwrites entries if
+// the keys already existed, and reports the outcome for each key in a
+// BatchResult. Unlike MSet, MSetWithResult isn't atomic: each entry is written
+// with a SET in a single pipeline, so entries that fail to be encoded or written
+// don't prevent the others from being written.
+//
+// MSetWithResult doesn't accept a TTL, so the entries are subject to the
+// ZeroTTLPolicy of the Cache.
+//
+// The error returned summarizes the keys that failed, and is nil if no key
+// failed.
+func (c *Cache) MSetWithResult(ctx context.Context, keyvalues map[string]any) (BatchResult, error) {
+	return c.mSetPipelined(ctx, keyvalues, 0)
+}
+
+// MSetWithTTL adds multiple entries into the cache with the provided TTL, or
+// overwrites entries if the keys already existed. If the ttl value is <= 0 the
+// keys will be persisted indefinitely, unless configured otherwise with the
+// WithZeroTTLPolicy Option.
+//
+// Every value is marshalled and compressed before any is written, then the
+// entries are written with a SET each in a single pipeline, so the keys don't
+// need to hash to the same slot when using Redis Cluster. Unlike MSet the writes
+// aren't atomic: entries that fail to be encoded or written don't prevent the
+// others from being written, and the error returned reports every key that
+// failed. Use MSetWithResult to inspect the outcome of each key.
+func (c *Cache) MSetWithTTL(ctx context.Context, keyvalues map[string]any, ttl time.Duration) error {
+	_, err := c.mSetPipelined(ctx, keyvalues, ttl)
+	return err
+}
+
+// mSetPipelined implements MSetWithResult and MSetWithTTL, writing the entries
+// with the provided ttl.
+func (c *Cache) mSetPipelined(ctx context.Context, keyvalues map[string]any, ttl time.Duration) (BatchResult, error) {
+	result := BatchResult{size: len(keyvalues)}
+	if len(keyvalues) == 0 {
+		return result, nil
+	}
+
+	if c.dryRun {
+		return result, c.mSet(ctx, keyvalues, make(map[string]int, len(keyvalues)))
+	}
+
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		for key := range keyvalues {
+			result.fail(key, err)
+		}
+		return result, result.err()
+	}
+
+	// Set handles writing both Encodings while migrating and buffering writes
+	if c.migration != nil || c.writeBatch != nil {
+		for key, v := range keyvalues {
+			if err := c.Set(ctx, key, v, ttl); err != nil {
+				result.fail(key, err)
+			}
+		}
+		return result, result.err()
+	}
+	if c.writeLocks != nil {
+		defer c.serializeWrites(sortedKeys(keyvalues)...)()
+	}
+
+	var (
+		cmds = make(rueidis.Commands, 0, len(keyvalues))
+		keys = make([]string, 0, len(keyvalues))
+	)
+	for key, v := range keyvalues {
+		redisKey, err := c.key(ctx, key)
+		if err != nil {
+			result.fail(key, err)
+			continue
+		}
+		data, err := c.encode(ctx, key, v)
+		if err != nil {
+			result.fail(key, err)
+			continue
+		}
+		cmd := c.redis.B().Set().Key(redisKey).Value(string(data))
+		if ttl > 0 {
+			cmd.Ex(ttl)
+		}
+		cmds = append(cmds, cmd.Build())
+		keys = append(keys, key)
+	}
+	if len(cmds) == 0 {
+		return result, result.err()
+	}
+
+	for i, res := range c.redis.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			result.fail(keys[i], fmt.Errorf("redis: %w", err))
+		}
+	}
+	return result, result.err()
+}
+
+// DeleteWithResult removes entries from the cache for the given keys like
+// Delete, and reports the outcome for each key in a BatchResult. Keys that
+// didn't exist are reported as OutcomeNotFound.
+//
+// Each key is deleted with a DEL in a single pipeline, so the keys don't need to
+// hash to the same slot when using Redis Cluster. The error returned summarizes
+// the keys that failed, and is nil if no key failed.
+func (c *Cache) DeleteWithResult(ctx context.Context, keys ...string) (BatchResult, error) {
+	result := BatchResult{size: len(keys)}
+	if len(keys) == 0 {
+		return result, nil
+	}
+	if c.dryRun {
+		return result, c.delete(ctx, keys)
+	}
+	defer c.serializeWrites(keys...)()
+
+	// While migrating the key of the target Encoding is deleted as well, using a
+	// separate command as it may hash to a different slot.
+	perKey := 1
+	if c.migration != nil {
+		perKey = 2
+	}
+	var (
+		cmds      = make(rueidis.Commands, 0, len(keys)*perKey)
+		deleted   = make([]string, 0, len(keys))
+		redisKeys = make([]string, 0, len(keys))
+	)
+	for _, key := range keys {
+		redisKey, err := c.key(ctx, key)
+		if err != nil {
+			result.fail(key, err)
+			continue
+		}
+		cmds = append(cmds, c.redis.B().Del().Key(redisKey).Build())
+		if c.migration != nil {
+			cmds = append(cmds, c.redis.B().Del().Key(c.migration.key(redisKey)).Build())
+		}
+		redisKeys = append(redisKeys, redisKey)
+		deleted = append(deleted, key)
+	}
+	if len(cmds) == 0 {
+		return result, result.err()
+	}
+
+	// Buffered writes must be flushed first or they would recreate the keys
+	if err := c.flushBuffered(ctx, redisKeys...); err != nil {
+		return result, err
+	}
+
+	results := c.redis.DoMulti(ctx, cmds...)
+	for i, key := range deleted {
+		var (
+			total int64
+			errs  []error
+		)
+		for _, res := range results[i*perKey : (i+1)*perKey] {
+			n, err := res.AsInt64()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			total += n
+		}
+		switch {
+		case len(errs) > 0:
+			result.fail(key, fmt.Errorf("redis: %w", errors.Join(errs...)))
+		case total == 0:
+			result.missing(key)
+		}
+	}
+	return result, result.err()
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMGetWithResult(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	require.NoError(t, rdb.MSet(context.Background(), map[string]any{"a": "alice", "b": "bob"}))
+	require.NoError(t, server.Set("corrupt", "\xc1\x00"))
+
+	values, result, err := MGetWithResult[string](context.Background(), rdb, "a", "b", "missing", "corrupt")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "1 of 4 keys failed")
+	assert.Equal(t, MultiResult[string]{"a": "alice", "b": "bob"}, values)
+	assert.Equal(t, 4, result.Len())
+	assert.Equal(t, OutcomeOK, result.Outcome("a"))
+	assert.Equal(t, OutcomeNotFound, result.Outcome("missing"))
+	assert.Equal(t, OutcomeError, result.Outcome("corrupt"))
+	assert.Equal(t, []string{"corrupt"}, result.Failed())
+	assert.Equal(t, []string{"missing"}, result.NotFound())
+	assert.Error(t, result.Err("corrupt"))
+	assert.NoError(t, result.Err("a"))
+
+	values, result, err = MGetWithResult[string](context.Background(), rdb, "a", "b")
+	assert.NoError(t, err)
+	assert.False(t, result.HasErrors())
+	assert.Nil(t, result.errs)
+	assert.Nil(t, result.notFound)
+	assert.Len(t, values, 2)
+}
+
+func TestCache_MSetWithResult(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMaxKeyLength(8))
+	result, err := rdb.MSetWithResult(context.Background(), map[string]any{
+		"a":                    "alice",
+		"b":                    "bob",
+		strings.Repeat("k", 9): "too long",
+	})
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+	assert.Equal(t, OutcomeOK, result.Outcome("a"))
+	assert.ErrorIs(t, result.Err(strings.Repeat("k", 9)), ErrKeyTooLong)
+
+	// Keys that failed don't prevent the others from being written
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "b", &val))
+	assert.Equal(t, "bob", val)
+}
+
+func TestCache_MSetWithTTL(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMaxKeyLength(8))
+	require.NoError(t, rdb.MSetWithTTL(context.Background(), map[string]any{
+		"a": "alice",
+		"b": "bob",
+	}, time.Minute))
+	for _, key := range []string{"a", "b"} {
+		assert.Equal(t, time.Minute, server.TTL(key))
+	}
+
+	err := rdb.MSetWithTTL(context.Background(), map[string]any{
+		"c":                    "carol",
+		strings.Repeat("k", 9): "too long",
+		strings.Repeat("x", 9): "too long",
+	}, time.Minute)
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+	assert.ErrorContains(t, err, "2 of 3 keys failed")
+	assert.ErrorContains(t, err, "key "+strings.Repeat("k", 9))
+	assert.ErrorContains(t, err, "key "+strings.Repeat("x", 9))
+
+	// Keys that failed don't prevent the others from being written
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "c", &val))
+	assert.Equal(t, "carol", val)
+	assert.Equal(t, time.Minute, server.TTL("c"))
+}
+
+func TestCache_DeleteWithResult(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	require.NoError(t, rdb.MSet(context.Background(), map[string]any{"a": "alice", "b": "bob"}))
+
+	result, err := rdb.DeleteWithResult(context.Background(), "a", "b", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"missing"}, result.NotFound())
+	assert.Equal(t, OutcomeOK, result.Outcome("a"))
+	assert.False(t, server.Exists("a"))
+	assert.False(t, server.Exists("b"))
+}
+
+func TestOutcome_String(t *testing.T) {
+	assert.Equal(t, "ok", OutcomeOK.String())
+	assert.Equal(t, "not-found", OutcomeNotFound.String())
+	assert.Equal(t, "error", OutcomeError.String())
+	assert.Equal(t, "unknown", Outcome(42).String())
+}
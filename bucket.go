@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// BucketFunc is a function type that determines the key of the hash a field of a
+// bucket is stored in by SetBucketed and GetBucketed.
+type BucketFunc func(bucketKey, field string) string
+
+// HashBuckets returns a BucketFunc that spreads the fields of a bucket across n
+// hashes by appending the FNV-1a hash of the field modulo n to the bucket key.
+// Redis stores small hashes in a compact encoding, so spreading a large family of
+// fields across enough hashes to keep each one small, bounded by the
+// hash-max-listpack-entries configuration, saves significantly more memory than
+// a single large hash.
+//
+// Providing n < 1 panics.
+func HashBuckets(n int) BucketFunc {
+	if n < 1 {
+		panic(fmt.Errorf("HashBuckets requires n >= 1, illegal use of api"))
+	}
+	return func(bucketKey, field string) string {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(field))
+		return bucketKey + ":" + strconv.FormatUint(uint64(h.Sum32()%uint32(n)), 10)
+	}
+}
+
+// bucket returns the key of the hash the field of the bucket is stored in.
+func (c *Cache) bucket(ctx context.Context, bucketKey, field string) (string, error) {
+	key := bucketKey
+	if c.bucketFunc != nil {
+		key = c.bucketFunc(bucketKey, field)
+	}
+	return c.key(ctx, key)
+}
+
+// SetBucketed stores the value as a field of a hash rather than as its own key.
+// For high-cardinality caches of small values, such as thousands of tiny related
+// entries, the overhead of a key per entry often exceeds the size of the values,
+// and grouping them into hashes reduces the key count and memory. The hash is
+// determined by the BucketFunc configured with WithBucketing, which by default
+// uses the bucket key as is. The value is marshalled and compressed like values
+// stored with Set.
+//
+// Redis expires hashes as a whole, so the TTL applies to the entire bucket and
+// is refreshed on every write to it. If the ttl value is <= 0 the TTL of the
+// bucket is left unchanged, and a new bucket is persisted indefinitely.
+func (c *Cache) SetBucketed(ctx context.Context, bucketKey, field string, v any, ttl time.Duration) error {
+	if c.dryRun {
+		return dryRunUnsupported("SetBucketed")
+	}
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	redisKey, err := c.bucket(ctx, bucketKey, field)
+	if err != nil {
+		return err
+	}
+	data, err := c.encode(ctx, bucketKey, v)
+	if err != nil {
+		return err
+	}
+
+	cmds := make(rueidis.Commands, 0, 2)
+	cmds = append(cmds, c.redis.B().Hset().Key(redisKey).FieldValue().FieldValue(field, string(data)).Build())
+	if ttl > 0 {
+		cmds = append(cmds, c.redis.B().Expire().Key(redisKey).Seconds(int64(ttl.Seconds())).Build())
+	}
+	for _, res := range c.redis.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetBucketed retrieves the value stored with SetBucketed for the field of the
+// bucket and unmarshalls the value into v.
+//
+// If the bucket or the field does not exist ErrKeyNotFound will be returned as
+// the error value.
+func (c *Cache) GetBucketed(ctx context.Context, bucketKey, field string, v any) error {
+	redisKey, err := c.bucket(ctx, bucketKey, field)
+	if err != nil {
+		return err
+	}
+	data, err := c.redis.Do(ctx, c.redis.B().Hget().Key(redisKey).Field(field).Build()).AsBytes()
+	if errors.Is(err, rueidis.Nil) {
+		c.hooksMixin.miss(bucketKey)
+		return ErrKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	return c.recordRead(bucketKey, c.decode(ctx, bucketKey, data, v))
+}
+
+// DeleteBucketed removes the fields from the bucket. Buckets are removed by Redis
+// once their last field is removed.
+func (c *Cache) DeleteBucketed(ctx context.Context, bucketKey string, fields ...string) error {
+	if c.dryRun {
+		return dryRunUnsupported("DeleteBucketed")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	// Fields may be spread across hashes, so the fields are grouped by hash
+	grouped := make(map[string][]string)
+	for _, field := range fields {
+		redisKey, err := c.bucket(ctx, bucketKey, field)
+		if err != nil {
+			return err
+		}
+		grouped[redisKey] = append(grouped[redisKey], field)
+	}
+
+	cmds := make(rueidis.Commands, 0, len(grouped))
+	for redisKey, fields := range grouped {
+		cmds = append(cmds, c.redis.B().Hdel().Key(redisKey).Field(fields...).Build())
+	}
+	for _, res := range c.redis.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SetBucketed(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	require.NoError(t, rdb.SetBucketed(context.Background(), "flags", "dark-mode", true, time.Minute))
+	require.NoError(t, rdb.SetBucketed(context.Background(), "flags", "beta", false, 0))
+	assert.Equal(t, []string{"flags"}, server.Keys())
+	assert.Equal(t, time.Minute, server.TTL("flags"))
+
+	var val bool
+	assert.NoError(t, rdb.GetBucketed(context.Background(), "flags", "dark-mode", &val))
+	assert.True(t, val)
+	assert.ErrorIs(t, rdb.GetBucketed(context.Background(), "flags", "missing", &val), ErrKeyNotFound)
+	assert.ErrorIs(t, rdb.GetBucketed(context.Background(), "missing", "beta", &val), ErrKeyNotFound)
+
+	require.NoError(t, rdb.DeleteBucketed(context.Background(), "flags", "dark-mode", "beta"))
+	assert.False(t, server.Exists("flags"))
+}
+
+func TestCache_SetBucketed_HashBuckets(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithBucketing(HashBuckets(4)))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, rdb.SetBucketed(context.Background(), "user", fmt.Sprint(i), i, time.Minute))
+	}
+	keys := server.Keys()
+	assert.Len(t, keys, 4)
+	for _, key := range keys {
+		assert.True(t, strings.HasPrefix(key, "user:"), key)
+	}
+
+	for i := 0; i < 100; i++ {
+		var val int
+		assert.NoError(t, rdb.GetBucketed(context.Background(), "user", fmt.Sprint(i), &val))
+		assert.Equal(t, i, val)
+	}
+
+	fields := make([]string, 100)
+	for i := range fields {
+		fields[i] = fmt.Sprint(i)
+	}
+	require.NoError(t, rdb.DeleteBucketed(context.Background(), "user", fields...))
+	assert.Empty(t, server.Keys())
+
+	assert.Panics(t, func() {
+		HashBuckets(0)
+	})
+}
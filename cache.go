@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/rueidis"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
@@ -15,6 +19,9 @@ const (
 	// Depending on Redis configuration keys may still be evicted if Redis is
 	// under memory pressure in accordance to the eviction policy configured.
 	InfiniteTTL time.Duration = -3
+
+	// NoExpiration is returned by TTL for a key that exists but doesn't expire.
+	NoExpiration time.Duration = -1
 )
 
 var (
@@ -29,13 +36,69 @@ var (
 // The zero-value is not usable, and this type should be instantiated using the
 // New function.
 type Cache struct {
-	redis            rueidis.Client
-	marshaller       Marshaller
-	unmarshaller     Unmarshaller
-	codec            Codec
-	mgetBatch        int // zero-value indicates no batching
-	nearCacheEnabled bool
-	nearCacheTTL     time.Duration
+	redis                rueidis.Client
+	cluster              bool
+	marshaller           Marshaller
+	unmarshaller         Unmarshaller
+	serialization        string
+	codec                Codec
+	readablePrefix       func(v any) string // nil indicates values aren't prefixed
+	negative             *negativeCaching   // nil indicates negative caching is disabled
+	retry                *retryHook         // nil indicates Redis commands aren't retried
+	mgetBatch            int                // zero-value indicates no batching
+	nearCacheEnabled     bool
+	nearCacheTTL         time.Duration
+	nearCacheMode        TrackingMode
+	nearCachePrefix      []string // empty indicates all keys are cached locally
+	nearCacheMaxMem      int      // zero indicates the rueidis default is used
+	nearCacheMaxVal      int      // zero indicates the size of values isn't limited
+	nearCacheLarge       sync.Map // redisKey -> struct{} of values exceeding nearCacheMaxVal
+	nearWriteThrough     bool
+	generation           *generation // nil indicates generation busting is disabled
+	migration            *migration  // nil indicates no migration is in progress
+	schemaMigrations     schemaMigrations
+	validator            Validator
+	validateReads        bool
+	writeTimestamps      bool
+	minFreshness         func(key string) time.Time
+	deleteStale          bool
+	keyTransforms        []KeyTransform // applied in order, empty indicates keys are stored as is
+	compressWhen         CompressionPredicate
+	compressSmaller      bool
+	compressAbove        int
+	streamingCompression bool
+	codecHeader          bool
+	sizeClasses          []SizeClassRule // sorted by MaxSize, unbounded rules last
+	dryRun               bool
+	coercion             bool
+	keySeparator         rune
+	writeLocks           *writeStripes
+	loadFlights          singleflight.Group
+	memory               *memoryStore // non-nil when running in memory
+	idempotencyTTL       time.Duration
+	computeLockTTL       time.Duration
+	streamChunkSize      int
+	scanConcurrency      int
+	contentHasher        ContentHasher
+	bucketFunc           BucketFunc // nil indicates bucket keys are used as is
+	maxKeyLength         int        // zero-value indicates key length isn't limited
+	earlyExpiration      float64    // XFetch beta, <= 0 indicates early expiration is disabled
+	zeroTTLPolicy        ZeroTTLPolicy
+	clock                Clock
+	tags                 map[string]string // added to the context of every command
+	codecs               sync.Map          // name -> Codec registered by Recompress
+	poisonHandler        PoisonHandler
+	captureFn            CaptureFunc   // nil indicates values aren't captured
+	auditFn              AuditLogger   // nil indicates mutations aren't audited
+	writeBatch           *writeBatcher // nil indicates write batching is disabled
+	formatSniffing       bool
+	bare                 bool       // values are stored without a header or compression
+	readFlights          *readGroup // nil indicates concurrent reads aren't coalesced
+	onHit                func(key string)
+	onMiss               func(key string)
+	events               *accessEvents // nil indicates no OnHit or OnMiss callbacks
+	inspector            PreCompressInspector
+	allowedTypes         map[reflect.Type]struct{} // nil indicates all types are allowed
 	hooksMixin
 }
 
@@ -48,24 +111,60 @@ func New(client rueidis.Client, opts ...Option) *Cache {
 		panic(fmt.Errorf("a valid redis client is required, illegal use of api"))
 	}
 	cache := &Cache{
-		redis:        client,
-		marshaller:   DefaultMarshaller(),
-		unmarshaller: DefaultUnmarshaller(),
-		codec:        nopCodec{},
+		redis:           client,
+		cluster:         isCluster(client),
+		marshaller:      DefaultMarshaller(),
+		unmarshaller:    DefaultUnmarshaller(),
+		serialization:   "msgpack",
+		codec:           nopCodec{},
+		idempotencyTTL:  DefaultIdempotencyTTL,
+		computeLockTTL:  DefaultComputeLockTTL,
+		streamChunkSize: DefaultStreamChunkSize,
+		scanConcurrency: DefaultScanConcurrency,
+		contentHasher:   SHA256ContentHasher,
+		clock:           systemClock{},
+		keySeparator:    DefaultKeySeparator,
 	}
 	for _, opt := range opts {
 		opt(cache)
 	}
+	if cache.generation != nil {
+		cache.generation.clock = cache.clock
+	}
+	cache.redis = cache.retryClient(cache.tagClient(client))
 
+	unmarshaller := cache.unmarshaller
+	if cache.coercion && cache.serialization == "json" {
+		unmarshaller = numericCoercion(unmarshaller)
+	}
 	cache.hooksMixin = hooksMixin{
+		serialization: cache.serialization,
+		codec:         codecName(cache.codec),
 		initial: hooks{
 			marshal:    cache.marshaller,
-			unmarshall: cache.unmarshaller,
+			unmarshall: unmarshaller,
 			compress:   cache.codec.Flate,
 			decompress: cache.codec.Deflate,
 		},
 	}
 	cache.chain()
+	if cache.migration != nil {
+		// The target Encoding bypasses the Hook chain, so its errors are reported
+		// separately
+		to := &cache.migration.to
+		to.Marshaller = cache.reportingMarshaller(to.Name, to.Marshaller)
+		to.Unmarshaller = cache.reportingUnmarshaller(to.Name, to.Unmarshaller)
+	}
+
+	if cache.onHit != nil || cache.onMiss != nil {
+		cache.events = newAccessEvents(cache.onHit, cache.onMiss)
+		cache.access = append(cache.access, cache.events)
+		go cache.events.run()
+	}
+	if cache.writeBatch != nil {
+		cache.writeBatch.clock = cache.clock
+		go cache.writeBatch.run(cache)
+	}
 
 	return cache
 }
@@ -74,34 +173,109 @@ func New(client rueidis.Client, opts ...Option) *Cache {
 // unmarshall the value into v.
 //
 // If the key does not exist ErrKeyNotFound will be returned as the error value.
+// A key that exists but holds an empty value is not treated as a miss, the empty
+// value is passed through decompression and unmarshalling like any other value.
 // A non-nil error value will be returned if the operation on the backing Redis
 // fails, or if the value cannot be unmarshalled into the target type.
-func (c *Cache) Get(ctx context.Context, key string, v any) error {
-	var (
-		data []byte
-		err  error
-	)
-
-	cmd := c.redis.B().Get().Key(key)
-	if c.nearCacheEnabled {
-		data, err = c.redis.DoCache(ctx, cmd.Cache(), c.nearCacheTTL).AsBytes()
+//
+// CallOptions such as WithoutNearCache can be provided to change the behavior of
+// this call only.
+func (c *Cache) Get(ctx context.Context, key string, v any, opts ...CallOption) error {
+	_, err := c.get(ctx, key, v, newCallOptions(opts))
+	return err
+}
+
+// GetWithSource behaves like Get but also returns the Source indicating which
+// layer served the value. If the key does not exist or the read fails SourceNone
+// is returned along with the error.
+//
+// GetWithSource is intended for debugging and verifying near caching is working
+// as expected.
+func (c *Cache) GetWithSource(ctx context.Context, key string, v any, opts ...CallOption) (Source, error) {
+	return c.get(ctx, key, v, newCallOptions(opts))
+}
+
+func (c *Cache) get(ctx context.Context, key string, v any, o callOptions) (Source, error) {
+	src, _, err := c.read(ctx, key, v, o)
+	return src, err
+}
+
+// read is like get but also returns the value as stored in Redis. In migration
+// mode the value isn't returned.
+func (c *Cache) read(ctx context.Context, key string, v any, o callOptions) (Source, []byte, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return SourceNone, nil, err
+	}
+	if c.migration != nil {
+		if err := c.getMigrating(ctx, key, redisKey, v); err != nil {
+			return SourceNone, nil, err
+		}
+		return SourceRedis, nil, nil
+	}
+	src, data, err := c.fetch(ctx, key, redisKey, o)
+	if err != nil {
+		return SourceNone, nil, err
+	}
+	if err := c.recordRead(key, c.decode(ctx, key, data, v)); err != nil {
+		return SourceNone, nil, err
+	}
+	return src, data, nil
+}
+
+// fetch reads the value of the key as stored in Redis from the write buffer, the
+// near cache, or Redis, and returns the Source it was read from. If the key does
+// not exist ErrKeyNotFound is returned and the read is recorded as a miss. Reads
+// of keys that exist are recorded by the caller once the value is decoded, see
+// recordRead.
+func (c *Cache) fetch(ctx context.Context, key, redisKey string, o callOptions) (Source, []byte, error) {
+	if data, ok := c.buffered(redisKey); ok {
+		return SourceWriteBuffer, data, nil
+	}
+
+	var res rueidis.RedisResult
+	nearCache := !o.skipNearCache && c.nearCacheable(redisKey)
+	if c.readFlights != nil {
+		// Reads bypassing the near cache don't share a read that may be served
+		// from it
+		flight := redisKey
+		if nearCache {
+			flight = "near:" + redisKey
+		}
+		var err error
+		res, err = c.readFlights.do(ctx, flight, func() rueidis.RedisResult {
+			return c.getRaw(ctx, redisKey, nearCache)
+		})
+		if err != nil {
+			return SourceNone, nil, err
+		}
 	} else {
-		data, err = c.redis.Do(ctx, cmd.Build()).AsBytes()
+		res = c.getRaw(ctx, redisKey, nearCache)
+	}
+	if msg, err := res.ToMessage(); err == nil {
+		c.observeSize(redisKey, msg)
 	}
+	data, err := res.AsBytes()
 	if err != nil {
 		if errors.Is(err, rueidis.Nil) {
-			return ErrKeyNotFound
+			c.hooksMixin.miss(key)
+			return SourceNone, nil, ErrKeyNotFound
 		}
-		return fmt.Errorf("redis: %w", err)
+		return SourceNone, nil, fmt.Errorf("redis: %w", err)
 	}
-	data, err = c.hooksMixin.current.decompress(data)
-	if err != nil {
-		return fmt.Errorf("decompress value: %w", err)
+	if res.IsCacheHit() {
+		return SourceNearCache, data, nil
 	}
-	if err := c.hooksMixin.current.unmarshall(data, v); err != nil {
-		return fmt.Errorf("unmarshall value: %w", err)
+	return SourceRedis, data, nil
+}
+
+// getRaw issues a GET for the key, served from the near cache if nearCache is true.
+func (c *Cache) getRaw(ctx context.Context, redisKey string, nearCache bool) rueidis.RedisResult {
+	cmd := c.redis.B().Get().Key(redisKey)
+	if nearCache {
+		return c.redis.DoCache(ctx, cmd.Cache(), c.nearCacheTTL)
 	}
-	return nil
+	return c.redis.Do(ctx, cmd.Build())
 }
 
 // GetAndUpdateTTL retrieves a value from the Cache for the given key, decompresses
@@ -115,6 +289,9 @@ func (c *Cache) Get(ctx context.Context, key string, v any) error {
 // if the key did not have a TTL previously. If the ttl value is <= 0 the key will
 // be persisted indefinitely.
 func (c *Cache) GetAndUpdateTTL(ctx context.Context, key string, v any, ttl time.Duration) error {
+	if c.dryRun {
+		return dryRunUnsupported("GetAndUpdateTTL")
+	}
 	// This is a bit wonky since tll values are used different in different places
 	// in client and Redis. So here we map InfiniteTTL to 0, so it keeps the same
 	// semantic meaning through the package.
@@ -122,17 +299,22 @@ func (c *Cache) GetAndUpdateTTL(ctx context.Context, key string, v any, ttl time
 		ttl = 0
 	}
 
-	var (
-		val []byte
-		err error
-	)
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return err
+	}
+
+	var val []byte
 
 	// If the TTL is less than or equal to 0 than we fetch the value but remove
 	// the TTL on the key.
 	if ttl <= 0 {
 		cmds := []rueidis.Completed{
-			c.redis.B().Get().Key(key).Build(),
-			c.redis.B().Persist().Key(key).Build(),
+			c.redis.B().Get().Key(redisKey).Build(),
+			c.redis.B().Persist().Key(redisKey).Build(),
 		}
 		var results []rueidis.RedisResult
 		results = c.redis.DoMulti(ctx, cmds...)
@@ -141,6 +323,7 @@ func (c *Cache) GetAndUpdateTTL(ctx context.Context, key string, v any, ttl time
 		val, err = getResult.AsBytes()
 		if err != nil {
 			if errors.Is(err, rueidis.Nil) {
+				c.hooksMixin.miss(key)
 				return ErrKeyNotFound
 			}
 			return fmt.Errorf("redis: %w", err)
@@ -149,29 +332,33 @@ func (c *Cache) GetAndUpdateTTL(ctx context.Context, key string, v any, ttl time
 			return fmt.Errorf("redis: %w", results[1].Error())
 		}
 	} else {
-		cmd := c.redis.B().Getex().Key(key).Ex(ttl).Build()
+		cmd := c.redis.B().Getex().Key(redisKey).Ex(ttl).Build()
 		val, err = c.redis.Do(ctx, cmd).AsBytes()
 
 		if err != nil {
 			if errors.Is(err, rueidis.Nil) {
+				c.hooksMixin.miss(key)
 				return ErrKeyNotFound
 			}
 			return fmt.Errorf("redis: %w", err)
 		}
 	}
 
-	data, err := c.hooksMixin.current.decompress(val)
-	if err != nil {
-		return fmt.Errorf("decompress value: %w", err)
-	}
-	if err := c.hooksMixin.current.unmarshall(data, v); err != nil {
-		return fmt.Errorf("unmarshall value: %w", err)
-	}
-	return nil
+	return c.recordRead(key, c.decode(ctx, key, val, v))
 }
 
 // Keys retrieves all the keys in Redis/Cache
+//
+// When generation busting is enabled only the keys of the current generation are
+// returned. When keys are transformed with WithKeyTransforms or WithKeyCompaction
+// the keys returned are restored to the keys originally provided. While in
+// migration mode the keys the target Encoding is stored under are returned as
+// the key they were written for.
 func (c *Cache) Keys(ctx context.Context) ([]string, error) {
+	if c.generation != nil || len(c.keyTransforms) > 0 || c.migration != nil {
+		return c.ScanKeys(ctx, "*")
+	}
+
 	cursor := uint64(0)
 	keys := make([]string, 0)
 	for {
@@ -193,8 +380,14 @@ func (c *Cache) Keys(ctx context.Context) ([]string, error) {
 
 // ScanKeys allows for scanning keys in Redis using a pattern.
 func (c *Cache) ScanKeys(ctx context.Context, pattern string) ([]string, error) {
+	pattern, restore, err := c.pattern(ctx, pattern)
+	if err != nil {
+		return nil, err
+	}
+
 	cursor := uint64(0)
 	keys := make([]string, 0)
+	seen := make(map[string]struct{})
 	for {
 		result, err := c.redis.Do(ctx, c.redis.B().Scan().Cursor(cursor).
 			Match(pattern).Count(1000).Build()).AsScanEntry()
@@ -203,7 +396,21 @@ func (c *Cache) ScanKeys(ctx context.Context, pattern string) ([]string, error)
 		}
 
 		cursor = result.Cursor
-		keys = append(keys, result.Elements...)
+		for _, redisKey := range result.Elements {
+			// Entries written with the target Encoding are read through the key
+			// they were written for, which may no longer hold the source Encoding
+			// once the migration is complete.
+			if c.migration != nil {
+				redisKey = strings.TrimSuffix(redisKey, c.migration.suffix)
+				if _, ok := seen[redisKey]; ok {
+					continue
+				}
+				seen[redisKey] = struct{}{}
+			}
+			if key, ok := restore(redisKey); ok {
+				keys = append(keys, key)
+			}
+		}
 
 		if cursor == 0 {
 			break
@@ -214,56 +421,131 @@ func (c *Cache) ScanKeys(ctx context.Context, pattern string) ([]string, error)
 }
 
 // Set adds an entry into the cache, or overwrites an entry if the key already
-// existed. If the ttl value is <= 0 the key will be persisted indefinitely.
+// existed. If the ttl value is <= 0 the key will be persisted indefinitely,
+// unless configured otherwise with the WithZeroTTLPolicy Option.
+//
+// If write batching is enabled with WithWriteBatching the entry is buffered and
+// written to Redis by a background flusher.
 func (c *Cache) Set(ctx context.Context, key string, v any, ttl time.Duration) error {
-	data, err := c.hooksMixin.current.marshal(v)
+	defer c.serializeWrites(key)()
+	size, err := c.set(ctx, key, v, ttl)
+	c.audit(ctx, AuditSet, key, size, err)
+	return err
+}
+
+// set stores the entry like Set and returns the size of the value as stored.
+func (c *Cache) set(ctx context.Context, key string, v any, ttl time.Duration) (int, error) {
+	ttl, err := c.resolveTTL(ttl)
 	if err != nil {
-		return fmt.Errorf("marshall value: %w", err)
+		return 0, err
 	}
-	data, err = c.hooksMixin.current.compress(data)
+	redisKey, err := c.key(ctx, key)
 	if err != nil {
-		return fmt.Errorf("compress value: %w", err)
+		return 0, err
+	}
+	if c.dryRun {
+		data, err := c.encode(ctx, key, v)
+		if err != nil {
+			return 0, err
+		}
+		return len(data), newDryRunReport("Set", []DryRunEntry{{Key: key, RedisKey: redisKey, Size: len(data)}})
+	}
+	if c.migration != nil {
+		return 0, c.setMigrating(ctx, key, redisKey, v, ttl, nil)
+	}
+	data, err := c.encode(ctx, key, v)
+	if err != nil {
+		return 0, err
+	}
+	if c.writeBatch != nil && c.writeBatch.enqueue(bufferedWrite{redisKey: redisKey, data: data, ttl: ttl}) {
+		return len(data), nil
 	}
 
-	cmd := c.redis.B().Set().Key(key).Value(string(data))
+	cmd := c.redis.B().Set().Key(redisKey).Value(string(data))
 	if ttl > 0 {
 		cmd.Ex(ttl)
 	}
 
 	err = c.redis.Do(ctx, cmd.Build()).Error()
 	if err != nil {
-		err = fmt.Errorf("redis: %w", err)
+		return len(data), fmt.Errorf("redis: %w", err)
+	}
+	if c.nearWriteThrough && c.nearCacheable(redisKey) {
+		c.refreshNearCache(ctx, redisKey)
+	}
+	return len(data), nil
+}
+
+// refreshNearCache reads the key through the near cache after it was written so
+// the next read is served locally. The write invalidated any stale local copy
+// before its reply was received, so the read caches the value as of the write or
+// newer. Errors are ignored since the write already succeeded and the next read
+// simply goes to Redis.
+func (c *Cache) refreshNearCache(ctx context.Context, redisKey string) {
+	res := c.getRaw(ctx, redisKey, true)
+	if msg, err := res.ToMessage(); err == nil {
+		c.observeSize(redisKey, msg)
 	}
-	return err
 }
 
 // SetIfAbsent adds an entry into the cache only if the key doesn't already exist.
 // The entry is set with the provided TTL and automatically removed from the cache
 // once the TTL is expired. If the ttl value is <= 0 the key will be persisted
-// indefinitely.
+// indefinitely. A tombstone written by negative caching is overwritten as if the
+// key didn't exist.
 func (c *Cache) SetIfAbsent(ctx context.Context, key string, v any, ttl time.Duration) (bool, error) {
-	data, err := c.hooksMixin.current.marshal(v)
+	return c.setIfAbsent(ctx, "SetIfAbsent", key, v, ttl)
+}
+
+// setIfAbsent implements SetIfAbsent and SetNX. The operation name is reported
+// in dry-run mode.
+func (c *Cache) setIfAbsent(ctx context.Context, op, key string, v any, ttl time.Duration) (bool, error) {
+	defer c.serializeWrites(key)()
+	ttl, err := c.resolveTTL(ttl)
 	if err != nil {
-		return false, fmt.Errorf("marshall value: %w", err)
+		return false, err
 	}
-	data, err = c.hooksMixin.current.compress(data)
+	redisKey, err := c.key(ctx, key)
 	if err != nil {
-		return false, fmt.Errorf("compress value: %w", err)
-	}
-
-	cmd := c.redis.B().Set().Key(key).Value(string(data)).Nx()
-	if ttl > 0 {
-		cmd.Ex(ttl)
+		return false, err
 	}
-
-	resp, err := c.redis.Do(ctx, cmd.Build()).ToMessage()
+	data, err := c.encode(ctx, key, v)
 	if err != nil {
-		err = fmt.Errorf("redis: %w", err)
+		return false, err
 	}
-	if resp.IsNil() {
-		return false, nil
+	if c.dryRun {
+		report := newDryRunReport(op, []DryRunEntry{{Key: key, RedisKey: redisKey, Size: len(data)}})
+		existing, err := c.dryRunExisting(ctx, report.Entries)
+		if err != nil {
+			return false, err
+		}
+		if existing > 0 {
+			report.Count, report.Bytes = 0, 0
+		}
+		return false, report
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return false, err
 	}
-	return true, nil
+
+	return c.setAbsent(ctx, redisKey, data, ttl)
+}
+
+// SetNX adds an entry into the cache only if the key doesn't already exist,
+// returning true if the entry was set and false if the key already existed. The
+// value is encoded like Set, so it can be read with Get. The check and the write
+// are a single SET NX command, making SetNX suitable for flags and idempotent
+// initialization where only one of several concurrent callers must succeed.
+// When negative caching is enabled with WithNegativeCaching the check and write
+// are a single script instead, so a tombstone is overwritten as if the key
+// didn't exist.
+//
+// The entry is set with the provided TTL with millisecond precision and
+// automatically removed from the cache once the TTL is expired. If the ttl value
+// is <= 0 the key will be persisted indefinitely, unless configured otherwise
+// with the WithZeroTTLPolicy Option.
+func (c *Cache) SetNX(ctx context.Context, key string, v any, ttl time.Duration) (bool, error) {
+	return c.setIfAbsent(ctx, "SetNX", key, v, ttl)
 }
 
 // SetIfPresent updates an entry into the cache if they key already exists in the
@@ -271,16 +553,35 @@ func (c *Cache) SetIfAbsent(ctx context.Context, key string, v any, ttl time.Dur
 // cache once the TTL is expired. If the ttl value is <= 0 the key will be persisted
 // indefinitely.
 func (c *Cache) SetIfPresent(ctx context.Context, key string, v any, ttl time.Duration) (bool, error) {
-	data, err := c.hooksMixin.current.marshal(v)
+	defer c.serializeWrites(key)()
+	ttl, err := c.resolveTTL(ttl)
 	if err != nil {
-		return false, fmt.Errorf("marshall value: %w", err)
+		return false, err
 	}
-	data, err = c.hooksMixin.current.compress(data)
+	redisKey, err := c.key(ctx, key)
 	if err != nil {
-		return false, fmt.Errorf("compress value: %w", err)
+		return false, err
+	}
+	data, err := c.encode(ctx, key, v)
+	if err != nil {
+		return false, err
+	}
+	if c.dryRun {
+		report := newDryRunReport("SetIfPresent", []DryRunEntry{{Key: key, RedisKey: redisKey, Size: len(data)}})
+		existing, err := c.dryRunExisting(ctx, report.Entries)
+		if err != nil {
+			return false, err
+		}
+		if existing == 0 {
+			report.Count, report.Bytes = 0, 0
+		}
+		return false, report
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return false, err
 	}
 
-	cmd := c.redis.B().Set().Key(key).Value(string(data)).Xx()
+	cmd := c.redis.B().Set().Key(redisKey).Value(string(data)).Xx()
 	if ttl > 0 {
 		cmd.Ex(ttl)
 	}
@@ -302,36 +603,141 @@ func (c *Cache) SetIfPresent(ctx context.Context, key string, v any, ttl time.Du
 // operates using a single atomic command it is the fastest way to bulk write
 // entries to the Cache. It greatly reduces network overhead and latency when
 // compared to calling SET sequentially.
+//
+// MSet doesn't accept a TTL, so the entries are subject to the ZeroTTLPolicy of
+// the Cache. With ZeroTTLDefault the entries are set and expired in a single
+// MULTI/EXEC transaction. If keyvalues is empty MSet is a no-op.
 func (c *Cache) MSet(ctx context.Context, keyvalues map[string]any) error {
+	if len(keyvalues) == 0 {
+		return nil
+	}
+	if c.writeLocks != nil {
+		defer c.serializeWrites(sortedKeys(keyvalues)...)()
+	}
+	sizes := make(map[string]int, len(keyvalues))
+	err := c.mSet(ctx, keyvalues, sizes)
+	if c.auditFn != nil {
+		for k := range keyvalues {
+			c.audit(ctx, AuditMSet, k, sizes[k], err)
+		}
+	}
+	return err
+}
+
+// mSet stores the entries like MSet, recording the size of each value as stored
+// in sizes.
+func (c *Cache) mSet(ctx context.Context, keyvalues map[string]any, sizes map[string]int) error {
+	ttl, err := c.resolveTTL(0)
+	if err != nil {
+		return err
+	}
+
 	// The key and values needs to be processed prior to calling MSet by marshalling
 	// and compressing the values.
 	cmd := c.redis.B().Mset().KeyValue()
+	redisKeys := make([]string, 0, len(keyvalues))
+	var entries []DryRunEntry
 	for k, v := range keyvalues {
-		val, err := c.hooksMixin.current.marshal(v)
+		redisKey, err := c.key(ctx, k)
 		if err != nil {
-			return fmt.Errorf("marshal value: %w", err)
+			return err
 		}
-		val, err = c.hooksMixin.current.compress(val)
+		val, err := c.encode(ctx, k, v)
 		if err != nil {
-			return fmt.Errorf("compress value: %w", err)
+			return err
+		}
+		cmd.KeyValue(redisKey, string(val))
+		redisKeys = append(redisKeys, redisKey)
+		sizes[k] = len(val)
+		if c.dryRun {
+			entries = append(entries, DryRunEntry{Key: k, RedisKey: redisKey, Size: len(val)})
 		}
-		cmd.KeyValue(k, string(val))
+	}
+	if c.dryRun {
+		return newDryRunReport("MSet", entries)
+	}
+	if err := c.flushBuffered(ctx, redisKeys...); err != nil {
+		return err
 	}
 
-	if err := c.redis.Do(ctx, cmd.Build()).Error(); err != nil {
-		return fmt.Errorf("redis: %w", err)
+	if ttl <= 0 {
+		if err := c.redis.Do(ctx, cmd.Build()).Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+		return nil
+	}
+
+	cmds := make(rueidis.Commands, 0, len(redisKeys)+3)
+	cmds = append(cmds, c.redis.B().Multi().Build(), cmd.Build())
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, c.redis.B().Pexpire().Key(redisKey).Milliseconds(ttl.Milliseconds()).Build())
+	}
+	cmds = append(cmds, c.redis.B().Exec().Build())
+	results := c.redis.DoMulti(ctx, cmds...)
+	for _, res := range results {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
 	}
 
+	// Commands failing inside the transaction are reported in the reply of EXEC
+	replies, err := results[len(results)-1].ToArray()
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	for _, reply := range replies {
+		if err := reply.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
 	return nil
 }
 
-// Delete removes entries from the cache for a given set of keys.
+// Delete removes entries from the cache for a given set of keys. If no keys are
+// provided Delete is a no-op.
 func (c *Cache) Delete(ctx context.Context, keys ...string) error {
-	return c.redis.Do(ctx, c.redis.B().Del().Key(keys...).Build()).Error()
+	if len(keys) == 0 {
+		return nil
+	}
+	defer c.serializeWrites(keys...)()
+	err := c.delete(ctx, keys)
+	for _, key := range keys {
+		c.audit(ctx, AuditDelete, key, 0, err)
+	}
+	return err
+}
+
+func (c *Cache) delete(ctx context.Context, keys []string) error {
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		entries := make([]DryRunEntry, len(keys))
+		for i, key := range keys {
+			entries[i] = DryRunEntry{Key: key, RedisKey: redisKeys[i]}
+		}
+		report := newDryRunReport("Delete", entries)
+		if report.Count, err = c.dryRunExisting(ctx, entries); err != nil {
+			return err
+		}
+		return report
+	}
+	if c.migration != nil {
+		return c.deleteMigrating(ctx, redisKeys)
+	}
+	// Buffered writes must be flushed first or they would recreate the keys
+	if err := c.flushBuffered(ctx, redisKeys...); err != nil {
+		return err
+	}
+	return c.redis.Do(ctx, c.redis.B().Del().Key(redisKeys...).Build()).Error()
 }
 
 // Flush flushes the cache deleting all keys/entries.
 func (c *Cache) Flush(ctx context.Context) error {
+	if c.dryRun {
+		return c.dryRunFlush(ctx, "Flush")
+	}
 	return c.redis.Do(ctx, c.redis.B().Flushdb().Sync().Build()).Error()
 }
 
@@ -339,6 +745,9 @@ func (c *Cache) Flush(ctx context.Context) error {
 // that were present when FLUSH ASYNC command was received by Redis will be deleted.
 // Any keys created during asynchronous flush will be unaffected.
 func (c *Cache) FlushAsync(ctx context.Context) error {
+	if c.dryRun {
+		return c.dryRunFlush(ctx, "FlushAsync")
+	}
 	return c.redis.Do(ctx, c.redis.B().Flushdb().Async().Build()).Error()
 }
 
@@ -351,63 +760,183 @@ func (c *Cache) Healthy(ctx context.Context) bool {
 	return c.redis.Do(ctx, c.redis.B().Ping().Build()).Error() == nil
 }
 
-// TTL returns the time to live for a particular key.
+// Exists reports if the key exists in the cache without reading its value, so
+// unlike Get it doesn't transfer, decompress or unmarshal the value. Keys with a
+// write buffered by WithWriteBatching exist. Keys holding a tombstone written
+// by negative caching don't exist.
+func (c *Cache) Exists(ctx context.Context, key string) (bool, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := c.buffered(redisKey); ok {
+		return true, nil
+	}
+	exists, err := c.existing(ctx, []string{redisKey})
+	if err != nil {
+		return false, err
+	}
+	return exists[0], nil
+}
+
+// ExistsMulti reports which of the keys exist in the cache without reading their
+// values. The returned map contains every key provided. The keys are checked
+// with a command per key in a single pipeline, so the keys may hash to different
+// slots on Redis Cluster. Like Exists, keys holding a tombstone don't exist.
+// Providing no keys returns an empty map without calling Redis.
+func (c *Cache) ExistsMulti(ctx context.Context, keys ...string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(keys))
+	if len(keys) == 0 {
+		return exists, nil
+	}
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	pendingKeys := make([]string, 0, len(keys))
+	pending := make([]string, 0, len(keys))
+	for i, redisKey := range redisKeys {
+		if _, ok := c.buffered(redisKey); ok {
+			exists[keys[i]] = true
+			continue
+		}
+		pendingKeys = append(pendingKeys, redisKey)
+		pending = append(pending, keys[i])
+	}
+	found, err := c.existing(ctx, pendingKeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, ok := range found {
+		exists[pending[i]] = ok
+	}
+	return exists, nil
+}
+
+// TTL returns the remaining time to live of the key, which can be used to decide
+// whether to refresh an entry before it expires. The TTL is read with the PTTL
+// command, so it has millisecond precision.
 //
 // If the key doesn't exist ErrKeyNotFound will be returned for the error value.
-// If the key doesn't have a TTL InfiniteTTL will be returned.
+// If the key exists but doesn't expire NoExpiration will be returned.
 func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
-	dur, err := c.redis.Do(ctx, c.redis.B().Ttl().Key(key).Build()).AsInt64() // c.redis.TTL(ctx, key).Result()
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	ms, err := c.redis.Do(ctx, c.redis.B().Pttl().Key(redisKey).Build()).AsInt64()
 	if err != nil {
 		return 0, fmt.Errorf("redis: %w", err)
 	}
 
-	// Redis returns -1 for TTL command to indicate there is no TTL on the key
-	if dur == -1 {
-		return InfiniteTTL, nil
+	// Redis returns -1 for PTTL command to indicate there is no TTL on the key
+	if ms == -1 {
+		return NoExpiration, nil
 	}
-	// Redis returns -2 for TTL command if the key doesn't exist
-	if dur == -2 {
+	// Redis returns -2 for PTTL command if the key doesn't exist
+	if ms == -2 {
 		return 0, ErrKeyNotFound
 	}
-	return time.Duration(dur) * time.Second, nil
+	return time.Duration(ms) * time.Millisecond, nil
 }
 
-// Expire sets a TTL on the given key.
+// Expire sets the TTL of the key without reading or rewriting its value, for
+// example to extend the lifetime of a session on access. The TTL is set with the
+// PEXPIRE command, so it has millisecond precision, and overrides any TTL the
+// key already has. Expire returns true if the key exists and false otherwise.
 //
-// If the key doesn't exist ErrKeyNotFound will be returned for the error value.
-// If the key already has a TTL it will be overridden with ttl value provided.
-//
-// Calling Expire with a non-positive ttl will result in the key being deleted.
-func (c *Cache) Expire(ctx context.Context, key string, ttl time.Duration) error {
-	ok, err := c.redis.Do(ctx, c.redis.B().Expire().Key(key).
-		Seconds(int64(ttl.Seconds())).Build()).AsBool()
+// Calling Expire with a ttl <= 0 returns ErrNoTTL rather than deleting the key,
+// use Delete to remove a key.
+func (c *Cache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if c.dryRun {
+		return false, dryRunUnsupported("Expire")
+	}
+	if ttl <= 0 {
+		return false, ErrNoTTL
+	}
+	redisKey, err := c.key(ctx, key)
 	if err != nil {
-		return fmt.Errorf("redis: %w", err)
+		return false, err
 	}
-	if !ok {
-		return ErrKeyNotFound
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return false, err
 	}
-	return nil
+	ok, err := c.redis.Do(ctx, c.redis.B().Pexpire().Key(redisKey).
+		Milliseconds(ttl.Milliseconds()).Build()).AsBool()
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return ok, nil
+}
+
+// Persist removes the TTL of the key so it never expires, without reading or
+// rewriting its value. Persist returns true if the key exists, regardless of
+// whether it had a TTL, and false otherwise.
+func (c *Cache) Persist(ctx context.Context, key string) (bool, error) {
+	if c.dryRun {
+		return false, dryRunUnsupported("Persist")
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return false, err
+	}
+	// PERSIST replies 0 both for a missing key and a key without a TTL, so the
+	// key is checked for existence in the same pipeline
+	results := c.redis.DoMulti(ctx,
+		c.redis.B().Persist().Key(redisKey).Build(),
+		c.redis.B().Exists().Key(redisKey).Build())
+	for _, res := range results {
+		if err := res.Error(); err != nil {
+			return false, fmt.Errorf("redis: %w", err)
+		}
+	}
+	n, err := results[1].AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return n > 0, nil
 }
 
 // ExtendTTL extends the TTL for the key by the given duration.
 //
-// ExtendTTL retrieves the TTL remaining for the key, adds the duration, and then
-// executes the EXPIRE command to set a new TTL.
+// ExtendTTL retrieves the TTL remaining for the key with millisecond precision,
+// adds the duration, and then executes the PEXPIRE command to set a new TTL. If
+// the key doesn't expire the TTL is set to the duration.
 //
 // If the key doesn't exist ErrKeyNotFound will be returned for the error value.
 func (c *Cache) ExtendTTL(ctx context.Context, key string, dur time.Duration) error {
-	ttl, err := c.redis.Do(ctx, c.redis.B().Ttl().Key(key).Build()).AsInt64()
+	if c.dryRun {
+		return dryRunUnsupported("ExtendTTL")
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	ms, err := c.redis.Do(ctx, c.redis.B().Pttl().Key(redisKey).Build()).AsInt64()
 	if err != nil {
 		return fmt.Errorf("redis: %w", err)
 	}
 
-	// Redis returns -2 for TTL command if the key doesn't exist
-	if ttl == -2 {
+	// Redis returns -2 for PTTL command if the key doesn't exist and -1 if the
+	// key doesn't expire
+	switch ms {
+	case -2:
 		return ErrKeyNotFound
+	case -1:
+		ms = 0
 	}
 
-	return c.Expire(ctx, key, time.Duration(ttl)*time.Second+dur)
+	ok, err := c.Expire(ctx, key, time.Duration(ms)*time.Millisecond+dur)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrKeyNotFound
+	}
+	return nil
 }
 
 // Client returns the underlying Redis client the Cache is wrapping/using.
@@ -431,7 +960,8 @@ func (c *Cache) SetClient(client rueidis.Client) {
 	if client == nil {
 		panic(fmt.Errorf("cannot set client to nil"))
 	}
-	c.redis = client
+	c.redis = c.retryClient(c.tagClient(client))
+	c.cluster = isCluster(client)
 }
 
 // MultiResult is a type representing returning multiple entries from the Cache.
@@ -471,8 +1001,24 @@ func (mr MultiResult[T]) IsEmpty() bool {
 // a MultiResult.
 //
 // If a key doesn't exist in Redis it will not be included in the MultiResult
-// returned. If all keys are not found the MultiResult will be empty.
+// returned. If all keys are not found the MultiResult will be empty. Keys holding
+// an empty value are included in the MultiResult. If no keys are provided an
+// empty MultiResult is returned without sending a command to Redis.
 func MGet[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R], error) {
+	if len(keys) == 0 {
+		return MultiResult[R]{}, nil
+	}
+	if c.migration != nil {
+		return mGetMigrating[R](ctx, c, keys...)
+	}
+	if c.writeBatch != nil {
+		return mGetBuffered[R](ctx, c, keys...)
+	}
+	return mGet[R](ctx, c, keys...)
+}
+
+// mGet implements MGet without consulting the write buffer.
+func mGet[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R], error) {
 
 	// If batching is enabled and the number of keys exceeds the batch size use
 	// multiple MGET commands in a pipeline.
@@ -480,16 +1026,17 @@ func MGet[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R],
 		return mGetBatch[R](ctx, c, keys...)
 	}
 
-	var (
-		results []string
-		err     error
-	)
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
 
-	cmd := c.redis.B().Mget().Key(keys...)
-	if c.nearCacheEnabled {
-		results, err = c.redis.DoCache(ctx, cmd.Cache(), c.nearCacheTTL).AsStrSlice()
+	var results []rueidis.RedisMessage
+	cmd := c.redis.B().Mget().Key(redisKeys...)
+	if c.nearCacheable(redisKeys...) {
+		results, err = c.redis.DoCache(ctx, cmd.Cache(), c.nearCacheTTL).ToArray()
 	} else {
-		results, err = c.redis.Do(ctx, cmd.Build()).AsStrSlice()
+		results, err = c.redis.Do(ctx, cmd.Build()).ToArray()
 	}
 
 	if err != nil {
@@ -497,18 +1044,25 @@ func MGet[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R],
 	}
 	resultMap := make(map[string]R)
 	for i, res := range results {
-		if res == "" {
+		c.observeSize(redisKeys[i], res)
+		if res.IsNil() {
 			// Some or all of the requested keys may not exist. Skip iterations
 			// where the key wasn't found
+			c.hooksMixin.miss(keys[i])
 			continue
 		}
-		data, err := c.hooksMixin.current.decompress([]byte(res))
+		raw, err := res.AsBytes()
 		if err != nil {
-			return nil, fmt.Errorf("decompress value: %w", err)
+			return nil, fmt.Errorf("redis: %w", err)
 		}
 		var val R
-		if err := c.hooksMixin.current.unmarshall(data, &val); err != nil {
-			return nil, fmt.Errorf("unmarshall value to type %T: %w", val, err)
+		err = c.recordRead(keys[i], c.decode(ctx, keys[i], raw, &val))
+		if errors.Is(err, ErrKeyNotFound) {
+			// The value failed read validation or is stale and is treated as a miss
+			continue
+		}
+		if err != nil {
+			return nil, err
 		}
 		resultMap[keys[i]] = val
 	}
@@ -520,17 +1074,23 @@ func MGet[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R],
 // fetching all keys in a single MGET command.
 func mGetBatch[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R], error) {
 
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
 	chunks := chunk(keys, c.mgetBatch)
+	redisChunks := chunk(redisKeys, c.mgetBatch)
 	resultMap := make(map[string]R)
 	var redisResults []rueidis.RedisResult
 
 	// Using near caching/local caching requires handling the calls to rueidis
 	// differently.
-	if c.nearCacheEnabled {
+	if c.nearCacheable(redisKeys...) {
 		cmds := make([]rueidis.CacheableTTL, 0, len(chunks))
 		for i := 0; i < len(chunks); i++ {
 			cmds = append(cmds, rueidis.CacheableTTL{
-				Cmd: c.redis.B().Mget().Key(chunks[i]...).Cache(),
+				Cmd: c.redis.B().Mget().Key(redisChunks[i]...).Cache(),
 				TTL: c.nearCacheTTL,
 			})
 		}
@@ -538,30 +1098,37 @@ func mGetBatch[R any](ctx context.Context, c *Cache, keys ...string) (MultiResul
 	} else {
 		cmds := make([]rueidis.Completed, 0, len(chunks))
 		for i := 0; i < len(chunks); i++ {
-			cmds = append(cmds, c.redis.B().Mget().Key(chunks[i]...).Build())
+			cmds = append(cmds, c.redis.B().Mget().Key(redisChunks[i]...).Build())
 		}
 		redisResults = c.redis.DoMulti(ctx, cmds...)
 	}
 
 	for i := 0; i < len(redisResults); i++ {
-		results, err := redisResults[i].AsStrSlice()
+		results, err := redisResults[i].ToArray()
 		if err != nil {
 			return nil, fmt.Errorf("redis: %w", err)
 		}
 		for j := 0; j < len(results); j++ {
 			res := results[j]
-			if res == "" {
+			c.observeSize(redisChunks[i][j], res)
+			if res.IsNil() {
 				// Some or all of the requested keys may not exist. Skip iterations
 				// where the key wasn't found
+				c.hooksMixin.miss(chunks[i][j])
 				continue
 			}
-			data, err := c.hooksMixin.current.decompress([]byte(res))
+			raw, err := res.AsBytes()
 			if err != nil {
-				return nil, fmt.Errorf("decompress value: %w", err)
+				return nil, fmt.Errorf("redis: %w", err)
 			}
 			var val R
-			if err := c.hooksMixin.current.unmarshall(data, &val); err != nil {
-				return nil, fmt.Errorf("unmarshall value to type %T: %w", val, err)
+			err = c.recordRead(chunks[i][j], c.decode(ctx, chunks[i][j], raw, &val))
+			if errors.Is(err, ErrKeyNotFound) {
+				// The value failed read validation or is stale and is treated as a miss
+				continue
+			}
+			if err != nil {
+				return nil, err
 			}
 			key := chunks[i][j]
 			resultMap[key] = val
@@ -575,8 +1142,31 @@ func mGetBatch[R any](ctx context.Context, c *Cache, keys ...string) (MultiResul
 // the relationship between key -> value is required use MGet instead.
 //
 // MGetValues is useful when you only want to values and want to avoid the
-// overhead of allocating a slice from a MultiResult.
+// overhead of allocating a slice from a MultiResult. If no keys are provided an
+// empty slice is returned without sending a command to Redis.
 func MGetValues[T any](ctx context.Context, c *Cache, keys ...string) ([]T, error) {
+	if len(keys) == 0 {
+		return []T{}, nil
+	}
+	if c.migration != nil || c.writeBatch != nil {
+		var results MultiResult[T]
+		var err error
+		if c.migration != nil {
+			results, err = mGetMigrating[T](ctx, c, keys...)
+		} else {
+			results, err = mGetBuffered[T](ctx, c, keys...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		values := make([]T, 0, len(results))
+		for _, key := range keys {
+			if val, ok := results[key]; ok {
+				values = append(values, val)
+			}
+		}
+		return values, nil
+	}
 
 	// If batching is enabled and the number of keys exceeds the batch size use
 	// multiple MGET commands in a pipeline.
@@ -584,16 +1174,17 @@ func MGetValues[T any](ctx context.Context, c *Cache, keys ...string) ([]T, erro
 		return mGetValuesBatch[T](ctx, c, keys...)
 	}
 
-	var (
-		results []string
-		err     error
-	)
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
 
-	cmd := c.redis.B().Mget().Key(keys...)
-	if c.nearCacheEnabled {
-		results, err = c.redis.DoCache(ctx, cmd.Cache(), c.nearCacheTTL).AsStrSlice()
+	var results []rueidis.RedisMessage
+	cmd := c.redis.B().Mget().Key(redisKeys...)
+	if c.nearCacheable(redisKeys...) {
+		results, err = c.redis.DoCache(ctx, cmd.Cache(), c.nearCacheTTL).ToArray()
 	} else {
-		results, err = c.redis.Do(ctx, cmd.Build()).AsStrSlice()
+		results, err = c.redis.Do(ctx, cmd.Build()).ToArray()
 	}
 
 	if err != nil {
@@ -603,18 +1194,25 @@ func MGetValues[T any](ctx context.Context, c *Cache, keys ...string) ([]T, erro
 	values := make([]T, 0, len(keys))
 	for i := 0; i < len(results); i++ {
 		res := results[i]
-		if res == "" {
+		c.observeSize(redisKeys[i], res)
+		if res.IsNil() {
 			// Some or all of the requested keys may not exist. Skip iterations
 			// where the key wasn't found
+			c.hooksMixin.miss(keys[i])
 			continue
 		}
-		data, err := c.hooksMixin.current.decompress([]byte(res))
+		raw, err := res.AsBytes()
 		if err != nil {
-			return nil, fmt.Errorf("decompress value: %w", err)
+			return nil, fmt.Errorf("redis: %w", err)
 		}
 		var val T
-		if err := c.hooksMixin.current.unmarshall(data, &val); err != nil {
-			return nil, fmt.Errorf("unmarshall value to type %T: %w", val, err)
+		err = c.recordRead(keys[i], c.decode(ctx, keys[i], raw, &val))
+		if errors.Is(err, ErrKeyNotFound) {
+			// The value failed read validation or is stale and is treated as a miss
+			continue
+		}
+		if err != nil {
+			return nil, err
 		}
 		values = append(values, val)
 	}
@@ -622,17 +1220,23 @@ func MGetValues[T any](ctx context.Context, c *Cache, keys ...string) ([]T, erro
 }
 
 func mGetValuesBatch[T any](ctx context.Context, c *Cache, keys ...string) ([]T, error) {
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
 	chunks := chunk(keys, c.mgetBatch)
+	redisChunks := chunk(redisKeys, c.mgetBatch)
 	values := make([]T, 0, len(keys))
 	var redisResults []rueidis.RedisResult
 
 	// Using near caching/local caching requires handling the calls to rueidis
 	// differently.
-	if c.nearCacheEnabled {
+	if c.nearCacheable(redisKeys...) {
 		cmds := make([]rueidis.CacheableTTL, 0, len(chunks))
 		for i := 0; i < len(chunks); i++ {
 			cmds = append(cmds, rueidis.CacheableTTL{
-				Cmd: c.redis.B().Mget().Key(chunks[i]...).Cache(),
+				Cmd: c.redis.B().Mget().Key(redisChunks[i]...).Cache(),
 				TTL: c.nearCacheTTL,
 			})
 		}
@@ -640,30 +1244,37 @@ func mGetValuesBatch[T any](ctx context.Context, c *Cache, keys ...string) ([]T,
 	} else {
 		cmds := make([]rueidis.Completed, 0, len(chunks))
 		for i := 0; i < len(chunks); i++ {
-			cmds = append(cmds, c.redis.B().Mget().Key(chunks[i]...).Build())
+			cmds = append(cmds, c.redis.B().Mget().Key(redisChunks[i]...).Build())
 		}
 		redisResults = c.redis.DoMulti(ctx, cmds...)
 	}
 
 	for i := 0; i < len(redisResults); i++ {
-		results, err := redisResults[i].AsStrSlice()
+		results, err := redisResults[i].ToArray()
 		if err != nil {
 			return nil, fmt.Errorf("redis: %w", err)
 		}
 		for j := 0; j < len(results); j++ {
 			res := results[j]
-			if res == "" {
+			c.observeSize(redisChunks[i][j], res)
+			if res.IsNil() {
 				// Some or all of the requested keys may not exist. Skip iterations
 				// where the key wasn't found
+				c.hooksMixin.miss(chunks[i][j])
 				continue
 			}
-			data, err := c.hooksMixin.current.decompress([]byte(res))
+			raw, err := res.AsBytes()
 			if err != nil {
-				return nil, fmt.Errorf("decompress value: %w", err)
+				return nil, fmt.Errorf("redis: %w", err)
 			}
 			var val T
-			if err := c.hooksMixin.current.unmarshall(data, &val); err != nil {
-				return nil, fmt.Errorf("unmarshall value to type %T: %w", val, err)
+			err = c.recordRead(chunks[i][j], c.decode(ctx, chunks[i][j], raw, &val))
+			if errors.Is(err, ErrKeyNotFound) {
+				// The value failed read validation or is stale and is treated as a miss
+				continue
+			}
+			if err != nil {
+				return nil, err
 			}
 			values = append(values, val)
 		}
@@ -672,6 +1283,70 @@ func mGetValuesBatch[T any](ctx context.Context, c *Cache, keys ...string) ([]T,
 	return values, nil
 }
 
+// MGetEx retrieves multiple keys from Redis, updating the TTL of each key found,
+// and returns a MultiResult. MGetEx is the batch equivalent of GetAndUpdateTTL
+// and pipelines a GETEX command for each key so all the keys are read and have
+// their TTL refreshed in a single round trip.
+//
+// If a key doesn't exist in Redis it will not be included in the MultiResult
+// returned. If the ttl value is <= 0 the keys will be persisted indefinitely.
+func MGetEx[R any](ctx context.Context, c *Cache, ttl time.Duration, keys ...string) (MultiResult[R], error) {
+	if c.dryRun {
+		return nil, dryRunUnsupported("MGetEx")
+	}
+	// Map InfiniteTTL to 0 so it keeps the same semantic meaning as GetAndUpdateTTL
+	if ttl == InfiniteTTL {
+		ttl = 0
+	}
+	if len(keys) == 0 {
+		return MultiResult[R]{}, nil
+	}
+
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.flushBuffered(ctx, redisKeys...); err != nil {
+		return nil, err
+	}
+	if c.migration != nil {
+		return mGetExMigrating[R](ctx, c, ttl, keys, redisKeys)
+	}
+
+	cmds := make(rueidis.Commands, 0, len(keys))
+	for _, key := range redisKeys {
+		cmd := c.redis.B().Getex().Key(key)
+		if ttl > 0 {
+			cmds = append(cmds, cmd.Ex(ttl).Build())
+		} else {
+			cmds = append(cmds, cmd.Persist().Build())
+		}
+	}
+
+	resultMap := make(map[string]R)
+	for i, res := range c.redis.DoMulti(ctx, cmds...) {
+		raw, err := res.AsBytes()
+		if err != nil {
+			if errors.Is(err, rueidis.Nil) {
+				c.hooksMixin.miss(keys[i])
+				continue
+			}
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		var val R
+		err = c.recordRead(keys[i], c.decode(ctx, keys[i], raw, &val))
+		if errors.Is(err, ErrKeyNotFound) {
+			// The value failed read validation or is stale and is treated as a miss
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resultMap[keys[i]] = val
+	}
+	return resultMap, nil
+}
+
 // UpsertCallback is a callback function that is invoked by Upsert. An UpsertCallback
 // is passed if a key was found, the old value (or zero-value if the key wasn't found)
 // and the new value. An UpsertCallback is responsible for determining what value should
@@ -691,6 +1366,10 @@ type UpsertCallback[T any] func(found bool, oldValue T, newValue T) T
 // retryable. To determine if the error is retryable use the IsRetryable function
 // with the returned error.
 //
+// While in migration mode both Encodings of the key are watched, so on Redis
+// Cluster the key and the key of the target Encoding must hash to the same slot,
+// otherwise an error wrapping ErrCrossSlot is returned.
+//
 //	cb := rcache.UpsertCallback[Person](func(found bool, oldValue Person, newValue Person) Person {
 //		fmt.Println(found)
 //		fmt.Println(oldValue)
@@ -707,50 +1386,61 @@ type UpsertCallback[T any] func(found bool, oldValue T, newValue T) T
 //		break
 //	}
 func Upsert[T any](ctx context.Context, c *Cache, key string, val T, cb UpsertCallback[T], ttl time.Duration) error {
+	if c.dryRun {
+		return dryRunUnsupported("Upsert")
+	}
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return err
+	}
+	if c.migration != nil {
+		return upsertMigrating(ctx, c, key, redisKey, val, cb, ttl)
+	}
 
-	err := c.redis.Dedicated(func(client rueidis.DedicatedClient) error {
+	err = c.redis.Dedicated(func(client rueidis.DedicatedClient) error {
 
 		// Watches the key to detect changes during the transaction
-		err := client.Do(ctx, client.B().Watch().Key(key).Build()).Error()
+		err := client.Do(ctx, client.B().Watch().Key(redisKey).Build()).Error()
 		if err != nil {
 			return fmt.Errorf("redis: %w", err)
 		}
 
 		// Attempt to fetch the key from Redis
-		res, err := client.Do(ctx, client.B().Get().Key(key).Build()).AsBytes()
+		res, err := client.Do(ctx, client.B().Get().Key(redisKey).Build()).AsBytes()
 		if err != nil && !errors.Is(err, rueidis.Nil) {
 			return fmt.Errorf("redis: %w", err)
 		}
 		found := !errors.Is(err, rueidis.Nil)
 
-		// Since this all needs to be done in a dedicated connection this code is
-		// duplicated from the Cache.Get method.
 		var oldVal T
 		if found {
-			res, err = c.hooksMixin.current.decompress(res)
-			if err != nil {
-				return fmt.Errorf("decompress value: %w", err)
-			}
-			if err := c.hooksMixin.current.unmarshall(res, &oldVal); err != nil {
-				return fmt.Errorf("unmarshall value: %w", err)
+			// Stale values aren't deleted as the key is watched and is about to be
+			// overwritten anyway
+			err := c.decodeValue(ctx, key, res, &oldVal, false)
+			if errors.Is(err, ErrKeyNotFound) {
+				found = false
+				oldVal = *new(T)
+			} else if err != nil {
+				return err
 			}
 		}
 
 		// Invoke the callback to determine the value that should be set
 		newVal := cb(found, oldVal, val)
 
-		// Since this all needs to be done in a dedicated connection this code is
-		// duplicated from the Cache.Set method.
-		newData, err := c.hooksMixin.current.marshal(newVal)
-		if err != nil {
-			return fmt.Errorf("marshal value: %w", err)
-		}
-		newData, err = c.hooksMixin.current.compress(newData)
+		newData, err := c.encode(ctx, key, newVal)
 		if err != nil {
-			return fmt.Errorf("compress value: %w", err)
+			return err
 		}
 
-		setCmd := client.B().Set().Key(key).Value(string(newData))
+		setCmd := client.B().Set().Key(redisKey).Value(string(newData))
 		if ttl > 0 {
 			setCmd.Ex(ttl)
 		}
@@ -165,6 +165,32 @@ func TestCache_SetIfAbsent(t *testing.T) {
 	assert.Equal(t, "value123", s)
 }
 
+func TestCache_SetNX(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	cache := New(client)
+
+	ok, err := cache.SetNX(ctx, "key", "value", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), server.TTL("key"))
+
+	ok, err = cache.SetNX(ctx, "key", "other", 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	var s string
+	assert.NoError(t, cache.Get(ctx, "key", &s))
+	assert.Equal(t, "value", s)
+
+	ok, err = cache.SetNX(ctx, "ttl", "value", 1500*time.Millisecond)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, server.TTL("ttl"))
+}
+
 func TestCache_SetIfPresent(t *testing.T) {
 	setup()
 	defer tearDown()
@@ -558,6 +584,32 @@ func TestCache_Keys(t *testing.T) {
 	assert.ElementsMatch(t, expected, actual)
 }
 
+func TestCache_Exists(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	cache := New(client)
+	assert.NoError(t, cache.Set(ctx, "key", "value", 0))
+	assert.NoError(t, cache.Set(ctx, "other", "value", 0))
+
+	ok, err := cache.Exists(ctx, "key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = cache.Exists(ctx, "missing")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	exists, err := cache.ExistsMulti(ctx, "key", "missing", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"key": true, "missing": false, "other": true}, exists)
+
+	exists, err = cache.ExistsMulti(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, exists)
+}
+
 func TestCache_TTL(t *testing.T) {
 	setup()
 	defer tearDown()
@@ -572,12 +624,18 @@ func TestCache_TTL(t *testing.T) {
 
 	ttl, err := rdb.TTL(context.Background(), "test-nottl")
 	assert.NoError(t, err)
-	assert.Equal(t, InfiniteTTL, ttl)
+	assert.Equal(t, NoExpiration, ttl)
 
 	ttl, err = rdb.TTL(context.Background(), "test-ttl")
 	assert.NoError(t, err)
 	assert.Equal(t, time.Second*300, ttl)
 
+	err = client.Do(context.Background(), client.B().Set().Key("test-pttl").Value("test").Px(1500*time.Millisecond).Build()).Error()
+	assert.NoError(t, err)
+	ttl, err = rdb.TTL(context.Background(), "test-pttl")
+	assert.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, ttl)
+
 	_, err = rdb.TTL(context.Background(), "random")
 	assert.ErrorIs(t, err, ErrKeyNotFound)
 }
@@ -594,20 +652,49 @@ func TestCache_Expire(t *testing.T) {
 	err = client.Do(context.Background(), client.B().Set().Key("test-ttl").Value("test").Ex(time.Second*300).Build()).Error()
 	assert.NoError(t, err)
 
-	err = rdb.Expire(context.Background(), "test-nottl", time.Second*300)
+	ok, err := rdb.Expire(context.Background(), "test-nottl", time.Second*300)
 	assert.NoError(t, err)
+	assert.True(t, ok)
 	ttl, err := client.Do(context.Background(), client.B().Ttl().Key("test-nottl").Build()).AsInt64()
 	assert.NoError(t, err)
 	assert.Equal(t, int64(300), ttl)
 
-	err = rdb.Expire(context.Background(), "test-ttl", InfiniteTTL)
+	ok, err = rdb.Expire(context.Background(), "test-ttl", 1500*time.Millisecond)
 	assert.NoError(t, err)
-	ttl, err = client.Do(context.Background(), client.B().Ttl().Key("test-ttl").Build()).AsInt64()
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, server.TTL("test-ttl"))
+
+	// Non-positive TTLs are rejected rather than deleting the key
+	for _, ttl := range []time.Duration{0, -time.Second, InfiniteTTL} {
+		ok, err = rdb.Expire(context.Background(), "test-ttl", ttl)
+		assert.ErrorIs(t, err, ErrNoTTL)
+		assert.False(t, ok)
+	}
+	assert.True(t, server.Exists("test-ttl"))
+
+	ok, err = rdb.Expire(context.Background(), "random", time.Second*300)
 	assert.NoError(t, err)
-	assert.Equal(t, int64(-2), ttl)
+	assert.False(t, ok)
+}
 
-	err = rdb.Expire(context.Background(), "random", time.Second*300)
-	assert.ErrorIs(t, err, ErrKeyNotFound)
+func TestCache_Persist(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.NoError(t, rdb.Set(context.Background(), "test-ttl", "test", time.Minute))
+	assert.NoError(t, rdb.Set(context.Background(), "test-nottl", "test", 0))
+
+	for _, key := range []string{"test-ttl", "test-nottl"} {
+		ok, err := rdb.Persist(context.Background(), key)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(0), server.TTL(key))
+	}
+
+	ok, err := rdb.Persist(context.Background(), "random")
+	assert.NoError(t, err)
+	assert.False(t, ok)
 }
 
 func TestCache_ExtendTTL(t *testing.T) {
@@ -626,7 +713,20 @@ func TestCache_ExtendTTL(t *testing.T) {
 	assert.NoError(t, err)
 	ttl, err := client.Do(context.Background(), client.B().Ttl().Key("test-nottl").Build()).AsInt64()
 	assert.NoError(t, err)
-	assert.Equal(t, int64(59), ttl)
+	assert.Equal(t, int64(60), ttl)
+
+	// Extending a key without a TTL by less than a second expires it after the
+	// duration
+	err = client.Do(context.Background(), client.B().Set().Key("test-short").Value("test").Build()).Error()
+	assert.NoError(t, err)
+	assert.NoError(t, rdb.ExtendTTL(context.Background(), "test-short", 500*time.Millisecond))
+	assert.Equal(t, 500*time.Millisecond, server.TTL("test-short"))
+
+	// Sub-second TTLs are preserved
+	err = client.Do(context.Background(), client.B().Set().Key("test-ms").Value("test").Px(1500*time.Millisecond).Build()).Error()
+	assert.NoError(t, err)
+	assert.NoError(t, rdb.ExtendTTL(context.Background(), "test-ms", time.Second))
+	assert.Equal(t, 2500*time.Millisecond, server.TTL("test-ms"))
 
 	err = rdb.ExtendTTL(context.Background(), "test-ttl", time.Second*120)
 	assert.NoError(t, err)
@@ -658,3 +758,104 @@ func TestCache_ScanKeys(t *testing.T) {
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, []string{"system:123", "system:456", "system:789"}, keys)
 }
+
+func TestCache_EmptyValue(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	// Raw serialization stores strings verbatim so an empty string is stored as
+	// an empty value in Redis rather than a msgpack encoded empty string.
+	marshaller := Marshaller(func(v any) ([]byte, error) {
+		return []byte(v.(string)), nil
+	})
+	unmarshaller := Unmarshaller(func(b []byte, v any) error {
+		*(v.(*string)) = string(b)
+		return nil
+	})
+
+	for _, rdb := range []*Cache{New(client), New(client, Serialization(marshaller, unmarshaller))} {
+		err := rdb.Set(context.Background(), "empty", "", 0)
+		assert.NoError(t, err)
+
+		s := "not empty"
+		err = rdb.Get(context.Background(), "empty", &s)
+		assert.NoError(t, err)
+		assert.Equal(t, "", s)
+
+		err = rdb.Get(context.Background(), "missing", &s)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		results, err := MGet[string](context.Background(), rdb, "empty", "missing")
+		assert.NoError(t, err)
+		assert.Equal(t, MultiResult[string]{"empty": ""}, results)
+
+		values, err := MGetValues[string](context.Background(), rdb, "empty", "missing")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{""}, values)
+	}
+}
+
+func TestMGetEx(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.NoError(t, rdb.Set(context.Background(), "session:1", "alice", time.Second*10))
+	assert.NoError(t, rdb.Set(context.Background(), "session:2", "bob", 0))
+
+	results, err := MGetEx[string](context.Background(), rdb, time.Second*300, "session:1", "session:2", "session:3")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"session:1": "alice", "session:2": "bob"}, results)
+
+	for _, key := range []string{"session:1", "session:2"} {
+		ttl, err := client.Do(context.Background(), client.B().Ttl().Key(key).Build()).AsInt64()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(300), ttl)
+	}
+
+	results, err = MGetEx[string](context.Background(), rdb, InfiniteTTL, "session:1")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"session:1": "alice"}, results)
+	ttl, err := client.Do(context.Background(), client.B().Ttl().Key("session:1").Build()).AsInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), ttl)
+}
+
+func TestCache_EmptyBatches(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	configs := map[string][]Option{
+		"default":        nil,
+		"generations":    {WithGenerationBusting()},
+		"write batching": {WithWriteBatching(10, time.Hour)},
+	}
+	for name, opts := range configs {
+		t.Run(name, func(t *testing.T) {
+			rdb := New(client, opts...)
+			commands := server.CommandCount()
+
+			results, err := MGet[string](ctx, rdb)
+			assert.NoError(t, err)
+			assert.NotNil(t, results)
+			assert.True(t, results.IsEmpty())
+
+			values, err := MGetValues[string](ctx, rdb)
+			assert.NoError(t, err)
+			assert.NotNil(t, values)
+			assert.Empty(t, values)
+
+			results, err = MGetEx[string](ctx, rdb, time.Minute)
+			assert.NoError(t, err)
+			assert.True(t, results.IsEmpty())
+
+			assert.NoError(t, rdb.MSet(ctx, nil))
+			assert.NoError(t, rdb.MSet(ctx, map[string]any{}))
+			assert.NoError(t, rdb.Delete(ctx))
+			assert.NoError(t, rdb.Delete(ctx, []string{}...))
+
+			assert.Equal(t, commands, server.CommandCount())
+		})
+	}
+}
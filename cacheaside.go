@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Phases of a cache-aside operation reported to a CacheAsideHook.
+const (
+	// PhaseRead is reading the keys from the Cache.
+	PhaseRead = "read"
+
+	// PhaseLoad is invoking the loader for the keys that missed.
+	PhaseLoad = "load"
+
+	// PhaseWrite is writing the loaded values to the Cache.
+	PhaseWrite = "write"
+)
+
+// CacheAsideResult describes the outcome of a cache-aside operation reported to
+// a CacheAsideHook.
+type CacheAsideResult struct {
+	// Hits is the number of keys read from the Cache.
+	Hits int
+
+	// Misses is the number of keys that weren't read from the Cache, including
+	// keys that failed to be read, and were loaded instead.
+	Misses int
+
+	// LoadDuration is how long loading the missed keys took, or zero if the
+	// loader wasn't invoked.
+	LoadDuration time.Duration
+
+	// Err is the error returned to the caller, if any.
+	Err error
+}
+
+// CacheAsideHook is an optional interface a Hook can implement to observe the
+// full lifecycle of cache-aside operations: GetOrSet, MGetOrLoad,
+// MGetOrLoadBatched, and Cacheable. It is intended for tracing, where the
+// operation is a span with child spans for each phase, so traces show where time
+// went on a miss.
+//
+// StartCacheAside is invoked when an operation starts with its name, and the
+// returned function is invoked with the result once the operation returns.
+// StartCacheAsidePhase is invoked with the context returned by StartCacheAside
+// when each phase starts, and the returned function is invoked with the error of
+// the phase once it completes. The write phase of Cacheable happens in the
+// background and may complete after the operation returns. Phases that aren't
+// needed, such as loading when every key is a hit, are not started.
+//
+// The contexts returned are used for the rest of the operation, including the
+// calls to Redis and the loader, so implementations can thread spans through
+// them.
+type CacheAsideHook interface {
+	StartCacheAside(ctx context.Context, operation string) (context.Context, func(result CacheAsideResult))
+	StartCacheAsidePhase(ctx context.Context, phase string) (context.Context, func(err error))
+}
+
+// startCacheAside starts a cache-aside operation in every CacheAsideHook,
+// returning the function ending it.
+func (hs *hooksMixin) startCacheAside(ctx context.Context, operation string) (context.Context, func(CacheAsideResult)) {
+	if len(hs.cacheAside) == 0 {
+		return ctx, func(CacheAsideResult) {}
+	}
+	ends := make([]func(CacheAsideResult), len(hs.cacheAside))
+	for i, h := range hs.cacheAside {
+		ctx, ends[i] = h.StartCacheAside(ctx, operation)
+	}
+	return ctx, func(result CacheAsideResult) {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](result)
+		}
+	}
+}
+
+// startCacheAsidePhase starts a phase of a cache-aside operation in every
+// CacheAsideHook, returning the function ending it.
+func (hs *hooksMixin) startCacheAsidePhase(ctx context.Context, phase string) (context.Context, func(error)) {
+	if len(hs.cacheAside) == 0 {
+		return ctx, func(error) {}
+	}
+	ends := make([]func(error), len(hs.cacheAside))
+	for i, h := range hs.cacheAside {
+		ctx, ends[i] = h.StartCacheAsidePhase(ctx, phase)
+	}
+	return ctx, func(err error) {
+		for i := len(ends) - 1; i >= 0; i-- {
+			ends[i](err)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package cachehttp provides HTTP middleware that caches GET responses using
+// Cache.
+//
+// Responses are stored with their status, headers, and body using the
+// serialization and compression configured on the Cache, and are served from
+// the cache on subsequent requests until they expire. The TTL is derived from
+// the Cache-Control max-age directive of the response.
+package cachehttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+const (
+	// DefaultMaxBodySize is the default maximum size of a response body that will
+	// be cached.
+	DefaultMaxBodySize = 1 << 20
+
+	// DefaultWriteTimeout is the default timeout for storing a response in the
+	// cache.
+	DefaultWriteTimeout = 200 * time.Millisecond
+)
+
+// Response is a cached HTTP response.
+type Response struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	StoredAt time.Time
+}
+
+// KeyFunc derives the cache key for a request. vary is the list of request
+// headers the key must vary by, as configured with WithVary.
+type KeyFunc func(r *http.Request, vary []string) string
+
+// CacheableFunc determines if a response to a request may be stored in the cache.
+type CacheableFunc func(r *http.Request, status int, header http.Header) bool
+
+// DefaultKey derives a key from the request method, URL, and the values of the
+// request headers in vary.
+func DefaultKey(r *http.Request, vary []string) string {
+	var b strings.Builder
+	b.WriteString("cachehttp:")
+	b.WriteString(r.Method)
+	b.WriteByte(' ')
+	b.WriteString(r.URL.String())
+	for _, name := range vary {
+		b.WriteByte('\n')
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteByte('=')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// DefaultCacheable permits caching successful responses unless the response
+// sets a cookie.
+func DefaultCacheable(r *http.Request, status int, header http.Header) bool {
+	return status == http.StatusOK && header.Get("Set-Cookie") == ""
+}
+
+type config struct {
+	keyFunc       KeyFunc
+	cacheableFunc CacheableFunc
+	vary          []string
+	defaultTTL    time.Duration
+	maxBodySize   int
+	writeTimeout  time.Duration
+}
+
+// Option configures the caching middleware.
+type Option func(c *config)
+
+// WithKeyFunc configures how cache keys are derived from a request. By default,
+// DefaultKey is used.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(c *config) {
+		c.keyFunc = fn
+	}
+}
+
+// WithCacheable configures which responses may be cached. By default,
+// DefaultCacheable is used. Responses prohibited from being cached by their
+// Cache-Control or Vary headers are never cached regardless of the CacheableFunc.
+func WithCacheable(fn CacheableFunc) Option {
+	return func(c *config) {
+		c.cacheableFunc = fn
+	}
+}
+
+// WithVary configures the request headers cached responses vary by. Responses
+// with a Vary header naming a header that isn't configured are not cached since
+// they can't be served correctly.
+func WithVary(headers ...string) Option {
+	return func(c *config) {
+		for _, h := range headers {
+			c.vary = append(c.vary, http.CanonicalHeaderKey(h))
+		}
+	}
+}
+
+// WithDefaultTTL configures the TTL used for responses without a Cache-Control
+// max-age directive. By default, such responses are not cached.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithMaxBodySize configures the maximum size in bytes of a response body that
+// will be cached. Larger responses are served but not cached.
+func WithMaxBodySize(size int) Option {
+	return func(c *config) {
+		c.maxBodySize = size
+	}
+}
+
+// WithWriteTimeout configures the timeout for storing a response in the cache.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.writeTimeout = timeout
+	}
+}
+
+// Middleware returns HTTP middleware that serves GET and HEAD requests from the
+// cache, and stores cacheable responses from the next handler in the cache.
+//
+// Requests with a Cache-Control no-cache or no-store directive bypass the cache
+// and their responses are not stored. Errors reading from the cache are treated
+// as a cache miss, and errors storing a response are logged.
+func Middleware(c *cache.Cache, opts ...Option) func(http.Handler) http.Handler {
+	if c == nil {
+		panic(fmt.Errorf("a valid cache is required, illegal use of api"))
+	}
+	cfg := &config{
+		keyFunc:       DefaultKey,
+		cacheableFunc: DefaultCacheable,
+		maxBodySize:   DefaultMaxBodySize,
+		writeTimeout:  DefaultWriteTimeout,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if directives := parseCacheControl(r.Header); directives.has("no-cache") || directives.has("no-store") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// HEAD requests are served from cached GET responses but never stored
+			// since they don't have a body.
+			getReq := r
+			if r.Method == http.MethodHead {
+				getReq = r.Clone(r.Context())
+				getReq.Method = http.MethodGet
+			}
+			key := cfg.keyFunc(getReq, cfg.vary)
+
+			var resp Response
+			err := c.Get(r.Context(), key, &resp)
+			if err == nil {
+				serve(w, r, resp, c.Clock().Now())
+				return
+			}
+			if !errors.Is(err, cache.ErrKeyNotFound) {
+				slog.Error(fmt.Sprintf("Failed to read cached response for key %s", key),
+					slog.Any("err", err))
+			}
+
+			if r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rec := &recorder{ResponseWriter: w, maxBodySize: cfg.maxBodySize}
+			next.ServeHTTP(rec, r)
+
+			ttl, ok := cfg.ttl(r, rec)
+			if !ok {
+				return
+			}
+			resp = Response{
+				Status:   rec.status,
+				Header:   rec.Header().Clone(),
+				Body:     rec.body.Bytes(),
+				StoredAt: c.Clock().Now(),
+			}
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), cfg.writeTimeout)
+			defer cancel()
+			if err := c.Set(ctx, key, resp, ttl); err != nil {
+				slog.Error(fmt.Sprintf("Failed to cache response for key %s", key),
+					slog.Any("err", err))
+			}
+		})
+	}
+}
+
+// ttl determines if the recorded response may be cached and its TTL.
+func (c *config) ttl(r *http.Request, rec *recorder) (time.Duration, bool) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.overflow {
+		return 0, false
+	}
+	header := rec.Header()
+	if !c.cacheableFunc(r, rec.status, header) {
+		return 0, false
+	}
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" || !c.varies(name) {
+				return 0, false
+			}
+		}
+	}
+
+	directives := parseCacheControl(header)
+	if directives.has("no-store") || directives.has("no-cache") || directives.has("private") {
+		return 0, false
+	}
+	// s-maxage applies to shared caches and takes precedence over max-age
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, ok := directives[name]; ok {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return c.defaultTTL, c.defaultTTL > 0
+}
+
+func (c *config) varies(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	for _, v := range c.vary {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// serve writes a cached response.
+func serve(w http.ResponseWriter, r *http.Request, resp Response, now time.Time) {
+	header := w.Header()
+	for name, values := range resp.Header {
+		header[name] = values
+	}
+	age := int(now.Sub(resp.StoredAt) / time.Second)
+	if age < 0 {
+		age = 0
+	}
+	header.Set("Age", strconv.Itoa(age))
+	w.WriteHeader(resp.Status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(resp.Body)
+	}
+}
+
+// cacheControl holds the directives of a Cache-Control header.
+type cacheControl map[string]string
+
+func (c cacheControl) has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+func parseCacheControl(header http.Header) cacheControl {
+	directives := make(cacheControl)
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
+
+// recorder is a http.ResponseWriter that writes the response to the underlying
+// ResponseWriter while capturing the status and body.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	maxBodySize int
+	overflow    bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	if !r.overflow {
+		if r.body.Len()+len(p) > r.maxBodySize {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (r *recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
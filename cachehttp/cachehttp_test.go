@@ -0,0 +1,162 @@
+package cachehttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+func newTestCache(t *testing.T) (*cache.Cache, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return cache.New(client), server
+}
+
+func TestMiddleware(t *testing.T) {
+	rdb, server := newTestCache(t)
+
+	calls := 0
+	handler := Middleware(rdb, WithVary("Accept-Language"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("hello " + r.Header.Get("Accept-Language")))
+	}))
+
+	do := func(method string, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/greeting?x=1", nil)
+		req.Header.Set("Accept-Language", lang)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "en")
+	assert.Equal(t, "hello en", rec.Body.String())
+	assert.Equal(t, 1, calls)
+	assert.Len(t, server.Keys(), 1)
+	assert.Equal(t, time.Minute, server.TTL(server.Keys()[0]))
+
+	rec = do(http.MethodGet, "en")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hello en", rec.Body.String())
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "0", rec.Header().Get("Age"))
+	assert.Equal(t, 1, calls)
+
+	rec = do(http.MethodHead, "en")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, 1, calls)
+
+	rec = do(http.MethodGet, "fr")
+	assert.Equal(t, "hello fr", rec.Body.String())
+	assert.Equal(t, 2, calls)
+}
+
+func TestMiddleware_NotCacheable(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "No Max Age",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+		{
+			name: "No Store",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-store, max-age=60")
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+		{
+			name: "Private",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "private, max-age=60")
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+		{
+			name: "Unsupported Vary",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("Vary", "Cookie")
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+		{
+			name: "Error Status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "Set Cookie",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				w.Header().Set("Set-Cookie", "session=1")
+				_, _ = w.Write([]byte("hello"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rdb, server := newTestCache(t)
+			handler := Middleware(rdb)(test.handler)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Empty(t, server.Keys())
+		})
+	}
+}
+
+func TestMiddleware_Options(t *testing.T) {
+	rdb, server := newTestCache(t)
+
+	handler := Middleware(rdb,
+		WithDefaultTTL(time.Hour),
+		WithMaxBodySize(4),
+		WithKeyFunc(func(r *http.Request, vary []string) string {
+			return "page:" + r.URL.Path
+		}),
+		WithCacheable(func(r *http.Request, status int, header http.Header) bool {
+			return status == http.StatusOK || status == http.StatusNotFound
+		}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+
+	for _, path := range []string{"/a", "/missing", "/toolong"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.ElementsMatch(t, []string{"page:/a", "page:/missing"}, server.Keys())
+	assert.Equal(t, time.Hour, server.TTL("page:/a"))
+
+	// Requests with no-cache bypass the cache
+	req := httptest.NewRequest(http.MethodGet, "/b", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, server.Exists("page:/b"))
+}
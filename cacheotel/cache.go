@@ -3,6 +3,7 @@ package cacheotel
 import (
 	"context"
 	"fmt"
+	"runtime"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -32,6 +33,20 @@ func InstrumentMetrics(c *cache.Cache, opts ...MetricsOption) error {
 }
 
 func addMetricHook(cache *cache.Cache, conf *config) error {
+	hits, err := conf.meter.Int64Counter("rueidis.cache.hits_total",
+		metric.WithDescription("Count of reads that found the key in the cache"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	misses, err := conf.meter.Int64Counter("rueidis.cache.misses_total",
+		metric.WithDescription("Count of reads that did not find the key in the cache"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
 	serializationTime, err := conf.meter.Float64Histogram("rueidis.cache.serialization_time_seconds",
 		metric.WithDescription("Duration of time in seconds to marshal/unmarshal data"),
 		metric.WithUnit("s"),
@@ -47,6 +62,17 @@ func addMetricHook(cache *cache.Cache, conf *config) error {
 		return err
 	}
 
+	var serializationCPU metric.Float64Histogram
+	if _, supported := threadCPUTime(); conf.cpuTime && supported {
+		serializationCPU, err = conf.meter.Float64Histogram("rueidis.cache.serialization_cpu_time_seconds",
+			metric.WithDescription("CPU time in seconds spent to marshal/unmarshal data"),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(ExponentialBuckets(0.001, 2, 5)...))
+		if err != nil {
+			return err
+		}
+	}
+
 	compressionTime, err := conf.meter.Float64Histogram("rueidis.cache.compression_time_seconds",
 		metric.WithDescription("Duration of time in seconds to compress/decompress data"),
 		metric.WithUnit("s"),
@@ -62,27 +88,179 @@ func addMetricHook(cache *cache.Cache, conf *config) error {
 		return err
 	}
 
+	compressionRatio, err := conf.meter.Float64Histogram("rueidis.cache.compression_ratio",
+		metric.WithDescription("Ratio of the compressed size to the original size of compressed values"),
+		metric.WithUnit("1"),
+		metric.WithExplicitBucketBoundaries(0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1))
+	if err != nil {
+		return err
+	}
+
+	nearCacheSkipped, err := conf.meter.Int64Counter("rueidis.cache.near_cache_skipped_total",
+		metric.WithDescription("Count of reads that bypassed the near cache because the value exceeded the maximum size"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	refreshes, err := conf.meter.Int64Counter("rueidis.cache.refreshes_total",
+		metric.WithDescription("Count of refreshes of registered keys performed by a Scheduler"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return err
+	}
+
+	refreshDuration, err := conf.meter.Float64Histogram("rueidis.cache.refresh_duration_seconds",
+		metric.WithDescription("Duration of time in seconds to refresh registered keys"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+
+	// Hooks aren't provided the context of the operation, so only the tags of the
+	// Cache are recorded rather than the tags carried by the context.
+	attrs := append([]attribute.KeyValue(nil), conf.attrs...)
+	for name, value := range cache.Config().Tags {
+		attrs = append(attrs, attribute.String(name, value))
+	}
+
 	cache.AddHook(&metricsHook{
-		attrs:               conf.attrs,
+		attrs:               attrs,
+		codec:               cache.Config().Compression,
+		counters:            conf.counters,
+		hits:                hits,
+		misses:              misses,
 		serializationTime:   serializationTime,
 		serializationErrors: serializationErrors,
+		serializationCPU:    serializationCPU,
 		compressionTime:     compressionTime,
 		compressionErrors:   compressionErrors,
+		compressionRatio:    compressionRatio,
+		nearCacheSkipped:    nearCacheSkipped,
+		refreshes:           refreshes,
+		refreshDuration:     refreshDuration,
 	})
 	return nil
 }
 
 type metricsHook struct {
 	attrs               []attribute.KeyValue
+	counters            *Counters
+	hits                metric.Int64Counter
+	misses              metric.Int64Counter
 	serializationTime   metric.Float64Histogram
 	serializationErrors metric.Int64Counter
+	serializationCPU    metric.Float64Histogram // nil unless CPU time is recorded
 	compressionTime     metric.Float64Histogram
 	compressionErrors   metric.Int64Counter
+	compressionRatio    metric.Float64Histogram
+	nearCacheSkipped    metric.Int64Counter
+	refreshes           metric.Int64Counter
+	refreshDuration     metric.Float64Histogram
+	codec               string // name of the Codec values are compressed with
+}
+
+func (m *metricsHook) Hit(_ string) {
+	m.hits.Add(context.Background(), 1, metric.WithAttributes(m.attrs...))
+	if m.counters != nil {
+		m.counters.hits.Add(1)
+	}
+}
+
+func (m *metricsHook) Miss(_ string) {
+	m.misses.Add(context.Background(), 1, metric.WithAttributes(m.attrs...))
+	if m.counters != nil {
+		m.counters.misses.Add(1)
+	}
+}
+
+func (m *metricsHook) NearCacheSkipped(_ string) {
+	m.nearCacheSkipped.Add(context.Background(), 1, metric.WithAttributes(m.attrs...))
+	if m.counters != nil {
+		m.counters.nearCacheSkipped.Add(1)
+	}
+}
+
+// Refreshed records refreshes performed by a Scheduler tagged with whether every
+// key was refreshed successfully.
+func (m *metricsHook) Refreshed(_ int, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	attrs := make([]attribute.KeyValue, 0, len(m.attrs)+1)
+	attrs = append(attrs, m.attrs...)
+	attrs = append(attrs, attribute.String("result", result))
+	m.refreshes.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+	m.refreshDuration.Record(context.Background(), duration.Seconds(), metric.WithAttributes(m.attrs...))
+}
+
+// SerializationError records serialization errors tagged with the name of the
+// serialization that failed, including serializations bypassing the Hook chain
+// such as the target Encoding of a migration.
+func (m *metricsHook) SerializationError(serialization string, operation string) {
+	attrs := make([]attribute.KeyValue, 0, len(m.attrs)+2)
+	attrs = append(attrs, m.attrs...)
+	attrs = append(attrs,
+		attribute.String("operation", operation),
+		attribute.String("serialization", serialization))
+	m.serializationErrors.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+	if m.counters != nil {
+		m.counters.serializationErrors.Add(1)
+	}
+}
+
+// CompressionError records compression errors tagged with the name of the Codec
+// that failed, including Codecs recorded in the header of values compressed with
+// a different Codec than configured.
+func (m *metricsHook) CompressionError(codec string, operation string) {
+	attrs := make([]attribute.KeyValue, 0, len(m.attrs)+2)
+	attrs = append(attrs, m.attrs...)
+	attrs = append(attrs,
+		attribute.String("operation", operation),
+		attribute.String("codec", codec))
+	m.compressionErrors.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+	if m.counters != nil {
+		m.counters.compressionErrors.Add(1)
+	}
+}
+
+// cpuTimer is a measurement of the CPU time of the OS thread started by
+// startCPUTimer.
+type cpuTimer struct {
+	start   time.Duration
+	started bool
+}
+
+// startCPUTimer locks the goroutine to its OS thread and starts measuring the
+// CPU time of the thread, if CPU time is recorded. recordCPUTime must be called
+// with the returned cpuTimer to unlock the thread.
+func (m *metricsHook) startCPUTimer() cpuTimer {
+	if m.serializationCPU == nil {
+		return cpuTimer{}
+	}
+	runtime.LockOSThread()
+	start, _ := threadCPUTime()
+	return cpuTimer{start: start, started: true}
+}
+
+// recordCPUTime records the CPU time of the OS thread since startCPUTimer and
+// unlocks the goroutine from its thread.
+func (m *metricsHook) recordCPUTime(t cpuTimer, attrs []attribute.KeyValue) {
+	if !t.started {
+		return
+	}
+	end, ok := threadCPUTime()
+	runtime.UnlockOSThread()
+	if ok {
+		m.serializationCPU.Record(context.Background(), (end - t.start).Seconds(), metric.WithAttributes(attrs...))
+	}
 }
 
 func (m *metricsHook) MarshalHook(next cache.Marshaller) cache.Marshaller {
 	return func(v any) ([]byte, error) {
 		start := time.Now()
+		cpu := m.startCPUTimer()
 
 		data, err := next(v)
 
@@ -93,9 +271,10 @@ func (m *metricsHook) MarshalHook(next cache.Marshaller) cache.Marshaller {
 		attrs = append(attrs, attribute.String("operation", "marshal"))
 
 		m.serializationTime.Record(context.Background(), dur, metric.WithAttributes(attrs...))
+		m.recordCPUTime(cpu, attrs)
 
-		if err != nil {
-			m.serializationErrors.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+		if m.counters != nil {
+			m.counters.marshals.Add(1)
 		}
 
 		return data, err
@@ -105,6 +284,7 @@ func (m *metricsHook) MarshalHook(next cache.Marshaller) cache.Marshaller {
 func (m *metricsHook) UnmarshallHook(next cache.Unmarshaller) cache.Unmarshaller {
 	return func(b []byte, v any) error {
 		start := time.Now()
+		cpu := m.startCPUTimer()
 
 		err := next(b, v)
 
@@ -115,9 +295,10 @@ func (m *metricsHook) UnmarshallHook(next cache.Unmarshaller) cache.Unmarshaller
 		attrs = append(attrs, attribute.String("operation", "unmarshal"))
 
 		m.serializationTime.Record(context.Background(), dur, metric.WithAttributes(attrs...))
+		m.recordCPUTime(cpu, attrs)
 
-		if err != nil {
-			m.serializationErrors.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+		if m.counters != nil {
+			m.counters.unmarshals.Add(1)
 		}
 
 		return err
@@ -138,8 +319,16 @@ func (m *metricsHook) CompressHook(next cache.CompressionHook) cache.Compression
 
 		m.compressionTime.Record(context.Background(), dur, metric.WithAttributes(attrs...))
 
-		if err != nil {
-			m.compressionErrors.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+		// Values aren't compressed when compression is disabled, so there is no
+		// ratio to record.
+		if err == nil && m.codec != "none" && len(data) > 0 {
+			ratio := float64(len(compressed)) / float64(len(data))
+			ratioAttrs := append(attrs, attribute.String("codec", m.codec))
+			m.compressionRatio.Record(context.Background(), ratio, metric.WithAttributes(ratioAttrs...))
+		}
+
+		if m.counters != nil {
+			m.counters.compressions.Add(1)
 		}
 
 		return compressed, err
@@ -160,8 +349,8 @@ func (m *metricsHook) DecompressHook(next cache.CompressionHook) cache.Compressi
 
 		m.compressionTime.Record(context.Background(), dur, metric.WithAttributes(attrs...))
 
-		if err != nil {
-			m.compressionErrors.Add(context.Background(), 1, metric.WithAttributes(attrs...))
+		if m.counters != nil {
+			m.counters.decompressions.Add(1)
 		}
 
 		return decompressed, err
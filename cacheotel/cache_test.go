@@ -0,0 +1,185 @@
+package cacheotel
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	cache "github.com/jkratz55/rueidis-cache"
+	"github.com/jkratz55/rueidis-cache/compression/lz4"
+)
+
+func newTestCache(t *testing.T, opts ...cache.Option) *cache.Cache {
+	server := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return cache.New(client, opts...)
+}
+
+func TestInstrumentMetrics_Counters(t *testing.T) {
+	rdb := newTestCache(t, cache.LZ4())
+
+	counters := &Counters{}
+	require.NoError(t, InstrumentMetrics(rdb, WithCounters(counters)))
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+
+	var s string
+	assert.NoError(t, rdb.Get(context.Background(), "key", &s))
+	assert.ErrorIs(t, rdb.Get(context.Background(), "missing", &s), cache.ErrKeyNotFound)
+	assert.ErrorIs(t, rdb.Get(context.Background(), "missing", &s), cache.ErrKeyNotFound)
+
+	assert.Equal(t, CounterSnapshot{
+		Hits:           1,
+		Misses:         2,
+		Marshals:       1,
+		Unmarshals:     1,
+		Compressions:   1,
+		Decompressions: 1,
+	}, counters.Snapshot())
+
+	counters.Reset()
+	assert.Equal(t, CounterSnapshot{}, counters.Snapshot())
+}
+
+func TestInstrumentMetrics_CompressionRatio(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	rdb := newTestCache(t, cache.LZ4())
+	require.NoError(t, InstrumentMetrics(rdb, WithMeterProvider(provider)))
+	assert.NoError(t, rdb.Set(context.Background(), "key", strings.Repeat("compressible ", 100), 0))
+
+	// Values of a Cache without compression aren't recorded
+	uncompressed := newTestCache(t)
+	require.NoError(t, InstrumentMetrics(uncompressed, WithMeterProvider(provider)))
+	assert.NoError(t, uncompressed.Set(context.Background(), "key", "value", 0))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var found bool
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "rueidis.cache.compression_ratio" {
+				continue
+			}
+			found = true
+			hist := m.Data.(metricdata.Histogram[float64])
+			require.Len(t, hist.DataPoints, 1)
+			dp := hist.DataPoints[0]
+			assert.Equal(t, uint64(1), dp.Count)
+			assert.Less(t, dp.Sum, 0.5)
+
+			val, ok := dp.Attributes.Value(attribute.Key("codec"))
+			assert.True(t, ok)
+			assert.Equal(t, "lz4", val.AsString())
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestInstrumentMetrics_ErrorsByCodec(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	rdb := cache.New(client, cache.LZ4(),
+		cache.WithMigrationMode(cache.MsgpackEncoding(), cache.JSONEncoding()))
+	counters := &Counters{}
+	require.NoError(t, InstrumentMetrics(rdb, WithMeterProvider(provider), WithCounters(counters)))
+
+	// The target Encoding of the migration bypasses the Hook chain, but its
+	// errors are still recorded with its name
+	compressed, err := lz4.NewCodec().Flate([]byte("{not json"))
+	require.NoError(t, err)
+	require.NoError(t, server.Set("key:json", string(compressed)))
+	var s string
+	assert.Error(t, rdb.Get(context.Background(), "key", &s))
+
+	require.NoError(t, server.Set("corrupt:json", "not lz4"))
+	assert.Error(t, rdb.Get(context.Background(), "corrupt", &s))
+
+	snapshot := counters.Snapshot()
+	assert.Equal(t, int64(1), snapshot.SerializationErrors)
+	assert.Equal(t, int64(1), snapshot.CompressionErrors)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	found := map[string]string{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case "rueidis.cache.serialization_errors_total", "rueidis.cache.compression_errors_total":
+				sum := m.Data.(metricdata.Sum[int64])
+				require.Len(t, sum.DataPoints, 1)
+				attrs := sum.DataPoints[0].Attributes
+				if val, ok := attrs.Value(attribute.Key("serialization")); ok {
+					found[m.Name] = val.AsString()
+				}
+				if val, ok := attrs.Value(attribute.Key("codec")); ok {
+					found[m.Name] = val.AsString()
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"rueidis.cache.serialization_errors_total": "json",
+		"rueidis.cache.compression_errors_total":   "lz4",
+	}, found)
+}
+
+func TestInstrumentMetrics_SerializationCPUTime(t *testing.T) {
+	rdb := newTestCache(t)
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	require.NoError(t, InstrumentMetrics(rdb, WithMeterProvider(provider), WithSerializationCPUTime()))
+
+	require.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	var s string
+	require.NoError(t, rdb.Get(context.Background(), "key", &s))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	operations := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "rueidis.cache.serialization_cpu_time_seconds" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				val, _ := dp.Attributes.Value(attribute.Key("operation"))
+				operations[val.AsString()] = dp.Count
+			}
+		}
+	}
+	if _, supported := threadCPUTime(); !supported {
+		assert.Empty(t, operations)
+		return
+	}
+	assert.Equal(t, map[string]uint64{"marshal": 1, "unmarshal": 1}, operations)
+}
@@ -70,6 +70,19 @@ func newInstrumentingHook(conf *config) (*instrumentingHook, error) {
 	}, nil
 }
 
+// attributes returns the attributes to record for the command, including the
+// tags carried by the context so commands can be correlated with the Cache
+// operation that issued them.
+func (i *instrumentingHook) attributes(ctx context.Context, command string) []attribute.KeyValue {
+	tags := cache.TagsFromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(i.attrs)+len(tags)+1)
+	attrs = append(attrs, i.attrs...)
+	for name, value := range tags {
+		attrs = append(attrs, attribute.String(name, value))
+	}
+	return append(attrs, attribute.String("command", command))
+}
+
 func (i *instrumentingHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) (resp rueidis.RedisResult) {
 	cmdName := cmd.Commands()[0]
 
@@ -77,9 +90,7 @@ func (i *instrumentingHook) Do(client rueidis.Client, ctx context.Context, cmd r
 	resp = client.Do(ctx, cmd)
 	dur := time.Since(start)
 
-	attrs := make([]attribute.KeyValue, len(i.attrs)+1)
-	copy(attrs, i.attrs)
-	attrs[len(attrs)-1] = attribute.String("command", cmdName)
+	attrs := i.attributes(ctx, cmdName)
 	i.cmdDuration.Record(ctx, dur.Seconds(), metric.WithAttributes(attrs...))
 
 	if resp.Error() != nil {
@@ -98,9 +109,7 @@ func (i *instrumentingHook) DoMulti(client rueidis.Client, ctx context.Context,
 	resps = client.DoMulti(ctx, multi...)
 	dur := time.Since(start)
 
-	attrs := make([]attribute.KeyValue, len(i.attrs)+1)
-	copy(attrs, attrs)
-	attrs[len(attrs)-1] = attribute.String("command", "pipeline")
+	attrs := i.attributes(ctx, "pipeline")
 	i.cmdDuration.Record(ctx, dur.Seconds(), metric.WithAttributes(attrs...))
 
 	return resps
@@ -113,9 +122,7 @@ func (i *instrumentingHook) DoCache(client rueidis.Client, ctx context.Context,
 	resp = client.DoCache(ctx, cmd, ttl)
 	dur := time.Since(start)
 
-	attrs := make([]attribute.KeyValue, len(i.attrs)+1)
-	copy(attrs, i.attrs)
-	attrs[len(attrs)-1] = attribute.String("command", cmdName)
+	attrs := i.attributes(ctx, cmdName)
 	i.cmdDuration.Record(ctx, dur.Seconds(), metric.WithAttributes(attrs...))
 
 	if resp.Error() != nil {
@@ -137,9 +144,7 @@ func (i *instrumentingHook) DoMultiCache(client rueidis.Client, ctx context.Cont
 	resps = client.DoMultiCache(ctx, multi...)
 	dur := time.Since(start)
 
-	attrs := make([]attribute.KeyValue, len(i.attrs)+1)
-	copy(attrs, i.attrs)
-	attrs[len(attrs)-1] = attribute.String("command", "pipeline")
+	attrs := i.attributes(ctx, "pipeline")
 	i.cmdDuration.Record(ctx, dur.Seconds(), metric.WithAttributes(attrs...))
 
 	return resps
@@ -0,0 +1,116 @@
+package cacheotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+func TestInstrumentClient_PoolName(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client, err = InstrumentClient(client, WithMeterProvider(provider), WithPoolName("sessions"))
+	require.NoError(t, err)
+
+	assert.NoError(t, client.Do(context.Background(), client.B().Ping().Build()).Error())
+	for _, res := range client.DoMulti(context.Background(), client.B().Ping().Build(), client.B().Ping().Build()) {
+		assert.NoError(t, res.Error())
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var found bool
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "rueidis.command.duration_seconds" {
+				continue
+			}
+			found = true
+			hist := m.Data.(metricdata.Histogram[float64])
+			assert.Len(t, hist.DataPoints, 2)
+			for _, dp := range hist.DataPoints {
+				val, ok := dp.Attributes.Value(attribute.Key("pool.name"))
+				assert.True(t, ok)
+				assert.Equal(t, "sessions", val.AsString())
+
+				val, ok = dp.Attributes.Value(attribute.Key("db.system"))
+				assert.True(t, ok)
+				assert.Equal(t, "redis", val.AsString())
+			}
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestInstrumentClient_Tags(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client, err = InstrumentClient(client, WithMeterProvider(provider))
+	require.NoError(t, err)
+
+	c := cache.New(client, cache.WithTags(map[string]string{"cache": "users"}))
+	require.NoError(t, InstrumentMetrics(c, WithMeterProvider(provider)))
+
+	ctx := cache.ContextWithTags(context.Background(), map[string]string{"operation": "profile"})
+	require.NoError(t, c.Set(ctx, "key", "value", 0))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var found int
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch m.Name {
+			case "rueidis.command.duration_seconds":
+				found++
+				hist := m.Data.(metricdata.Histogram[float64])
+				require.Len(t, hist.DataPoints, 1)
+				attrs := hist.DataPoints[0].Attributes
+				val, ok := attrs.Value(attribute.Key("cache"))
+				assert.True(t, ok)
+				assert.Equal(t, "users", val.AsString())
+				val, ok = attrs.Value(attribute.Key("operation"))
+				assert.True(t, ok)
+				assert.Equal(t, "profile", val.AsString())
+			case "rueidis.cache.serialization_time_seconds":
+				found++
+				hist := m.Data.(metricdata.Histogram[float64])
+				require.Len(t, hist.DataPoints, 1)
+				val, ok := hist.DataPoints[0].Attributes.Value(attribute.Key("cache"))
+				assert.True(t, ok)
+				assert.Equal(t, "users", val.AsString())
+			}
+		}
+	}
+	assert.Equal(t, 2, found)
+}
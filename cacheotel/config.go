@@ -4,30 +4,40 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type config struct {
-	dbSystem      string
-	attrs         []attribute.KeyValue
-	meterProvider metric.MeterProvider
-	meter         metric.Meter
-	poolName      string
-	buckets       []float64
+	dbSystem       string
+	attrs          []attribute.KeyValue
+	meterProvider  metric.MeterProvider
+	meter          metric.Meter
+	tracerProvider trace.TracerProvider
+	poolName       string
+	buckets        []float64
+	counters       *Counters
+	cpuTime        bool
 }
 
 func newConfig(opts ...baseOption) *config {
 	conf := &config{
-		dbSystem:      "redis",
-		attrs:         []attribute.KeyValue{},
-		meterProvider: otel.GetMeterProvider(),
-		buckets:       ExponentialBuckets(0.001, 2, 10), // 1ms, 2ms, 4ms, 8ms, 16ms, 32ms, 64ms, 128ms, 256ms, 512ms
+		dbSystem:       "redis",
+		attrs:          []attribute.KeyValue{},
+		meterProvider:  otel.GetMeterProvider(),
+		tracerProvider: otel.GetTracerProvider(),
+		buckets:        ExponentialBuckets(0.001, 2, 10), // 1ms, 2ms, 4ms, 8ms, 16ms, 32ms, 64ms, 128ms, 256ms, 512ms
 	}
 
 	for _, opt := range opts {
 		opt.apply(conf)
 	}
 
-	conf.attrs = append(conf.attrs, attribute.String("db.system", conf.dbSystem))
+	if conf.dbSystem != "" {
+		conf.attrs = append(conf.attrs, attribute.String("db.system", conf.dbSystem))
+	}
+	if conf.poolName != "" {
+		conf.attrs = append(conf.attrs, attribute.String("pool.name", conf.poolName))
+	}
 	return conf
 }
 
@@ -48,18 +58,40 @@ func (fn option) apply(conf *config) {
 
 func (fn option) metrics() {}
 
-func WithAtributes(atts ...attribute.KeyValue) Option {
+// WithAttributes adds attributes to all the metrics. Attributes are appended to
+// the attributes added by previous options and the default db.system attribute,
+// so a single attribute can be added without losing the defaults.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
 	return option(func(conf *config) {
-		conf.attrs = atts
+		conf.attrs = append(conf.attrs, attrs...)
 	})
 }
 
+// WithAtributes adds attributes to all the metrics.
+//
+// Deprecated: Use WithAttributes instead.
+func WithAtributes(atts ...attribute.KeyValue) Option {
+	return WithAttributes(atts...)
+}
+
+// WithDBSystem sets the value of the db.system attribute added to all the
+// metrics, which defaults to redis. Providing an empty string omits the
+// db.system attribute.
 func WithDBSystem(system string) Option {
 	return option(func(conf *config) {
 		conf.dbSystem = system
 	})
 }
 
+// WithPoolName sets the name of the connection pool, which is added to all the
+// metrics as the pool.name attribute. This allows distinguishing the metrics of
+// multiple clients or Cache instances in the same application.
+func WithPoolName(name string) Option {
+	return option(func(conf *config) {
+		conf.poolName = name
+	})
+}
+
 type MetricsOption interface {
 	baseOption
 	metrics()
@@ -86,3 +118,56 @@ func WithExplicitBucketBoundaries(boundaries []float64) MetricsOption {
 		conf.buckets = boundaries
 	})
 }
+
+// WithCounters configures the metrics hook to also tally measurements in the
+// provided Counters. This allows asserting instrumentation in tests without a
+// full MeterProvider.
+func WithCounters(counters *Counters) MetricsOption {
+	return metricOption(func(conf *config) {
+		conf.counters = counters
+	})
+}
+
+// WithSerializationCPUTime configures the metrics hook to also record the CPU
+// time spent marshalling and unmarshalling values in the
+// rueidis.cache.serialization_cpu_time_seconds histogram, alongside the wall
+// time recorded in rueidis.cache.serialization_time_seconds. Under scheduler
+// contention wall time includes time the goroutine spent waiting to run, so
+// comparing the two distinguishes genuinely expensive serialization from
+// scheduling delays.
+//
+// CPU time is measured with the CPU clock of the OS thread, so the goroutine is
+// locked to its thread for the duration of each marshal and unmarshal. This adds
+// two clock_gettime system calls per operation, typically well under a
+// microsecond, and prevents the scheduler from migrating the goroutine while it
+// serializes. CPU time is only supported on Linux. On other platforms the
+// option is a no-op and the histogram is not recorded. The resolution of the
+// thread CPU clock depends on the kernel, so serialization of small values may
+// be recorded as zero.
+func WithSerializationCPUTime() MetricsOption {
+	return metricOption(func(conf *config) {
+		conf.cpuTime = true
+	})
+}
+
+// TracingOption configures the tracing instrumentation of InstrumentTracing.
+type TracingOption interface {
+	baseOption
+	tracing()
+}
+
+type tracingOption func(conf *config)
+
+func (t tracingOption) apply(conf *config) {
+	t(conf)
+}
+
+func (t tracingOption) tracing() {}
+
+// WithTracerProvider sets the TracerProvider used to create spans, which
+// defaults to the global TracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) TracingOption {
+	return tracingOption(func(conf *config) {
+		conf.tracerProvider = tp
+	})
+}
@@ -0,0 +1,27 @@
+package cacheotel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestNewConfig_Attributes(t *testing.T) {
+	conf := newConfig()
+	assert.Equal(t, []attribute.KeyValue{attribute.String("db.system", "redis")}, conf.attrs)
+
+	conf = newConfig(
+		WithAttributes(attribute.String("service", "api")),
+		WithAttributes(attribute.String("region", "us-east-1")),
+		WithPoolName("primary"))
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("service", "api"),
+		attribute.String("region", "us-east-1"),
+		attribute.String("db.system", "redis"),
+		attribute.String("pool.name", "primary"),
+	}, conf.attrs)
+
+	conf = newConfig(WithAtributes(attribute.String("service", "api")), WithDBSystem(""))
+	assert.Equal(t, []attribute.KeyValue{attribute.String("service", "api")}, conf.attrs)
+}
@@ -0,0 +1,74 @@
+package cacheotel
+
+import (
+	"sync/atomic"
+)
+
+// Counters tallies the measurements recorded by the metrics hook in memory. It
+// is primarily intended for asserting instrumentation in unit tests without
+// setting up a MeterProvider and reader.
+//
+// The zero-value is ready to use, and Counters is safe for concurrent use.
+type Counters struct {
+	hits                atomic.Int64
+	misses              atomic.Int64
+	marshals            atomic.Int64
+	unmarshals          atomic.Int64
+	compressions        atomic.Int64
+	decompressions      atomic.Int64
+	serializationErrors atomic.Int64
+	compressionErrors   atomic.Int64
+	invalidations       atomic.Int64
+	nearCacheSkipped    atomic.Int64
+}
+
+// CounterSnapshot is a point-in-time copy of the values tallied by Counters.
+type CounterSnapshot struct {
+	Hits                int64
+	Misses              int64
+	Marshals            int64
+	Unmarshals          int64
+	Compressions        int64
+	Decompressions      int64
+	SerializationErrors int64
+	CompressionErrors   int64
+
+	// NearCacheInvalidations is tallied by the callback returned by
+	// InstrumentInvalidations.
+	NearCacheInvalidations int64
+
+	// NearCacheSkipped is the number of reads that bypassed the near cache
+	// because the value exceeded cache.NearCacheMaxValueSize.
+	NearCacheSkipped int64
+}
+
+// Snapshot returns the current values of the Counters.
+func (c *Counters) Snapshot() CounterSnapshot {
+	return CounterSnapshot{
+		Hits:                c.hits.Load(),
+		Misses:              c.misses.Load(),
+		Marshals:            c.marshals.Load(),
+		Unmarshals:          c.unmarshals.Load(),
+		Compressions:        c.compressions.Load(),
+		Decompressions:      c.decompressions.Load(),
+		SerializationErrors: c.serializationErrors.Load(),
+		CompressionErrors:   c.compressionErrors.Load(),
+
+		NearCacheInvalidations: c.invalidations.Load(),
+		NearCacheSkipped:       c.nearCacheSkipped.Load(),
+	}
+}
+
+// Reset sets all the Counters back to zero.
+func (c *Counters) Reset() {
+	c.hits.Store(0)
+	c.misses.Store(0)
+	c.marshals.Store(0)
+	c.unmarshals.Store(0)
+	c.compressions.Store(0)
+	c.decompressions.Store(0)
+	c.serializationErrors.Store(0)
+	c.compressionErrors.Store(0)
+	c.invalidations.Store(0)
+	c.nearCacheSkipped.Store(0)
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package cacheotel
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// threadCPUTime returns the CPU time consumed by the calling OS thread. The
+// caller must be locked to its thread with runtime.LockOSThread for successive
+// measurements to be comparable.
+func threadCPUTime() (time.Duration, bool) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_THREAD_CPUTIME_ID, &ts); err != nil {
+		return 0, false
+	}
+	return time.Duration(ts.Nano()), true
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package cacheotel
+
+import "time"
+
+// threadCPUTime isn't supported on this platform.
+func threadCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,63 @@
+package cacheotel
+
+import (
+	"context"
+
+	"github.com/redis/rueidis"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+// InstrumentInvalidations returns a callback for the OnInvalidations field of
+// rueidis.ClientOption that counts the entries of the near cache invalidated by
+// Redis in the rueidis.cache.near_cache_invalidations_total counter, and then
+// invokes next if it is not nil. Combined with near cache hits, this shows
+// whether the near cache is thrashing due to frequent writes upstream.
+//
+// rueidis only notifies invalidations through the client option, so the callback
+// must be provided when creating the client used by the Cache. Invalidations of
+// individual keys are recorded with the attribute scope=key, while invalidations
+// of the entire near cache, such as after a FLUSHALL or a reconnect, are recorded
+// once with the attribute scope=all.
+func InstrumentInvalidations(next func([]rueidis.RedisMessage), opts ...MetricsOption) (func([]rueidis.RedisMessage), error) {
+	baseOpts := make([]baseOption, len(opts))
+	for i, opt := range opts {
+		baseOpts[i] = opt
+	}
+	conf := newConfig(baseOpts...)
+
+	if conf.meter == nil {
+		conf.meter = conf.meterProvider.Meter(
+			name,
+			metric.WithInstrumentationVersion("semver"+cache.Version()))
+	}
+
+	invalidations, err := conf.meter.Int64Counter("rueidis.cache.near_cache_invalidations_total",
+		metric.WithDescription("Count of near cache entries invalidated by Redis"),
+		metric.WithUnit("count"))
+	if err != nil {
+		return nil, err
+	}
+
+	keyAttrs := metric.WithAttributes(append(append([]attribute.KeyValue{}, conf.attrs...),
+		attribute.String("scope", "key"))...)
+	allAttrs := metric.WithAttributes(append(append([]attribute.KeyValue{}, conf.attrs...),
+		attribute.String("scope", "all"))...)
+
+	return func(messages []rueidis.RedisMessage) {
+		// A nil slice signals the entire near cache was invalidated
+		if messages == nil {
+			invalidations.Add(context.Background(), 1, allAttrs)
+		} else {
+			invalidations.Add(context.Background(), int64(len(messages)), keyAttrs)
+		}
+		if conf.counters != nil {
+			conf.counters.invalidations.Add(max(int64(len(messages)), 1))
+		}
+		if next != nil {
+			next(messages)
+		}
+	}, nil
+}
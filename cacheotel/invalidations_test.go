@@ -0,0 +1,53 @@
+package cacheotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestInstrumentInvalidations(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	counters := &Counters{}
+
+	var forwarded int
+	onInvalidations, err := InstrumentInvalidations(func(messages []rueidis.RedisMessage) {
+		forwarded++
+	}, WithMeterProvider(provider), WithCounters(counters))
+	require.NoError(t, err)
+
+	onInvalidations(make([]rueidis.RedisMessage, 2))
+	onInvalidations(make([]rueidis.RedisMessage, 1))
+	onInvalidations(nil)
+	assert.Equal(t, 3, forwarded)
+	assert.Equal(t, int64(4), counters.Snapshot().NearCacheInvalidations)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	totals := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "rueidis.cache.near_cache_invalidations_total" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				scope, _ := dp.Attributes.Value(attribute.Key("scope"))
+				totals[scope.AsString()] = dp.Value
+			}
+		}
+	}
+	assert.Equal(t, map[string]int64{"key": 3, "all": 1}, totals)
+
+	// The callback doesn't require a next callback
+	onInvalidations, err = InstrumentInvalidations(nil, WithMeterProvider(provider))
+	require.NoError(t, err)
+	onInvalidations(nil)
+}
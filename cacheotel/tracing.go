@@ -0,0 +1,101 @@
+package cacheotel
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+// InstrumentTracing instruments the cache-aside operations of the Cache,
+// GetOrSet, MGetOrLoad, MGetOrLoadBatched, and Cacheable, with tracing. Each
+// operation is recorded as a span named after the operation, such as
+// cache.MGetOrLoad, with child spans cache.read, cache.load, and cache.write for
+// the phases of the operation, so traces show where the time went on a miss.
+//
+// The operation span has the attributes cache.hit, true if every key was read
+// from the Cache, cache.hits and cache.misses with the number of keys, and
+// cache.loader.duration_seconds if the loader was invoked. Misses of the read
+// phase aren't recorded as errors.
+//
+// Commands sent to Redis are traced as children of the phase spans when the
+// client is instrumented with a tracing hook such as rueidisotel.
+func InstrumentTracing(c *cache.Cache, opts ...TracingOption) error {
+	baseOpts := make([]baseOption, len(opts))
+	for i, opt := range opts {
+		baseOpts[i] = opt
+	}
+	conf := newConfig(baseOpts...)
+
+	attrs := make([]attribute.KeyValue, 0, len(c.Config().Tags))
+	for name, value := range c.Config().Tags {
+		attrs = append(attrs, attribute.String(name, value))
+	}
+
+	c.AddHook(&tracingHook{
+		tracer: conf.tracerProvider.Tracer(
+			name,
+			trace.WithInstrumentationVersion("semver"+cache.Version())),
+		attrs: attrs,
+	})
+	return nil
+}
+
+type tracingHook struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+func (t *tracingHook) StartCacheAside(ctx context.Context, operation string) (context.Context, func(cache.CacheAsideResult)) {
+	ctx, span := t.tracer.Start(ctx, "cache."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(t.attrs...))
+	return ctx, func(result cache.CacheAsideResult) {
+		span.SetAttributes(
+			attribute.Bool("cache.hit", result.Misses == 0),
+			attribute.Int("cache.hits", result.Hits),
+			attribute.Int("cache.misses", result.Misses))
+		if result.LoadDuration > 0 {
+			span.SetAttributes(attribute.Float64("cache.loader.duration_seconds", result.LoadDuration.Seconds()))
+		}
+		endSpan(span, result.Err)
+	}
+}
+
+func (t *tracingHook) StartCacheAsidePhase(ctx context.Context, phase string) (context.Context, func(error)) {
+	ctx, span := t.tracer.Start(ctx, "cache."+phase, trace.WithSpanKind(trace.SpanKindInternal))
+	return ctx, func(err error) {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			err = nil
+		}
+		endSpan(span, err)
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *tracingHook) MarshalHook(next cache.Marshaller) cache.Marshaller {
+	return next
+}
+
+func (t *tracingHook) UnmarshallHook(next cache.Unmarshaller) cache.Unmarshaller {
+	return next
+}
+
+func (t *tracingHook) CompressHook(next cache.CompressionHook) cache.CompressionHook {
+	return next
+}
+
+func (t *tracingHook) DecompressHook(next cache.CompressionHook) cache.CompressionHook {
+	return next
+}
@@ -0,0 +1,102 @@
+package cacheotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+func spanAttr(span sdktrace.ReadOnlySpan, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestInstrumentTracing_MGetOrLoad(t *testing.T) {
+	rdb := newTestCache(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	require.NoError(t, InstrumentTracing(rdb, WithTracerProvider(provider)))
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "a", "cached", 0))
+
+	results, err := cache.MGetOrLoad(ctx, rdb, []string{"a", "b"}, time.Minute,
+		func(ctx context.Context, keys []string) (map[string]string, error) {
+			return map[string]string{"b": "loaded"}, nil
+		})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	root := spans[3]
+	assert.Equal(t, "cache.MGetOrLoad", root.Name())
+	for i, name := range []string{"cache.read", "cache.load", "cache.write"} {
+		assert.Equal(t, name, spans[i].Name())
+		assert.Equal(t, root.SpanContext().SpanID(), spans[i].Parent().SpanID())
+	}
+
+	hit, ok := spanAttr(root, "cache.hit")
+	require.True(t, ok)
+	assert.False(t, hit.AsBool())
+	hits, _ := spanAttr(root, "cache.hits")
+	assert.Equal(t, int64(1), hits.AsInt64())
+	misses, _ := spanAttr(root, "cache.misses")
+	assert.Equal(t, int64(1), misses.AsInt64())
+	_, ok = spanAttr(root, "cache.loader.duration_seconds")
+	assert.True(t, ok)
+
+	// Every key is a hit so only the read phase is started
+	recorder = tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+	_, err = cache.MGetOrLoad(ctx, rdb, []string{"a", "b"}, time.Minute,
+		func(ctx context.Context, keys []string) (map[string]string, error) {
+			t.Fatal("loader invoked")
+			return nil, nil
+		})
+	require.NoError(t, err)
+	spans = recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "cache.read", spans[0].Name())
+	hit, _ = spanAttr(spans[1], "cache.hit")
+	assert.True(t, hit.AsBool())
+	_, ok = spanAttr(spans[1], "cache.loader.duration_seconds")
+	assert.False(t, ok)
+}
+
+func TestInstrumentTracing_Cacheable(t *testing.T) {
+	rdb := newTestCache(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	require.NoError(t, InstrumentTracing(rdb, WithTracerProvider(provider)))
+
+	boom := errors.New("boom")
+	_, err := cache.Cacheable(context.Background(), rdb, "key", time.Second, time.Minute,
+		func(ctx context.Context) (string, error) {
+			return "", boom
+		})
+	require.ErrorIs(t, err, boom)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "cache.read", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code, "misses aren't errors")
+	assert.Equal(t, "cache.load", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "cache.Cacheable", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+}
@@ -0,0 +1,185 @@
+// Package cacheprom provides instrumentation for Cache using native Prometheus
+// collectors.
+//
+// cacheprom is an alternative to cacheotel for applications that only expose
+// Prometheus metrics and don't want to pull in the OpenTelemetry SDK.
+package cacheprom
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+const (
+	namespace = "rueidis"
+	subsystem = "cache"
+)
+
+// Instrument registers Prometheus collectors with the provided Registerer and
+// adds a Hook to the Cache recording cache hits and misses, serialization time,
+// compression time, errors, and refreshes performed by a Scheduler.
+//
+// The collectors use fixed metric names, so instrumenting multiple Cache instances
+// with the same Registerer will fail. Use prometheus.WrapRegistererWith to add
+// distinguishing labels when instrumenting more than one Cache.
+func Instrument(c *cache.Cache, reg prometheus.Registerer) error {
+	if c == nil {
+		return fmt.Errorf("a valid cache is required")
+	}
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+
+	hook := &metricsHook{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "hits_total",
+			Help:      "Count of reads that found the key in the cache",
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "misses_total",
+			Help:      "Count of reads that did not find the key in the cache",
+		}),
+		serializationTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "serialization_time_seconds",
+			Help:      "Duration of time in seconds to marshal/unmarshal data",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 5),
+		}, []string{"operation"}),
+		serializationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "serialization_errors_total",
+			Help:      "Count of errors during marshaling and unmarshalling operations",
+		}, []string{"operation"}),
+		compressionTime: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "compression_time_seconds",
+			Help:      "Duration of time in seconds to compress/decompress data",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 5),
+		}, []string{"operation"}),
+		compressionErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "compression_errors_total",
+			Help:      "Count of error during compression/decompression operations",
+		}, []string{"operation"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "refreshes_total",
+			Help:      "Count of refreshes of registered keys performed by a Scheduler",
+		}, []string{"result"}),
+		refreshDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "refresh_duration_seconds",
+			Help:      "Duration of time in seconds to refresh registered keys",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 10),
+		}),
+	}
+
+	collectors := []prometheus.Collector{
+		hook.hits,
+		hook.misses,
+		hook.serializationTime,
+		hook.serializationErrors,
+		hook.compressionTime,
+		hook.compressionErrors,
+		hook.refreshes,
+		hook.refreshDuration,
+	}
+	for _, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			return fmt.Errorf("register collector: %w", err)
+		}
+	}
+
+	c.AddHook(hook)
+	return nil
+}
+
+type metricsHook struct {
+	hits                prometheus.Counter
+	misses              prometheus.Counter
+	serializationTime   *prometheus.HistogramVec
+	serializationErrors *prometheus.CounterVec
+	compressionTime     *prometheus.HistogramVec
+	compressionErrors   *prometheus.CounterVec
+	refreshes           *prometheus.CounterVec
+	refreshDuration     prometheus.Histogram
+}
+
+func (m *metricsHook) Hit(_ string) {
+	m.hits.Inc()
+}
+
+func (m *metricsHook) Miss(_ string) {
+	m.misses.Inc()
+}
+
+func (m *metricsHook) Refreshed(_ int, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.refreshes.WithLabelValues(result).Inc()
+	m.refreshDuration.Observe(duration.Seconds())
+}
+
+func (m *metricsHook) MarshalHook(next cache.Marshaller) cache.Marshaller {
+	return func(v any) ([]byte, error) {
+		start := time.Now()
+		data, err := next(v)
+		m.serializationTime.WithLabelValues("marshal").Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.serializationErrors.WithLabelValues("marshal").Inc()
+		}
+		return data, err
+	}
+}
+
+func (m *metricsHook) UnmarshallHook(next cache.Unmarshaller) cache.Unmarshaller {
+	return func(b []byte, v any) error {
+		start := time.Now()
+		err := next(b, v)
+		m.serializationTime.WithLabelValues("unmarshal").Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.serializationErrors.WithLabelValues("unmarshal").Inc()
+		}
+		return err
+	}
+}
+
+func (m *metricsHook) CompressHook(next cache.CompressionHook) cache.CompressionHook {
+	return func(data []byte) ([]byte, error) {
+		start := time.Now()
+		compressed, err := next(data)
+		m.compressionTime.WithLabelValues("compress").Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.compressionErrors.WithLabelValues("compress").Inc()
+		}
+		return compressed, err
+	}
+}
+
+func (m *metricsHook) DecompressHook(next cache.CompressionHook) cache.CompressionHook {
+	return func(data []byte) ([]byte, error) {
+		start := time.Now()
+		decompressed, err := next(data)
+		m.compressionTime.WithLabelValues("decompress").Observe(time.Since(start).Seconds())
+		if err != nil {
+			m.compressionErrors.WithLabelValues("decompress").Inc()
+		}
+		return decompressed, err
+	}
+}
@@ -0,0 +1,79 @@
+package cacheprom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+func TestInstrument(t *testing.T) {
+	server := miniredis.RunT(t)
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	defer client.Close()
+
+	reg := prometheus.NewRegistry()
+	rdb := cache.New(client)
+	require.NoError(t, Instrument(rdb, reg))
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+
+	var s string
+	assert.NoError(t, rdb.Get(context.Background(), "key", &s))
+	assert.ErrorIs(t, rdb.Get(context.Background(), "missing", &s), cache.ErrKeyNotFound)
+
+	count, err := testutil.GatherAndCount(reg, "rueidis_cache_hits_total", "rueidis_cache_misses_total",
+		"rueidis_cache_serialization_time_seconds", "rueidis_cache_compression_time_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	values := make(map[string]float64)
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if m.GetCounter() != nil {
+				values[mf.GetName()] += m.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(1), values["rueidis_cache_hits_total"])
+	assert.Equal(t, float64(1), values["rueidis_cache_misses_total"])
+
+	scheduler := cache.NewScheduler(rdb)
+	scheduler.Register([]string{"warm"}, time.Hour, func(ctx context.Context, key string) (any, error) {
+		return "value", nil
+	})
+	scheduler.Start(context.Background())
+	assert.Eventually(t, func() bool {
+		count, err := testutil.GatherAndCount(reg, "rueidis_cache_refreshes_total")
+		return err == nil && count == 1
+	}, time.Second, 10*time.Millisecond)
+	scheduler.Stop()
+
+	families, err = reg.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() != "rueidis_cache_refreshes_total" {
+			continue
+		}
+		require.Len(t, mf.GetMetric(), 1)
+		assert.Equal(t, "success", mf.GetMetric()[0].GetLabel()[0].GetValue())
+	}
+
+	// Registering the same collectors twice should fail
+	assert.Error(t, Instrument(rdb, reg))
+}
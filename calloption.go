@@ -0,0 +1,34 @@
+package cache
+
+// CallOption configures a single call to an operation of the Cache, overriding
+// the configuration of the Cache for that call only.
+type CallOption func(o *callOptions)
+
+// callOptions holds the configuration of a single call.
+type callOptions struct {
+	skipNearCache bool
+}
+
+// newCallOptions applies the CallOptions returning the resulting configuration.
+func newCallOptions(opts []CallOption) callOptions {
+	var o callOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithoutNearCache forces a read to be served by Redis even when near caching is
+// enabled for the Cache, while near caching remains in effect for other calls.
+// This is useful when a read must be authoritative, for example immediately after
+// a write made by another process that may not have invalidated the client side
+// cache yet.
+//
+// The client side cache is neither consulted nor populated by the call, so an
+// entry already cached locally is left as is and subsequent reads without the
+// option may still be served from it until it is invalidated or expires.
+func WithoutNearCache() CallOption {
+	return func(o *callOptions) {
+		o.skipNearCache = true
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+	"github.com/stretchr/testify/assert"
+)
+
+// cacheCallCounter is a rueidishook.Hook counting the commands sent through the
+// client side cache.
+type cacheCallCounter struct {
+	cached atomic.Int32
+}
+
+func (h *cacheCallCounter) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	return client.Do(ctx, cmd)
+}
+
+func (h *cacheCallCounter) DoMulti(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	return client.DoMulti(ctx, multi...)
+}
+
+func (h *cacheCallCounter) DoCache(client rueidis.Client, ctx context.Context, cmd rueidis.Cacheable, ttl time.Duration) rueidis.RedisResult {
+	h.cached.Add(1)
+	return client.DoCache(ctx, cmd, ttl)
+}
+
+func (h *cacheCallCounter) DoMultiCache(client rueidis.Client, ctx context.Context, multi ...rueidis.CacheableTTL) []rueidis.RedisResult {
+	h.cached.Add(int32(len(multi)))
+	return client.DoMultiCache(ctx, multi...)
+}
+
+func (h *cacheCallCounter) Receive(client rueidis.Client, ctx context.Context, subscribe rueidis.Completed, fn func(msg rueidis.PubSubMessage)) error {
+	return client.Receive(ctx, subscribe, fn)
+}
+
+func (h *cacheCallCounter) DoStream(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResultStream {
+	return client.DoStream(ctx, cmd)
+}
+
+func (h *cacheCallCounter) DoMultiStream(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) rueidis.MultiRedisResultStream {
+	return client.DoMultiStream(ctx, multi...)
+}
+
+func TestCache_Get_WithoutNearCache(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	counter := &cacheCallCounter{}
+	rdb := New(rueidishook.WithHook(client, counter), NearCache(time.Minute))
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, int32(1), counter.cached.Load())
+
+	val = ""
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val, WithoutNearCache()))
+	assert.Equal(t, "value", val)
+	assert.Equal(t, int32(1), counter.cached.Load())
+
+	source, err := rdb.GetWithSource(context.Background(), "key", &val, WithoutNearCache())
+	assert.NoError(t, err)
+	assert.Equal(t, SourceRedis, source)
+	assert.Equal(t, int32(1), counter.cached.Load())
+}
+
+func TestCache_Set_NearCacheWriteThrough(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	counter := &cacheCallCounter{}
+	rdb := New(rueidishook.WithHook(client, counter), NearCache(time.Minute), NearCacheWriteThrough())
+	assert.True(t, rdb.Config().NearCacheWriteThrough)
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.Equal(t, int32(1), counter.cached.Load())
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, "value", val)
+
+	// Writes aren't read back without the option or outside the near cache
+	counter.cached.Store(0)
+	rdb = New(rueidishook.WithHook(client, counter), NearCache(time.Minute))
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.Equal(t, int32(0), counter.cached.Load())
+
+	rdb = New(rueidishook.WithHook(client, counter), NearCacheWriteThrough())
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.Equal(t, int32(0), counter.cached.Load())
+}
@@ -0,0 +1,37 @@
+package cache
+
+// Stages of the encoding and decoding pipeline reported to a CaptureFunc.
+const (
+	// StageMarshalled is the value as returned by the Marshaller.
+	StageMarshalled = "marshalled"
+
+	// StageCompressed is the value as returned by the compression Codec. It isn't
+	// reported for values that aren't compressed.
+	StageCompressed = "compressed"
+
+	// StageStored is the value as written to Redis, including the readable prefix
+	// and the header if they are stored.
+	StageStored = "stored"
+
+	// StageRead is the value as read from Redis, including the readable prefix and
+	// the header if they are stored.
+	StageRead = "read"
+
+	// StageDecompressed is the value as returned by the decompression Codec. It
+	// isn't reported for values that aren't compressed.
+	StageDecompressed = "decompressed"
+)
+
+// CaptureFunc is a function type invoked with the bytes of a value for the key
+// after each stage of encoding and decoding. The stage is one of the Stage
+// constants. The data must not be modified, and must be copied if retained after
+// the function returns.
+type CaptureFunc func(key string, stage string, data []byte)
+
+// capture invokes the CaptureFunc, if one is configured, with the bytes of the
+// value after the stage.
+func (c *Cache) capture(key, stage string, data []byte) {
+	if c.captureFn != nil {
+		c.captureFn(key, stage, data)
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_CaptureHook(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	type capture struct {
+		key   string
+		stage string
+		data  string
+	}
+	var captured []capture
+	rdb := New(client, GZip(), CaptureHook(func(key string, stage string, data []byte) {
+		captured = append(captured, capture{key: key, stage: stage, data: string(data)})
+	}))
+
+	require.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	require.Len(t, captured, 3)
+	assert.Equal(t, []string{StageMarshalled, StageCompressed, StageStored},
+		[]string{captured[0].stage, captured[1].stage, captured[2].stage})
+	marshalled, err := DefaultMarshaller()("value")
+	require.NoError(t, err)
+	assert.Equal(t, "key", captured[0].key)
+	assert.Equal(t, string(marshalled), captured[0].data)
+	stored, err := server.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, stored, captured[2].data)
+
+	captured = nil
+	var val string
+	require.NoError(t, rdb.Get(context.Background(), "key", &val))
+	require.Len(t, captured, 2)
+	assert.Equal(t, capture{key: "key", stage: StageRead, data: stored}, captured[0])
+	assert.Equal(t, capture{key: "key", stage: StageDecompressed, data: string(marshalled)}, captured[1])
+
+	assert.Panics(t, func() {
+		CaptureHook(nil)
+	})
+}
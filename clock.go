@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"time"
+)
+
+// Clock provides the current time to the time-dependent behavior of the Cache,
+// such as write timestamps, minimum freshness, early expiration and refreshing
+// the generation. Providing a Clock with the WithClock Option allows tests to
+// control time and exercise that behavior deterministically.
+//
+// TTLs are enforced by Redis using its own clock, so a Clock doesn't change when
+// entries expire.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is a Clock that returns the current system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Clock returns the Clock used by the Cache.
+func (c *Cache) Clock() Clock {
+	return c.clock
+}
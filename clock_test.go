@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func TestCache_WithClock(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rdb := New(client, WithClock(clock))
+	assert.Equal(t, clock, rdb.Clock())
+
+	calls := 0
+	fn := func(ctx context.Context) (any, error) {
+		calls++
+		return calls, nil
+	}
+
+	var val int
+	require.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Minute, time.Hour, fn))
+	assert.Equal(t, 1, val)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Minute, time.Hour, fn))
+	assert.Equal(t, 1, val)
+
+	clock.Advance(time.Second)
+	require.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Minute, time.Hour, fn))
+	assert.Equal(t, 2, val)
+}
+
+func TestCache_WithClock_Generation(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	clock := &fakeClock{now: time.Now()}
+	rdb := New(client, WithClock(clock), WithGenerationBusting())
+	other := New(client, WithGenerationBusting())
+
+	require.NoError(t, rdb.Set(context.Background(), "key", "v0", 0))
+	require.NoError(t, other.BumpGeneration(context.Background()))
+
+	// The locally cached generation is used until the refresh interval elapses
+	var val string
+	require.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, "v0", val)
+
+	clock.Advance(DefaultGenerationRefresh)
+	assert.ErrorIs(t, rdb.Get(context.Background(), "key", &val), ErrKeyNotFound)
+}
+
+func TestWithClock_Nil(t *testing.T) {
+	assert.Panics(t, func() {
+		WithClock(nil)
+	})
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/redis/rueidis"
+)
+
+// readGroup collapses concurrent reads of the same key into a single Redis round
+// trip whose result is shared by every caller.
+type readGroup struct {
+	mu      sync.Mutex
+	flights map[string]*readFlight
+}
+
+// readFlight is a read in progress. The result is set before done is closed and
+// must not be modified afterward.
+type readFlight struct {
+	done chan struct{}
+	res  rueidis.RedisResult
+}
+
+func newReadGroup() *readGroup {
+	return &readGroup{
+		flights: make(map[string]*readFlight),
+	}
+}
+
+// do invokes fn to read the key unless a read of the key is already in flight,
+// in which case the result of that read is returned once it completes. Callers
+// joining a read in flight stop waiting when their context is done, returning
+// the context error, but the read itself is bound to the context of the caller
+// that started it.
+func (g *readGroup) do(ctx context.Context, key string, fn func() rueidis.RedisResult) (rueidis.RedisResult, error) {
+	g.mu.Lock()
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.res, nil
+		case <-ctx.Done():
+			return rueidis.RedisResult{}, ctx.Err()
+		}
+	}
+	f := &readFlight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.res = fn()
+	return f.res, nil
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowGetHook is a rueidishook.Hook counting GET commands and holding them until
+// released.
+type slowGetHook struct {
+	cacheCallCounter
+	gets    atomic.Int32
+	release chan struct{}
+}
+
+func (h *slowGetHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	if cmd.Commands()[0] == "GET" {
+		h.gets.Add(1)
+		<-h.release
+	}
+	return client.Do(ctx, cmd)
+}
+
+func TestCache_WithReadCoalescing(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	hook := &slowGetHook{release: make(chan struct{})}
+	rdb := New(rueidishook.WithHook(client, hook), WithReadCoalescing())
+	close(hook.release)
+	require.NoError(t, rdb.Set(context.Background(), "key", map[string]int{"count": 1}, 0))
+	hook.gets.Store(0)
+	hook.release = make(chan struct{})
+
+	const readers = 10
+	var wg sync.WaitGroup
+	results := make([]map[string]int, readers)
+	errs := make([]error, readers)
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = rdb.Get(context.Background(), "key", &results[i])
+		}(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(hook.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), hook.gets.Load())
+	for i := 0; i < readers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, map[string]int{"count": 1}, results[i])
+	}
+	// Every caller decodes into its own destination
+	results[0]["count"] = 2
+	assert.Equal(t, 1, results[1]["count"])
+
+	// Reads that don't overlap aren't coalesced
+	var res map[string]int
+	require.NoError(t, rdb.Get(context.Background(), "key", &res))
+	assert.Equal(t, int32(2), hook.gets.Load())
+}
+
+func TestCache_WithReadCoalescing_Canceled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	hook := &slowGetHook{release: make(chan struct{})}
+	rdb := New(rueidishook.WithHook(client, hook), WithReadCoalescing())
+	defer close(hook.release)
+
+	go func() {
+		var s string
+		_ = rdb.Get(context.Background(), "key", &s)
+	}()
+	for hook.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A caller waiting on a read in flight stops waiting once its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var s string
+	assert.ErrorIs(t, rdb.Get(ctx, "key", &s), context.DeadlineExceeded)
+	assert.Equal(t, int32(1), hook.gets.Load())
+}
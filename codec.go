@@ -1,5 +1,15 @@
 package cache
 
+import (
+	"errors"
+	"io"
+)
+
+// ErrUnknownCodec is an error value that signals a value was compressed with a
+// Codec, recorded in its header, that the Cache can't decompress because it is
+// neither configured, registered with WithCodecs, nor built into this package.
+var ErrUnknownCodec = errors.New("unknown compression codec")
+
 // Codec is an interface type that defines the behavior for compressing and
 // decompressing data.
 type Codec interface {
@@ -7,6 +17,17 @@ type Codec interface {
 	Deflate(data []byte) ([]byte, error)
 }
 
+// StreamCodec is an optional interface a Codec can implement to compress data
+// as it is written, rather than compressing a buffer holding the entire value.
+// It is used by StreamingCompression to compress values as they are marshalled.
+//
+// The data written to the returned writer, once closed, must be decompressible
+// by Deflate. The gzip, flate, and zstd Codecs implement StreamCodec.
+type StreamCodec interface {
+	Codec
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
 // nopCodec is a codec that is no-op, basically it does nothing. It is used as
 // the default Codec when compression is not used.
 type nopCodec struct{}
@@ -18,3 +39,7 @@ func (n nopCodec) Flate(data []byte) ([]byte, error) {
 func (n nopCodec) Deflate(data []byte) ([]byte, error) {
 	return data, nil
 }
+
+// CompressionPredicate determines if values serialized with the named
+// serialization, such as "msgpack" or "json", should be compressed.
+type CompressionPredicate func(serialization string) bool
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reverseCodec is a custom Codec reversing the bytes of values.
+type reverseCodec struct{}
+
+func (reverseCodec) Name() string { return "reverse" }
+
+func (reverseCodec) Flate(data []byte) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[len(data)-1-i] = b
+	}
+	return out, nil
+}
+
+func (r reverseCodec) Deflate(data []byte) ([]byte, error) {
+	return r.Flate(data)
+}
+
+func TestCache_WithCodecHeader(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	lz4 := New(client, LZ4(), WithCodecHeader())
+	zstd := New(client, Zstd(3), WithCodecHeader())
+
+	require.NoError(t, lz4.Set(ctx, "lz4", "value", 0))
+	raw, err := server.Get("lz4")
+	require.NoError(t, err)
+	h, _, ok, err := parseHeader([]byte(raw))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "lz4", h.codec)
+
+	// Switching Codecs keeps values written with the previous Codec readable
+	var s string
+	require.NoError(t, zstd.Get(ctx, "lz4", &s))
+	assert.Equal(t, "value", s)
+
+	require.NoError(t, zstd.Set(ctx, "zstd", "other", 0))
+	require.NoError(t, lz4.Get(ctx, "zstd", &s))
+	assert.Equal(t, "other", s)
+
+	// Recompressing with the configured Codec keeps the Codec recorded
+	n, err := lz4.Recompress(ctx, []string{"zstd"}, lz4.codec)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	raw, err = server.Get("zstd")
+	require.NoError(t, err)
+	h, _, _, err = parseHeader([]byte(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "lz4", h.codec)
+}
+
+func TestCache_WithCodecs(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	writer := New(client, Compression(reverseCodec{}), WithCodecHeader())
+	require.NoError(t, writer.Set(ctx, "key", "value", 0))
+
+	var s string
+	err := New(client, LZ4()).Get(ctx, "key", &s)
+	assert.ErrorIs(t, err, ErrUnknownCodec)
+
+	require.NoError(t, New(client, LZ4(), WithCodecs(reverseCodec{})).Get(ctx, "key", &s))
+	assert.Equal(t, "value", s)
+
+	assert.Panics(t, func() { WithCodecs(nil) })
+}
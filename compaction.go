@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+)
+
+// compactMarker marks a segment of a key that has been compacted. Segments of the
+// original key starting with the marker are escaped by doubling it so compaction
+// is always reversible.
+const compactMarker = "~"
+
+// KeyCompactor deterministically maps keys to a shorter form stored in Redis, and
+// maps the compact form back to the original key.
+//
+// Compact must be deterministic and Expand(Compact(key)) must return key for
+// every key, otherwise entries may collide or keys returned by Keys and ScanKeys
+// won't match the keys written.
+type KeyCompactor interface {
+	Compact(key string) string
+	Expand(key string) string
+}
+
+// segmentDictionary is a KeyCompactor that replaces segments of a key with a
+// shorter form from a dictionary.
+type segmentDictionary struct {
+	sep     string
+	compact map[string]string
+	expand  map[string]string
+}
+
+// SegmentDictionary returns a KeyCompactor that splits keys on sep and replaces
+// each segment found in dict with its short form. This suits structured keys
+// with verbose but repetitive segments, for example "tenant:acme:user-profile:42"
+// can be stored as "tenant:acme:~up:42" with {"user-profile": "up"}.
+//
+// Compacted segments are prefixed with "~", and segments of the original key
+// starting with "~" are escaped, so compaction is always reversible.
+//
+// SegmentDictionary panics if sep is empty, or if a short form is empty, starts
+// with "~", or is used for more than one segment.
+func SegmentDictionary(sep string, dict map[string]string) KeyCompactor {
+	if sep == "" {
+		panic(fmt.Errorf("empty separator not permitted, illegal use of api"))
+	}
+	d := &segmentDictionary{
+		sep:     sep,
+		compact: make(map[string]string, len(dict)),
+		expand:  make(map[string]string, len(dict)),
+	}
+	for long, short := range dict {
+		if short == "" || strings.HasPrefix(short, compactMarker) || strings.Contains(short, sep) {
+			panic(fmt.Errorf("invalid short form %q for segment %q, illegal use of api", short, long))
+		}
+		if other, ok := d.expand[short]; ok {
+			panic(fmt.Errorf("short form %q used for segments %q and %q, illegal use of api", short, other, long))
+		}
+		d.compact[long] = compactMarker + short
+		d.expand[short] = long
+	}
+	return d
+}
+
+func (d *segmentDictionary) Compact(key string) string {
+	segments := strings.Split(key, d.sep)
+	for i, segment := range segments {
+		if short, ok := d.compact[segment]; ok {
+			segments[i] = short
+		} else if strings.HasPrefix(segment, compactMarker) {
+			segments[i] = compactMarker + segment
+		}
+	}
+	return strings.Join(segments, d.sep)
+}
+
+func (d *segmentDictionary) Expand(key string) string {
+	segments := strings.Split(key, d.sep)
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, compactMarker) {
+			continue
+		}
+		segment = strings.TrimPrefix(segment, compactMarker)
+		if long, ok := d.expand[segment]; ok {
+			segments[i] = long
+		} else {
+			segments[i] = segment
+		}
+	}
+	return strings.Join(segments, d.sep)
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentDictionary(t *testing.T) {
+	compactor := SegmentDictionary(":", map[string]string{
+		"tenant":       "t",
+		"user-profile": "up",
+	})
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"tenant:acme:user-profile:42", "~t:acme:~up:42"},
+		{"other:key", "other:key"},
+		{"~t:user-profile", "~~t:~up"},
+		{"~~up", "~~~up"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		compacted := compactor.Compact(test.key)
+		assert.Equal(t, test.expected, compacted)
+		assert.Equal(t, test.key, compactor.Expand(compacted))
+	}
+
+	assert.Panics(t, func() { SegmentDictionary("", nil) })
+	assert.Panics(t, func() { SegmentDictionary(":", map[string]string{"a": "~a"}) })
+	assert.Panics(t, func() { SegmentDictionary(":", map[string]string{"a": "x", "b": "x"}) })
+}
+
+func TestCache_KeyCompaction(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithKeyCompaction(SegmentDictionary(":", map[string]string{
+		"user-profile": "up",
+	})))
+
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "user-profile:1", "Bob", 0))
+	assert.NoError(t, rdb.Set(ctx, "user-profile:2", "Alice", 0))
+	assert.True(t, server.Exists("~up:1"))
+
+	var s string
+	assert.NoError(t, rdb.Get(ctx, "user-profile:1", &s))
+	assert.Equal(t, "Bob", s)
+
+	res, err := MGet[string](ctx, rdb, "user-profile:1", "user-profile:2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"user-profile:1": "Bob", "user-profile:2": "Alice"}, map[string]string(res))
+
+	keys, err := rdb.Keys(ctx)
+	assert.NoError(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"user-profile:1", "user-profile:2"}, keys)
+
+	keys, err = rdb.ScanKeys(ctx, "user-profile:*")
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	assert.NoError(t, rdb.Delete(ctx, "user-profile:1"))
+	assert.False(t, server.Exists("~up:1"))
+}
@@ -38,3 +38,10 @@ func (c Codec) Deflate(data []byte) ([]byte, error) {
 	_, err := io.Copy(buffer, r)
 	return buffer.Bytes(), err
 }
+
+// NewWriter returns a writer compressing the data written to it into w, so
+// values can be compressed as they are marshalled. The writer must be closed to
+// flush the compressed data.
+func (c Codec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, c.Level)
+}
@@ -0,0 +1,57 @@
+package zstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec compresses and decompresses values with zstd. The encoder and decoder
+// are created once and reused for every value, both are safe for concurrent use
+// and pool their internal state, so compressing doesn't allocate an encoder per
+// call.
+//
+// Values are stored as zstd frames which start with the zstd magic number, so
+// decompressing a value that wasn't compressed with zstd fails instead of
+// returning corrupt data.
+type Codec struct {
+	level   zstd.EncoderLevel
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+// NewCodec creates a zstd Codec compressing with the provided zstd level. Levels
+// range from 1, favoring speed, to 22, favoring compression size, and are mapped
+// to the closest level supported by the encoder. Levels outside the range are
+// clamped.
+func NewCodec(level int) *Codec {
+	lvl := zstd.EncoderLevelFromZstd(level)
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(lvl))
+	if err != nil {
+		panic(err)
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		panic(err)
+	}
+	return &Codec{
+		level:   lvl,
+		encoder: encoder,
+		decoder: decoder,
+	}
+}
+
+func (c *Codec) Flate(data []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+}
+
+func (c *Codec) Deflate(data []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(data, nil)
+}
+
+// NewWriter returns a writer compressing the data written to it into w, so
+// values can be compressed as they are marshalled. The writer must be closed to
+// flush the compressed data. Each writer compresses on the calling goroutine.
+func (c *Codec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.level), zstd.WithEncoderConcurrency(1))
+}
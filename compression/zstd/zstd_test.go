@@ -0,0 +1,39 @@
+package zstd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jkratz55/rueidis-cache/compression/lz4"
+)
+
+func TestCodec(t *testing.T) {
+
+	codec := NewCodec(3)
+
+	// Looping to test for re-using the encoder and decoder
+	for i := 0; i < 10; i++ {
+		testStr := "This is a test string. Hopefully it flates and then deflates to the same value!"
+		compressed, err := codec.Flate([]byte(testStr))
+		assert.NoError(t, err)
+
+		decompressed, err := codec.Deflate(compressed)
+		assert.NoError(t, err)
+		assert.Equal(t, testStr, string(decompressed))
+	}
+}
+
+func TestCodec_NotZstd(t *testing.T) {
+	data := []byte("This is a test string compressed with another codec")
+	compressed, err := lz4.NewCodec().Flate(data)
+	assert.NoError(t, err)
+
+	_, err = NewCodec(3).Deflate(compressed)
+	assert.Error(t, err)
+
+	compressed, err = NewCodec(3).Flate(data)
+	assert.NoError(t, err)
+	_, err = lz4.NewCodec().Deflate(compressed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const (
+	// DefaultComputeLockTTL is the default duration of the lock acquired by
+	// GetOrComputeDistributed while computing a value.
+	DefaultComputeLockTTL = 30 * time.Second
+
+	// computeLockPrefix is the prefix of the keys used as locks by
+	// GetOrComputeDistributed.
+	computeLockPrefix = "rueidis-cache:lock:"
+
+	// computePollMin and computePollMax bound the delay between polls while
+	// waiting for another process to compute a value.
+	computePollMin = 10 * time.Millisecond
+	computePollMax = 500 * time.Millisecond
+)
+
+// releaseLockScript deletes the lock at KEYS[1] only if it is still held by the
+// token in ARGV[1], so a lock that expired and was acquired by another process
+// isn't released.
+var releaseLockScript = rueidis.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// GetOrComputeDistributed retrieves an entry from the Cache for the given key and
+// unmarshalls the value into dst. On a cache miss a single process across all
+// instances sharing the Redis keyspace computes the value using fn and stores it
+// with the provided TTL, while the others wait for the value to be stored and
+// read it. This protects expensive computations or origins from a stampede when
+// a popular key is missing.
+//
+// The computing process is elected using a lock in Redis that expires after
+// DefaultComputeLockTTL unless configured otherwise with WithComputeLockTTL. If
+// the computing process crashes or fn fails, the lock expires or is released and
+// a waiting process takes over. The lock TTL should exceed the time fn takes to
+// compute the value, otherwise another process may start computing the value
+// concurrently.
+//
+// Waiting processes poll Redis with an exponential backoff until the value is
+// stored or the context is done. Errors returned by fn are returned to the caller
+// that invoked it, the waiting callers continue waiting and try to compute the
+// value themselves.
+//
+// If early expiration is enabled with WithEarlyExpiration, a value read from the
+// cache may be treated as expired ahead of its TTL. The caller refreshes the
+// value if no other process holds the lock, while other callers keep using the
+// cached value. If refreshing the value fails the cached value is used and the
+// error is logged.
+func (c *Cache) GetOrComputeDistributed(
+	ctx context.Context,
+	key string,
+	dst any,
+	ttl time.Duration,
+	fn func(ctx context.Context) (any, error)) error {
+
+	_, data, err := c.read(ctx, key, dst, callOptions{})
+	if err == nil && c.expiresEarly(data) {
+		c.refreshEarly(ctx, key, dst, ttl, fn)
+		return nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	lockKey, token, err := c.computeLock(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	delay := computePollMin
+	for {
+		acquired, err := c.acquireLock(ctx, lockKey, token)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return c.compute(ctx, key, lockKey, token, dst, ttl, fn)
+		}
+
+		// Another process is computing the value. Wait for it to be stored, and
+		// if the lock is released or expires without the value being stored, try
+		// to acquire the lock again.
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, computePollMax)
+
+		err = c.Get(ctx, key, dst)
+		if !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+	}
+}
+
+// compute invokes fn while holding the lock, stores the value, and releases the
+// lock.
+func (c *Cache) compute(
+	ctx context.Context,
+	key, lockKey, token string,
+	dst any,
+	ttl time.Duration,
+	fn func(ctx context.Context) (any, error)) error {
+
+	defer func() {
+		_ = releaseLockScript.Exec(context.WithoutCancel(ctx), c.redis, []string{lockKey}, []string{token}).Error()
+	}()
+
+	// The value may have been stored between the last read and acquiring the lock
+	err := c.Get(ctx, key, dst)
+	if !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	return c.store(ctx, key, dst, ttl, fn)
+}
+
+// refreshEarly recomputes a value that expired early if no other process is
+// computing it. dst is only updated if the value was refreshed.
+func (c *Cache) refreshEarly(
+	ctx context.Context,
+	key string,
+	dst any,
+	ttl time.Duration,
+	fn func(ctx context.Context) (any, error)) {
+
+	lockKey, token, err := c.computeLock(ctx, key)
+	if err != nil {
+		return
+	}
+	if acquired, err := c.acquireLock(ctx, lockKey, token); err != nil || !acquired {
+		return
+	}
+	defer func() {
+		_ = releaseLockScript.Exec(context.WithoutCancel(ctx), c.redis, []string{lockKey}, []string{token}).Error()
+	}()
+
+	// The value is computed into a new value of the same type so dst keeps the
+	// cached value if the refresh fails.
+	refreshed := reflect.New(reflect.TypeOf(dst).Elem())
+	if err := c.store(ctx, key, refreshed.Interface(), ttl, fn); err != nil {
+		slog.Error(fmt.Sprintf("Failed to refresh value for key %s", key),
+			slog.Any("err", err))
+		return
+	}
+	reflect.ValueOf(dst).Elem().Set(refreshed.Elem())
+}
+
+// store invokes fn and stores the value along with the time fn took to compute
+// it, and populates dst with the value.
+func (c *Cache) store(
+	ctx context.Context,
+	key string,
+	dst any,
+	ttl time.Duration,
+	fn func(ctx context.Context) (any, error)) error {
+
+	start := c.clock.Now()
+	v, err := fn(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.setComputed(ctx, key, v, ttl, c.clock.Now().Sub(start)); err != nil {
+		return err
+	}
+
+	return c.populate(v, dst)
+}
+
+// populate round trips the value through the serialization to populate dst the
+// same way a read from the cache would.
+func (c *Cache) populate(v any, dst any) error {
+	data, err := c.marshaller(v)
+	if err != nil {
+		return fmt.Errorf("marshall value: %w", err)
+	}
+	if err := c.unmarshaller(data, dst); err != nil {
+		return fmt.Errorf("unmarshall value to type %T: %w", dst, err)
+	}
+	return nil
+}
+
+// computeLock returns the key of the lock used to compute the value for the key
+// and a random token identifying the holder of the lock.
+func (c *Cache) computeLock(ctx context.Context, key string) (string, string, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := lockToken()
+	if err != nil {
+		return "", "", err
+	}
+	return computeLockPrefix + redisKey, token, nil
+}
+
+// acquireLock attempts to acquire the lock returning true if it was acquired.
+func (c *Cache) acquireLock(ctx context.Context, lockKey, token string) (bool, error) {
+	err := c.redis.Do(ctx, c.redis.B().Set().Key(lockKey).Value(token).Nx().
+		Px(c.computeLockTTL).Build()).Error()
+	if errors.Is(err, rueidis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return true, nil
+}
+
+// lockToken returns a random token identifying the holder of a lock.
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
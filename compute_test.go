@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_GetOrComputeDistributed(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var calls atomic.Int32
+	fn := func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return "computed", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Each Cache simulates a separate process sharing Redis
+			rdb := New(client)
+			var val string
+			assert.NoError(t, rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, fn))
+			assert.Equal(t, "computed", val)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.False(t, server.Exists(computeLockPrefix+"key"))
+}
+
+func TestCache_GetOrComputeDistributed_Failure(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	var val string
+	err := rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, func(ctx context.Context) (any, error) {
+		return nil, assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.False(t, server.Exists(computeLockPrefix+"key"))
+
+	// A lock left behind by a crashed process is taken over once it expires
+	assert.NoError(t, server.Set(computeLockPrefix+"key", "crashed"))
+	server.SetTTL(computeLockPrefix+"key", time.Second)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		server.FastForward(time.Second)
+	}()
+	err = rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, func(ctx context.Context) (any, error) {
+		return "computed", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "computed", val)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, server.Set(computeLockPrefix+"other", "held"))
+	err = rdb.GetOrComputeDistributed(ctx, "other", &val, time.Minute, func(ctx context.Context) (any, error) {
+		return "computed", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
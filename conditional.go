@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/redis/rueidis"
+)
+
+// getIfChangedScript returns the version of the value stored at KEYS[1], and the
+// value itself only if the version differs from ARGV[1]. The version is the SHA1
+// of the value as stored in Redis, so it is computed without transferring the
+// value to the client. Returns nil if the key doesn't exist.
+var getIfChangedScript = rueidis.NewLuaScript(`
+local data = redis.call('GET', KEYS[1])
+if not data then
+	return nil
+end
+local version = redis.sha1hex(data)
+if version == ARGV[1] then
+	return {version}
+end
+return {version, data}`)
+
+// GetIfChanged retrieves an entry from the Cache for the given key like Get, but
+// only if the version of the entry differs from knownVersion. This mirrors HTTP
+// conditional requests: a client polling for changes provides the version it
+// received from the previous call, and if the entry hasn't changed since, the
+// value is neither transferred from Redis nor decoded and changed is false. When
+// the entry has changed, or knownVersion is empty, the value is unmarshalled into
+// v and changed is true. The current version is returned in both cases.
+//
+// The version is a hash of the value as stored in Redis computed by Redis itself,
+// so no additional data is stored alongside the value. Any write of the key
+// changes the version if the stored bytes differ, which may be the case even when
+// the value is logically the same, for example when using WithWriteTimestamps or
+// after changing compression. Versions should be treated as opaque.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+// GetIfChanged always reads from Redis and bypasses the near cache.
+func (c *Cache) GetIfChanged(ctx context.Context, key string, knownVersion string, v any) (bool, string, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return false, "", err
+	}
+
+	if c.migration != nil {
+		return c.getIfChangedMigrating(ctx, key, redisKey, knownVersion, v)
+	}
+
+	changed, version, data, err := c.getIfChanged(ctx, redisKey, knownVersion)
+	if errors.Is(err, rueidis.Nil) {
+		c.hooksMixin.miss(key)
+		return false, "", ErrKeyNotFound
+	}
+	if err != nil {
+		return false, "", err
+	}
+	if !changed {
+		c.hooksMixin.hit(key)
+		return false, version, nil
+	}
+	if err := c.recordRead(key, c.decode(ctx, key, data, v)); err != nil {
+		return false, "", err
+	}
+	return true, version, nil
+}
+
+// getIfChangedMigrating is like GetIfChanged but prefers the target Encoding and
+// falls back to the source Encoding when the Cache is in migration mode. The
+// versions of the two Encodings differ, so a client will see the entry as changed
+// once after it is rewritten with the target Encoding.
+func (c *Cache) getIfChangedMigrating(ctx context.Context, key, redisKey, knownVersion string, v any) (bool, string, error) {
+	candidates := []struct {
+		redisKey   string
+		unmarshall Unmarshaller
+	}{
+		{c.migration.key(redisKey), c.migration.to.Unmarshaller},
+		{redisKey, c.hooksMixin.current.unmarshall},
+	}
+	for _, candidate := range candidates {
+		changed, version, data, err := c.getIfChanged(ctx, candidate.redisKey, knownVersion)
+		if errors.Is(err, rueidis.Nil) {
+			continue
+		}
+		if err != nil {
+			return false, "", err
+		}
+		if !changed {
+			c.hooksMixin.hit(key)
+			return false, version, nil
+		}
+		if err := c.recordRead(key, c.decodeMigrating(ctx, key, data, v, candidate.unmarshall)); err != nil {
+			return false, "", err
+		}
+		return true, version, nil
+	}
+
+	c.hooksMixin.miss(key)
+	return false, "", ErrKeyNotFound
+}
+
+// getIfChanged executes getIfChangedScript for the key. The error wraps
+// rueidis.Nil if the key doesn't exist.
+func (c *Cache) getIfChanged(ctx context.Context, redisKey, knownVersion string) (bool, string, []byte, error) {
+	return ifChangedResult(getIfChangedScript.Exec(ctx, c.redis, []string{redisKey}, []string{knownVersion}))
+}
+
+// ifChangedResult parses the result of getIfChangedScript. The error wraps
+// rueidis.Nil if the key doesn't exist.
+func ifChangedResult(res rueidis.RedisResult) (bool, string, []byte, error) {
+	resp, err := res.ToArray()
+	if errors.Is(err, rueidis.Nil) {
+		return false, "", nil, err
+	}
+	if err != nil {
+		return false, "", nil, fmt.Errorf("redis: %w", err)
+	}
+	version, err := resp[0].ToString()
+	if err != nil {
+		return false, "", nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(resp) == 1 {
+		return false, version, nil, nil
+	}
+	data, err := resp[1].AsBytes()
+	if err != nil {
+		return false, "", nil, fmt.Errorf("redis: %w", err)
+	}
+	return true, version, data, nil
+}
+
+// StatusCode reports the outcome of MGetIfChanged for a key.
+type StatusCode uint8
+
+const (
+	// StatusUnchanged signals the version of the entry matches the known version,
+	// so the value was neither transferred nor decoded.
+	StatusUnchanged StatusCode = iota
+
+	// StatusUpdated signals the version of the entry differs from the known
+	// version and the value was unmarshalled into the destination.
+	StatusUpdated
+
+	// StatusMissing signals the key doesn't exist.
+	StatusMissing
+)
+
+// Status is the outcome of MGetIfChanged for a key, along with the current
+// version of the entry to provide to the next call. Version is empty if the key
+// doesn't exist.
+type Status struct {
+	Code    StatusCode
+	Version string
+}
+
+// MGetIfChanged is like GetIfChanged for multiple keys at once. The items map
+// each key to the version the caller received from a previous call, or an empty
+// string if the caller doesn't have a version, and the value of every key whose
+// version changed is unmarshalled into the destination in dsts for the key. Only
+// changed values are transferred from Redis and decoded, so many entries can be
+// polled efficiently. The version comparisons are made by Redis in a single
+// pipeline.
+//
+// The returned map reports the Status of each key. Destinations of keys that are
+// unchanged or missing are left untouched. Every key must have a non-nil pointer
+// destination in dsts. Errors decoding individual keys are joined into the
+// returned error, and those keys are omitted from the returned map, so the
+// statuses of the remaining keys are still reported.
+func (c *Cache) MGetIfChanged(ctx context.Context, items map[string]string, dsts map[string]any) (map[string]Status, error) {
+	if len(items) == 0 {
+		return map[string]Status{}, nil
+	}
+	keys := sortedKeys(items)
+	for _, key := range keys {
+		if rv := reflect.ValueOf(dsts[key]); rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return nil, fmt.Errorf("key %s: destination must be a non-nil pointer, got %T", key, dsts[key])
+		}
+	}
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// While migrating the target Encoding is compared first, falling back to the
+	// source Encoding
+	execs := make([]rueidis.LuaExec, 0, len(keys))
+	if c.migration != nil {
+		for i, redisKey := range redisKeys {
+			execs = append(execs, rueidis.LuaExec{
+				Keys: []string{c.migration.key(redisKey)},
+				Args: []string{items[keys[i]]},
+			})
+		}
+	}
+	for i, redisKey := range redisKeys {
+		execs = append(execs, rueidis.LuaExec{
+			Keys: []string{redisKey},
+			Args: []string{items[keys[i]]},
+		})
+	}
+	results := getIfChangedScript.ExecMulti(ctx, c.redis, execs...)
+
+	var (
+		statuses = make(map[string]Status, len(keys))
+		errs     []error
+	)
+	for i, key := range keys {
+		res, unmarshall := results[i], Unmarshaller(nil)
+		if c.migration != nil {
+			unmarshall = c.migration.to.Unmarshaller
+			if err := res.Error(); errors.Is(err, rueidis.Nil) {
+				res, unmarshall = results[len(keys)+i], c.hooksMixin.current.unmarshall
+			}
+		}
+		changed, version, data, err := ifChangedResult(res)
+		if errors.Is(err, rueidis.Nil) {
+			c.hooksMixin.miss(key)
+			statuses[key] = Status{Code: StatusMissing}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %w", key, err))
+			continue
+		}
+		if !changed {
+			c.hooksMixin.hit(key)
+			statuses[key] = Status{Code: StatusUnchanged, Version: version}
+			continue
+		}
+		if unmarshall != nil {
+			err = c.decodeMigrating(ctx, key, data, dsts[key], unmarshall)
+		} else {
+			err = c.decode(ctx, key, data, dsts[key])
+		}
+		err = c.recordRead(key, err)
+		if errors.Is(err, ErrKeyNotFound) {
+			// The value failed read validation or is stale and is treated as a miss
+			statuses[key] = Status{Code: StatusMissing}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %w", key, err))
+			continue
+		}
+		statuses[key] = Status{Code: StatusUpdated, Version: version}
+	}
+	return statuses, errors.Join(errs...)
+}
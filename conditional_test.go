@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetIfChanged(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+
+	var val string
+	_, _, err := rdb.GetIfChanged(context.Background(), "key", "", &val)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value1", 0))
+	changed, version, err := rdb.GetIfChanged(context.Background(), "key", "", &val)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotEmpty(t, version)
+	assert.Equal(t, "value1", val)
+
+	val = ""
+	changed, unchanged, err := rdb.GetIfChanged(context.Background(), "key", version, &val)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, version, unchanged)
+	assert.Empty(t, val)
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value2", 0))
+	changed, updated, err := rdb.GetIfChanged(context.Background(), "key", version, &val)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotEqual(t, version, updated)
+	assert.Equal(t, "value2", val)
+}
+
+func TestCache_GetIfChanged_MigrationMode(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	legacy, _ := json.Marshal("legacy")
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("key").Value(string(legacy)).Build()).Error())
+
+	rdb := New(client, WithMigrationMode(JSONEncoding(), MsgpackEncoding()))
+
+	var val string
+	changed, version, err := rdb.GetIfChanged(context.Background(), "key", "", &val)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "legacy", val)
+
+	changed, _, err = rdb.GetIfChanged(context.Background(), "key", version, &val)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "migrated", 0))
+	changed, _, err = rdb.GetIfChanged(context.Background(), "key", version, &val)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "migrated", val)
+}
+
+func TestCache_MGetIfChanged(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	ctx := context.Background()
+
+	assert.NoError(t, rdb.Set(ctx, "a", "a1", 0))
+	assert.NoError(t, rdb.Set(ctx, "b", "b1", 0))
+
+	var a, b, c string
+	dsts := map[string]any{"a": &a, "b": &b, "c": &c}
+	statuses, err := rdb.MGetIfChanged(ctx, map[string]string{"a": "", "b": "", "c": ""}, dsts)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUpdated, statuses["a"].Code)
+	assert.Equal(t, StatusUpdated, statuses["b"].Code)
+	assert.Equal(t, Status{Code: StatusMissing}, statuses["c"])
+	assert.Equal(t, "a1", a)
+	assert.Equal(t, "b1", b)
+
+	assert.NoError(t, rdb.Set(ctx, "b", "b2", 0))
+	a, b = "", ""
+	next, err := rdb.MGetIfChanged(ctx, map[string]string{
+		"a": statuses["a"].Version,
+		"b": statuses["b"].Version,
+	}, dsts)
+	require.NoError(t, err)
+	assert.Equal(t, Status{Code: StatusUnchanged, Version: statuses["a"].Version}, next["a"])
+	assert.Equal(t, StatusUpdated, next["b"].Code)
+	assert.NotEqual(t, statuses["b"].Version, next["b"].Version)
+	assert.Empty(t, a)
+	assert.Equal(t, "b2", b)
+
+	_, err = rdb.MGetIfChanged(ctx, map[string]string{"a": ""}, map[string]any{"a": a})
+	assert.Error(t, err)
+}
+
+func TestCache_MGetIfChanged_MigrationMode(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	legacy, _ := json.Marshal("legacy")
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("a").Value(string(legacy)).Build()).Error())
+
+	rdb := New(client, WithMigrationMode(JSONEncoding(), MsgpackEncoding()))
+	assert.NoError(t, rdb.Set(context.Background(), "b", "migrated", 0))
+
+	var a, b string
+	statuses, err := rdb.MGetIfChanged(context.Background(), map[string]string{"a": "", "b": ""},
+		map[string]any{"a": &a, "b": &b})
+	require.NoError(t, err)
+	assert.Equal(t, StatusUpdated, statuses["a"].Code)
+	assert.Equal(t, StatusUpdated, statuses["b"].Code)
+	assert.Equal(t, "legacy", a)
+	assert.Equal(t, "migrated", b)
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/jkratz55/rueidis-cache/compression/brotli"
+	"github.com/jkratz55/rueidis-cache/compression/flate"
+	"github.com/jkratz55/rueidis-cache/compression/gzip"
+	"github.com/jkratz55/rueidis-cache/compression/lz4"
+	"github.com/jkratz55/rueidis-cache/compression/zstd"
+)
+
+// CacheConfig is a read-only snapshot of the configuration a Cache was built
+// with. It is intended for diagnostics such as logging the configuration at
+// startup, or asserting expectations in tests.
+//
+// Modifying a CacheConfig has no effect on the Cache it was retrieved from.
+type CacheConfig struct {
+	// Serialization is the name of the serialization used for values: msgpack,
+	// json, or custom when provided through the Serialization Option.
+	Serialization string
+
+	// Compression is the name of the compression Codec used for values, or none
+	// if compression is not enabled. Codecs that are not built into this package
+	// are identified by their type name unless they implement a Name method.
+	Compression string
+
+	// NearCacheEnabled indicates if server assisted client side caching is used.
+	NearCacheEnabled bool
+
+	// NearCacheTTL is the maximum duration entries are kept in the client side
+	// cache. NearCacheTTL is zero if near caching is not enabled.
+	NearCacheTTL time.Duration
+
+	// NearCacheMode is the tracking mode configured with NearCacheMode.
+	NearCacheMode TrackingMode
+
+	// NearCachePrefixes are the key prefixes near caching is restricted to. An
+	// empty slice indicates all keys are cached locally.
+	NearCachePrefixes []string
+
+	// NearCacheMaxMemory is the bound on client side cache memory configured
+	// with NearCacheMaxMemory. A value of zero indicates the rueidis default of
+	// rueidis.DefaultCacheBytes per connection.
+	NearCacheMaxMemory int
+
+	// NearCacheMaxValueSize is the maximum size of values cached in the client
+	// side cache configured with NearCacheMaxValueSize. A value of zero indicates
+	// the size of values isn't limited.
+	NearCacheMaxValueSize int
+
+	// NearCacheWriteThrough indicates if Set populates the near cache, configured
+	// with NearCacheWriteThrough.
+	NearCacheWriteThrough bool
+
+	// MGetBatchSize is the maximum number of keys per MGET command. A value of
+	// zero indicates batching is disabled.
+	MGetBatchSize int
+
+	// GenerationBusting indicates if keys are prefixed with a generation allowing
+	// the entire Cache to be invalidated using BumpGeneration.
+	GenerationBusting bool
+
+	// Hooks is the number of Hooks added to the Cache.
+	Hooks int
+
+	// Tags are the tags configured with WithTags.
+	Tags map[string]string
+
+	// InMemory indicates the Cache is backed by the in-process store started
+	// by NewWithFallback because Redis was unavailable.
+	InMemory bool
+}
+
+// Config returns a snapshot of the configuration of the Cache.
+func (c *Cache) Config() CacheConfig {
+	return CacheConfig{
+		Serialization:         c.serialization,
+		Compression:           codecName(c.codec),
+		NearCacheEnabled:      c.nearCacheEnabled,
+		NearCacheTTL:          c.nearCacheTTL,
+		NearCacheMode:         c.nearCacheMode,
+		NearCachePrefixes:     append([]string(nil), c.nearCachePrefix...),
+		NearCacheMaxMemory:    c.nearCacheMaxMem,
+		NearCacheMaxValueSize: c.nearCacheMaxVal,
+		NearCacheWriteThrough: c.nearWriteThrough,
+		MGetBatchSize:         c.mgetBatch,
+		GenerationBusting:     c.generation != nil,
+		Hooks:                 len(c.hooksMixin.hooks),
+		Tags:                  maps.Clone(c.tags),
+		InMemory:              c.memory != nil,
+	}
+}
+
+// codecName returns a human-readable name for the provided Codec.
+func codecName(codec Codec) string {
+	switch codec.(type) {
+	case nopCodec, *nopCodec:
+		return "none"
+	case flate.Codec, *flate.Codec:
+		return "flate"
+	case *gzip.Codec:
+		return "gzip"
+	case *lz4.Codec:
+		return "lz4"
+	case *brotli.Codec:
+		return "brotli"
+	case *zstd.Codec:
+		return "zstd"
+	}
+	if named, ok := codec.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return fmt.Sprintf("%T", codec)
+}
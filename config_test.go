@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Config(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	conf := New(client).Config()
+	assert.Equal(t, CacheConfig{
+		Serialization: "msgpack",
+		Compression:   "none",
+	}, conf)
+
+	conf = New(client, JSON(), LZ4(), NearCache(time.Minute), BatchMultiGets(100)).Config()
+	assert.Equal(t, CacheConfig{
+		Serialization:    "json",
+		Compression:      "lz4",
+		NearCacheEnabled: true,
+		NearCacheTTL:     time.Minute,
+		MGetBatchSize:    100,
+	}, conf)
+
+	conf = New(client, NearCache(time.Minute), NearCacheMaxMemory(32<<20)).Config()
+	assert.Equal(t, 32<<20, conf.NearCacheMaxMemory)
+
+	conf = New(client, NearCacheMaxMemory(-1)).Config()
+	assert.Zero(t, conf.NearCacheMaxMemory)
+
+	conf = New(client, Serialization(DefaultMarshaller(), DefaultUnmarshaller()), Flate()).Config()
+	assert.Equal(t, "custom", conf.Serialization)
+	assert.Equal(t, "flate", conf.Compression)
+
+	conf = New(client, Zstd(3)).Config()
+	assert.Equal(t, "zstd", conf.Compression)
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// GetConsistent retrieves multiple related entries from the Cache as a single
+// consistent snapshot and unmarshalls each value into the destination in dsts
+// for its key. All the keys are read with a single MGET, which Redis executes
+// atomically, so no concurrent write is observed partially, such as an object
+// updated without its index.
+//
+// Unlike MGet, GetConsistent bypasses the near cache, as entries cached locally
+// are invalidated independently, and flushes buffered writes first. The cost of
+// a consistent read is therefore a round trip to Redis on every call.
+//
+// On Redis Cluster all the keys must hash to the same slot, which requires the
+// keys to share a hash tag such as {user:1}:profile and {user:1}:index. If they
+// don't an error wrapping ErrCrossSlot is returned. While migrating between
+// Encodings the keys of the target Encoding are read in the same MGET, so they
+// must hash to the same slot as well.
+//
+// Every key must have a non-nil pointer destination in dsts. The destinations of
+// keys that don't exist are set to their zero value, and a *MissingKeysError
+// reporting those keys is returned. Errors decoding individual keys are joined
+// with it, so the remaining destinations are still populated.
+func (c *Cache) GetConsistent(ctx context.Context, keys []string, dsts map[string]any) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if rv := reflect.ValueOf(dsts[key]); rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return fmt.Errorf("key %s: destination must be a non-nil pointer, got %T", key, dsts[key])
+		}
+	}
+
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	readKeys := redisKeys
+	if c.migration != nil {
+		// The target Encoding is read first, falling back to the source Encoding
+		readKeys = make([]string, 0, len(redisKeys)*2)
+		for _, redisKey := range redisKeys {
+			readKeys = append(readKeys, c.migration.key(redisKey))
+		}
+		readKeys = append(readKeys, redisKeys...)
+	}
+	if c.cluster && !sameSlot(readKeys...) {
+		return fmt.Errorf("consistent read: %w", ErrCrossSlot)
+	}
+	// Buffered writes must be flushed first or the snapshot would miss them
+	if err := c.FlushWrites(ctx); err != nil {
+		return err
+	}
+
+	results, err := c.redis.Do(ctx, c.redis.B().Mget().Key(readKeys...).Build()).ToArray()
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+
+	var (
+		missing []string
+		errs    []error
+	)
+	for i, key := range keys {
+		res, unmarshall := results[i], c.hooksMixin.current.unmarshall
+		if c.migration != nil {
+			unmarshall = c.migration.to.Unmarshaller
+			if res.IsNil() {
+				res, unmarshall = results[len(keys)+i], c.hooksMixin.current.unmarshall
+			}
+		}
+		if res.IsNil() {
+			c.hooksMixin.miss(key)
+			reflect.ValueOf(dsts[key]).Elem().SetZero()
+			missing = append(missing, key)
+			continue
+		}
+		data, err := res.AsBytes()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: redis: %w", key, err))
+			continue
+		}
+		if c.migration != nil {
+			err = c.decodeMigrating(ctx, key, data, dsts[key], unmarshall)
+		} else {
+			err = c.decode(ctx, key, data, dsts[key])
+		}
+		err = c.recordRead(key, err)
+		if errors.Is(err, ErrKeyNotFound) {
+			// The value failed read validation or is stale and is treated as a miss
+			reflect.ValueOf(dsts[key]).Elem().SetZero()
+			missing = append(missing, key)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %w", key, err))
+		}
+	}
+
+	if len(missing) > 0 {
+		errs = append(errs, &MissingKeysError{Keys: missing})
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetConsistent(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	require.NoError(t, rdb.Set(context.Background(), "{user:1}:profile", "alice", 0))
+	require.NoError(t, rdb.Set(context.Background(), "{user:1}:index", 7, 0))
+
+	var (
+		profile string
+		index   int
+		extra   = "stale"
+	)
+	keys := []string{"{user:1}:profile", "{user:1}:index", "{user:1}:extra"}
+	err := rdb.GetConsistent(context.Background(), keys, map[string]any{
+		"{user:1}:profile": &profile,
+		"{user:1}:index":   &index,
+		"{user:1}:extra":   &extra,
+	})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	var missingErr *MissingKeysError
+	require.True(t, errors.As(err, &missingErr))
+	assert.Equal(t, []string{"{user:1}:extra"}, missingErr.Keys)
+	assert.Equal(t, "alice", profile)
+	assert.Equal(t, 7, index)
+	assert.Equal(t, "", extra)
+
+	// Every key requires a destination
+	err = rdb.GetConsistent(context.Background(), keys[:2], map[string]any{"{user:1}:profile": &profile})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrKeyNotFound)
+
+	// Keys must hash to the same slot on Redis Cluster
+	rdb.cluster = true
+	assert.NoError(t, rdb.GetConsistent(context.Background(), keys[:2], map[string]any{
+		"{user:1}:profile": &profile,
+		"{user:1}:index":   &index,
+	}))
+	err = rdb.GetConsistent(context.Background(), []string{"user:1", "user:2"}, map[string]any{
+		"user:1": &profile,
+		"user:2": &profile,
+	})
+	assert.ErrorIs(t, err, ErrCrossSlot)
+}
+
+func TestCache_GetConsistent_Migration(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	from := New(client)
+	require.NoError(t, from.Set(context.Background(), "a", "source", 0))
+
+	rdb := New(client, WithMigrationMode(MsgpackEncoding(), JSONEncoding()))
+	require.NoError(t, rdb.Set(context.Background(), "b", "target", 0))
+
+	var a, b string
+	require.NoError(t, rdb.GetConsistent(context.Background(), []string{"a", "b"}, map[string]any{"a": &a, "b": &b}))
+	assert.Equal(t, "source", a)
+	assert.Equal(t, "target", b)
+}
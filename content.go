@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// ContentHasher is a function type that hashes the marshalled value of an entry
+// stored with Put, returning the key the entry is stored under.
+type ContentHasher func(data []byte) string
+
+// SHA256ContentHasher is the default ContentHasher, returning the hex encoded
+// SHA-256 digest of the data.
+func SHA256ContentHasher(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put adds an entry into the cache under a key derived from its content and
+// returns the key. The value is marshalled and hashed with the ContentHasher
+// configured with WithContentHasher, SHA-256 by default, so equal values are
+// stored once under the same key regardless of how many times they are put.
+// This suits deduplicating large immutable values such as documents or
+// rendered templates. If the ttl value is <= 0 the key will be persisted
+// indefinitely, putting an existing value refreshes its TTL.
+//
+// The key is derived from the marshalled value before compression, so it is
+// stable across Codecs but requires marshalling to be deterministic. The default
+// msgpack serialization doesn't sort map keys, so values containing maps may be
+// stored under multiple keys.
+func (c *Cache) Put(ctx context.Context, v any, ttl time.Duration) (string, error) {
+	data, err := c.marshaller(v)
+	if err != nil {
+		return "", fmt.Errorf("marshall value: %w", err)
+	}
+	key := c.contentHasher(data)
+	if err := c.Set(ctx, key, v, ttl); err != nil {
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Put(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, LZ4())
+	key, err := rdb.Put(context.Background(), "document", time.Minute)
+	require.NoError(t, err)
+	data, _ := DefaultMarshaller()("document")
+	assert.Equal(t, SHA256ContentHasher(data), key)
+
+	// Equal values are stored once under the same key
+	again, err := rdb.Put(context.Background(), "document", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+	assert.Len(t, server.Keys(), 1)
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), key, &val))
+	assert.Equal(t, "document", val)
+
+	other, err := rdb.Put(context.Background(), "other", time.Minute)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestCache_Put_ContentHasher(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithContentHasher(func(data []byte) string {
+		h := fnv.New64a()
+		_, _ = h.Write(data)
+		return "fnv:" + strconv.FormatUint(h.Sum64(), 16)
+	}))
+	key, err := rdb.Put(context.Background(), "document", 0)
+	require.NoError(t, err)
+	assert.Regexp(t, "^fnv:[0-9a-f]+$", key)
+	assert.True(t, server.Exists(key))
+
+	assert.Panics(t, func() {
+		WithContentHasher(nil)
+	})
+}
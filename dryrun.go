@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrDryRun is matched by the *DryRunReport returned in place of performing a
+// mutation when the Cache is configured with WithDryRun.
+var ErrDryRun = errors.New("dry run")
+
+// ErrDryRunUnsupported is returned by mutating methods that can't report what
+// they would have done when the Cache is configured with WithDryRun. Rather than
+// writing to Redis, these methods fail without side effects.
+var ErrDryRunUnsupported = errors.New("operation not supported in dry run")
+
+// DryRunEntry describes a single key a mutation would have affected.
+type DryRunEntry struct {
+	// Key is the key as provided to the Cache.
+	Key string
+
+	// RedisKey is the key as it would be stored in Redis, after key transforms
+	// and generation prefixes are applied.
+	RedisKey string
+
+	// Size is the size in bytes of the value as it would be stored in Redis. It
+	// is zero for deletes.
+	Size int
+}
+
+// DryRunReport describes a mutation that wasn't performed because the Cache is
+// configured with WithDryRun. It is returned as the error of the mutating call,
+// and can be retrieved with errors.As.
+type DryRunReport struct {
+	// Operation is the method of the Cache that was called, such as "Set".
+	Operation string
+
+	// Entries are the keys the mutation would have written or removed, sorted by
+	// Key. Flush reports no entries.
+	Entries []DryRunEntry
+
+	// Count is the number of keys that would have been affected, determined by
+	// reading the current state of Redis. For conditional writes and deletes it
+	// can be lower than the number of Entries.
+	Count int
+
+	// Bytes is the total size in bytes of the values that would have been
+	// written.
+	Bytes int
+}
+
+// Error implements the error interface.
+func (r *DryRunReport) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "dry run: %s would affect %d keys", r.Operation, r.Count)
+	if r.Bytes > 0 {
+		fmt.Fprintf(&sb, " writing %d bytes", r.Bytes)
+	}
+	return sb.String()
+}
+
+// Is reports if target is ErrDryRun.
+func (r *DryRunReport) Is(target error) bool {
+	return target == ErrDryRun
+}
+
+// newDryRunReport builds the report of a mutation of entries, counting each of
+// them as affected.
+func newDryRunReport(op string, entries []DryRunEntry) *DryRunReport {
+	slices.SortFunc(entries, func(a, b DryRunEntry) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	report := &DryRunReport{
+		Operation: op,
+		Entries:   entries,
+		Count:     len(entries),
+	}
+	for _, entry := range entries {
+		report.Bytes += entry.Size
+	}
+	return report
+}
+
+// dryRunUnsupported returns the error of a mutating method that doesn't support
+// dry run.
+func dryRunUnsupported(op string) error {
+	return fmt.Errorf("%s: %w", op, ErrDryRunUnsupported)
+}
+
+// dryRunExisting counts how many of the entries currently exist in Redis. Each
+// key is checked with its own command so the keys may hash to different slots.
+func (c *Cache) dryRunExisting(ctx context.Context, entries []DryRunEntry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	redisKeys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		redisKeys = append(redisKeys, entry.RedisKey)
+	}
+	exists, err := c.existing(ctx, redisKeys)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, ok := range exists {
+		if ok {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// dryRunFlush reports the number of keys a FLUSHDB would have removed.
+func (c *Cache) dryRunFlush(ctx context.Context, op string) error {
+	n, err := c.redis.Do(ctx, c.redis.B().Dbsize().Build()).AsInt64()
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	return &DryRunReport{Operation: op, Count: int(n)}
+}
@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_WithDryRun(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	require.NoError(t, server.Set("existing", "value"))
+	rdb := New(client, WithDryRun(), WithKeyTransforms(PrefixKeys("app:")))
+
+	var report *DryRunReport
+	err := rdb.Set(ctx, "key", "value", 0)
+	assert.ErrorIs(t, err, ErrDryRun)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "Set", report.Operation)
+	require.Len(t, report.Entries, 1)
+	assert.Equal(t, "key", report.Entries[0].Key)
+	assert.Equal(t, "app:key", report.Entries[0].RedisKey)
+	assert.Positive(t, report.Entries[0].Size)
+	assert.Equal(t, report.Entries[0].Size, report.Bytes)
+	assert.Equal(t, 1, report.Count)
+	assert.False(t, server.Exists("app:key"))
+
+	err = rdb.MSet(ctx, map[string]any{"b": 2, "a": 1})
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "MSet", report.Operation)
+	require.Len(t, report.Entries, 2)
+	assert.Equal(t, "a", report.Entries[0].Key)
+	assert.Equal(t, "b", report.Entries[1].Key)
+	assert.Equal(t, 2, report.Count)
+	assert.Equal(t, report.Entries[0].Size+report.Entries[1].Size, report.Bytes)
+	assert.False(t, server.Exists("app:a"))
+
+	// Reads still work normally
+	var v string
+	assert.ErrorIs(t, rdb.Get(ctx, "key", &v), ErrKeyNotFound)
+
+	require.NoError(t, server.Set("app:existing", "value"))
+	err = rdb.Delete(ctx, "existing", "missing")
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "Delete", report.Operation)
+	assert.Len(t, report.Entries, 2)
+	assert.Equal(t, 1, report.Count)
+	assert.Zero(t, report.Bytes)
+	assert.True(t, server.Exists("app:existing"))
+
+	_, err = rdb.DeleteWithResult(ctx, "existing")
+	assert.ErrorIs(t, err, ErrDryRun)
+	assert.True(t, server.Exists("app:existing"))
+
+	err = rdb.Flush(ctx)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, 2, report.Count)
+	assert.True(t, server.Exists("existing"))
+}
+
+func TestCache_WithDryRun_Conditional(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	require.NoError(t, server.Set("existing", "value"))
+	rdb := New(client, WithDryRun())
+
+	var report *DryRunReport
+	_, err := rdb.SetIfAbsent(ctx, "existing", "new", 0)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "SetIfAbsent", report.Operation)
+	assert.Zero(t, report.Count)
+	assert.Zero(t, report.Bytes)
+
+	_, err = rdb.SetIfPresent(ctx, "existing", "new", 0)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, 1, report.Count)
+	assert.Positive(t, report.Bytes)
+
+	got, err := server.Get("existing")
+	require.NoError(t, err)
+	assert.Equal(t, "value", got)
+}
+
+func TestCache_WithDryRun_Unsupported(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	require.NoError(t, server.Set("existing", "value"))
+	_, err := server.SetAdd("s1", "a", "b")
+	require.NoError(t, err)
+	rdb := New(client, WithDryRun(), WithGenerationBusting())
+
+	var v string
+	cb := func(found bool, oldValue string, newValue string) string {
+		return newValue
+	}
+	ops := map[string]func() error{
+		"Upsert": func() error {
+			return Upsert[string](ctx, rdb, "existing", "new", cb, time.Minute)
+		},
+		"GetAndUpdateTTL": func() error {
+			return rdb.GetAndUpdateTTL(ctx, "existing", &v, time.Minute)
+		},
+		"MGetEx": func() error {
+			_, err := MGetEx[string](ctx, rdb, time.Minute, "existing")
+			return err
+		},
+		"Expire": func() error {
+			_, err := rdb.Expire(ctx, "existing", time.Minute)
+			return err
+		},
+		"Persist": func() error {
+			_, err := rdb.Persist(ctx, "existing")
+			return err
+		},
+		"ExtendTTL": func() error {
+			return rdb.ExtendTTL(ctx, "existing", time.Minute)
+		},
+		"SetOnce": func() error {
+			_, err := rdb.SetOnce(ctx, "existing", "new", time.Minute, "id")
+			return err
+		},
+		"SetVersionedTime": func() error {
+			_, err := rdb.SetVersionedTime(ctx, "existing", "new", 1, time.Minute)
+			return err
+		},
+		"DeleteVersionedTime": func() error {
+			_, err := rdb.DeleteVersionedTime(ctx, "existing", 1, time.Minute)
+			return err
+		},
+		"SwapSnapshot": func() error {
+			return rdb.SwapSnapshot(ctx, map[string]any{"existing": "new"}, time.Minute)
+		},
+		"BumpGeneration": func() error {
+			return rdb.BumpGeneration(ctx)
+		},
+		"Recompress": func() error {
+			_, err := rdb.Recompress(ctx, []string{"existing"}, reverseCodec{})
+			return err
+		},
+		"SInterStore": func() error {
+			_, err := rdb.SInterStore(ctx, "dst", "s1")
+			return err
+		},
+		"SUnionStore": func() error {
+			_, err := rdb.SUnionStore(ctx, "dst", "s1")
+			return err
+		},
+		"SDiffStore": func() error {
+			_, err := rdb.SDiffStore(ctx, "dst", "s1")
+			return err
+		},
+	}
+	keys := server.Keys()
+	for op, fn := range ops {
+		t.Run(op, func(t *testing.T) {
+			assert.ErrorIs(t, fn(), ErrDryRunUnsupported)
+		})
+	}
+
+	// Nothing was written to Redis
+	assert.Equal(t, keys, server.Keys())
+	assert.Equal(t, time.Duration(0), server.TTL("existing"))
+	assert.False(t, server.Exists("dst"))
+}
+
+func TestCache_WithDryRun_Headers(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithDryRun())
+
+	var report *DryRunReport
+	err := rdb.SetWithMeta(ctx, "meta", "value", map[string]string{"owner": "alice"}, time.Minute)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "SetWithMeta", report.Operation)
+	assert.Equal(t, 1, report.Count)
+
+	err = rdb.SetSoftHard(ctx, "soft", "value", time.Second, time.Minute)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "Set", report.Operation)
+	assert.Equal(t, 1, report.Count)
+
+	// Tombstones written for misses reported by the loader of GetOrSet
+	negative := New(client, WithDryRun(), WithNegativeCaching(time.Second, time.Minute, 2))
+	var v string
+	err = negative.GetOrSet(ctx, "missing", &v, time.Minute, func(ctx context.Context) (any, error) {
+		return nil, ErrKeyNotFound
+	})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	require.True(t, errors.As(err, &report))
+	assert.Equal(t, "SetTombstone", report.Operation)
+	assert.Equal(t, 1, report.Count)
+
+	assert.Empty(t, server.Keys())
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/rueidis"
+)
+
+// DefaultMGetEachBatchSize is the number of keys MGetEach reads from Redis at a
+// time, unless a batch size is configured with BatchMultiGets.
+const DefaultMGetEachBatchSize = 100
+
+// MGetEach retrieves the values of multiple keys and unmarshalls them one at a
+// time, invoking fn with each key and its value once unmarshalled. Unlike MGet,
+// which holds every value in memory until all of them are decoded, values are
+// decoded and handed to fn incrementally, so memory is bounded by the batch
+// being read rather than by the number of keys. This suits reads of thousands of
+// large values by memory sensitive consumers.
+//
+// The value of each key is unmarshalled into the destination returned by dst for
+// the key, which must be a pointer as with Get. The destination can be reused
+// across keys once fn returns, for example to avoid allocating a value per key,
+// as long as fn doesn't retain it.
+//
+// The keys are read in batches of DefaultMGetEachBatchSize keys, or the batch
+// size configured with BatchMultiGets, with a GET per key in a pipeline, so the
+// keys may hash to different slots on Redis Cluster. The next batch is only read
+// once fn has been invoked for every key of the previous batch. fn is invoked in
+// the order of the keys, and isn't invoked for keys that don't exist, or whose
+// value is treated as a miss such as a stale value.
+//
+// Iteration stops at the first error returned by fn, which is returned as is, or
+// by reading or decoding a value. The context is checked before each key, so
+// cancelling the context stops iteration and returns the context error.
+func (c *Cache) MGetEach(
+	ctx context.Context,
+	keys []string,
+	dst func(key string) any,
+	fn func(key string, dst any) error) error {
+
+	if dst == nil || fn == nil {
+		panic(fmt.Errorf("nil dst or fn not permitted, illegal use of api"))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// The target Encoding may be stored in a different slot while migrating, so
+	// each key is read with Get instead.
+	if c.migration != nil {
+		for _, key := range keys {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			v := dst(key)
+			err := c.Get(ctx, key, v)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := fn(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return err
+	}
+	batchSize := c.mgetBatch
+	if batchSize <= 0 {
+		batchSize = DefaultMGetEachBatchSize
+	}
+	for offset, redisChunk := range chunk(redisKeys, batchSize) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		results := c.getEachBatch(ctx, redisChunk)
+		for i, res := range results {
+			// Values are released as they are decoded, so memory held by the
+			// batch shrinks as iteration progresses.
+			results[i] = rueidis.RedisResult{}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			key := keys[offset*batchSize+i]
+			if err := c.decodeEach(ctx, key, redisChunk[i], res, dst, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getEachBatch reads the keys with a GET per key in a single pipeline. Keys with
+// a write buffered by WithWriteBatching are resolved from the write buffer when
+// decoded, and are still read from Redis as the pipeline is built up front.
+func (c *Cache) getEachBatch(ctx context.Context, redisKeys []string) []rueidis.RedisResult {
+	if c.nearCacheable(redisKeys...) {
+		cmds := make([]rueidis.CacheableTTL, 0, len(redisKeys))
+		for _, redisKey := range redisKeys {
+			cmds = append(cmds, rueidis.CacheableTTL{
+				Cmd: c.redis.B().Get().Key(redisKey).Cache(),
+				TTL: c.nearCacheTTL,
+			})
+		}
+		return c.redis.DoMultiCache(ctx, cmds...)
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys))
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, c.redis.B().Get().Key(redisKey).Build())
+	}
+	return c.redis.DoMulti(ctx, cmds...)
+}
+
+// decodeEach unmarshalls the value of a key read by MGetEach into the
+// destination for the key and invokes fn with it, unless the key doesn't exist
+// or its value is treated as a miss.
+func (c *Cache) decodeEach(
+	ctx context.Context,
+	key, redisKey string,
+	res rueidis.RedisResult,
+	dst func(key string) any,
+	fn func(key string, dst any) error) error {
+
+	if msg, err := res.ToMessage(); err == nil {
+		c.observeSize(redisKey, msg)
+	}
+	data, ok := c.buffered(redisKey)
+	if !ok {
+		var err error
+		data, err = res.AsBytes()
+		if errors.Is(err, rueidis.Nil) {
+			c.hooksMixin.miss(key)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	v := dst(key)
+	err := c.recordRead(key, c.decode(ctx, key, data, v))
+	if errors.Is(err, ErrKeyNotFound) {
+		// The value failed read validation or is stale and is treated as a miss
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fn(key, v)
+}
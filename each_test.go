@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_MGetEach(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, BatchMultiGets(2))
+	keys := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		keys = append(keys, key)
+		if i == 3 {
+			continue
+		}
+		require.NoError(t, rdb.Set(ctx, key, i, time.Minute))
+	}
+
+	// A single destination is reused across keys
+	var (
+		val  int
+		seen []string
+		vals []int
+	)
+	err := rdb.MGetEach(ctx, keys, func(key string) any {
+		val = 0
+		return &val
+	}, func(key string, dst any) error {
+		seen = append(seen, key)
+		vals = append(vals, *dst.(*int))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key:0", "key:1", "key:2", "key:4"}, seen)
+	assert.Equal(t, []int{0, 1, 2, 4}, vals)
+
+	// Iteration stops at the first error returned by fn
+	boom := errors.New("boom")
+	calls := 0
+	err = rdb.MGetEach(ctx, keys, func(key string) any { return new(int) }, func(key string, dst any) error {
+		calls++
+		return boom
+	})
+	assert.Equal(t, boom, err)
+	assert.Equal(t, 1, calls)
+
+	assert.NoError(t, rdb.MGetEach(ctx, nil, func(key string) any { return new(int) }, func(key string, dst any) error {
+		return boom
+	}))
+	assert.Panics(t, func() { _ = rdb.MGetEach(ctx, keys, nil, nil) })
+}
+
+func TestCache_MGetEach_Cancelled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rdb := New(client)
+	keys := []string{"a", "b", "c"}
+	for _, key := range keys {
+		require.NoError(t, rdb.Set(ctx, key, key, 0))
+	}
+
+	var seen []string
+	err := rdb.MGetEach(ctx, keys, func(key string) any { return new(string) }, func(key string, dst any) error {
+		seen = append(seen, key)
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"a"}, seen)
+}
+
+func TestCache_MGetEach_Buffered(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithWriteBatching(100, time.Hour))
+	defer rdb.Close(ctx)
+	require.NoError(t, rdb.Set(ctx, "key", "buffered", 0))
+	assert.False(t, server.Exists("key"))
+
+	values := map[string]string{}
+	err := rdb.MGetEach(ctx, []string{"key", "missing"}, func(key string) any { return new(string) },
+		func(key string, dst any) error {
+			values[key] = *dst.(*string)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "buffered"}, values)
+}
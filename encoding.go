@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"encoding/json"
+
 	"github.com/vmihailenco/msgpack/v5"
 )
 
@@ -27,3 +29,33 @@ func DefaultUnmarshaller() Unmarshaller {
 		return msgpack.Unmarshal(b, v)
 	}
 }
+
+// Encoding is a named pair of a Marshaller and Unmarshaller.
+type Encoding struct {
+	Name         string
+	Marshaller   Marshaller
+	Unmarshaller Unmarshaller
+}
+
+// MsgpackEncoding returns an Encoding using msgpack, the default serialization
+// of the Cache.
+func MsgpackEncoding() Encoding {
+	return Encoding{
+		Name:         "msgpack",
+		Marshaller:   DefaultMarshaller(),
+		Unmarshaller: DefaultUnmarshaller(),
+	}
+}
+
+// JSONEncoding returns an Encoding using JSON.
+func JSONEncoding() Encoding {
+	return Encoding{
+		Name: "json",
+		Marshaller: func(v any) ([]byte, error) {
+			return json.Marshal(v)
+		},
+		Unmarshaller: func(b []byte, v any) error {
+			return json.Unmarshal(b, v)
+		},
+	}
+}
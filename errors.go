@@ -23,3 +23,17 @@ func (e RetryableError) IsRetryable() bool {
 func (e RetryableError) Error() string {
 	return e.cause.Error()
 }
+
+func (e RetryableError) Unwrap() error {
+	return e.cause
+}
+
+// NonRetryable wraps an error returned by a loader to signal the operation must
+// not be retried by a RetryPolicy, for example when the value doesn't exist in
+// the source of truth.
+func NonRetryable(err error) error {
+	return RetryableError{
+		retryable: false,
+		cause:     err,
+	}
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"sync"
+)
+
+// DefaultEventBuffer is the number of hit and miss events buffered for the
+// callbacks registered with OnHit and OnMiss before events are dropped.
+const DefaultEventBuffer = 1024
+
+// accessEvent is a cache hit or miss for a key.
+type accessEvent struct {
+	key string
+	hit bool
+}
+
+// accessEvents is an AccessHook that dispatches hits and misses to callbacks
+// from a background goroutine. Events are sent through a buffered channel and
+// dropped when the buffer is full so the read path is never blocked.
+type accessEvents struct {
+	onHit  func(key string)
+	onMiss func(key string)
+	events chan accessEvent
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newAccessEvents(onHit, onMiss func(key string)) *accessEvents {
+	return &accessEvents{
+		onHit:  onHit,
+		onMiss: onMiss,
+		events: make(chan accessEvent, DefaultEventBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+func (e *accessEvents) Hit(key string) {
+	if e.onHit != nil {
+		e.send(accessEvent{key: key, hit: true})
+	}
+}
+
+func (e *accessEvents) Miss(key string) {
+	if e.onMiss != nil {
+		e.send(accessEvent{key: key})
+	}
+}
+
+func (e *accessEvents) send(event accessEvent) {
+	select {
+	case e.events <- event:
+	default:
+		// The buffer is full, drop the event rather than blocking the read
+	}
+}
+
+// run invokes the callbacks for each event until close is called.
+func (e *accessEvents) run() {
+	for {
+		select {
+		case <-e.done:
+			return
+		case event := <-e.events:
+			if event.hit {
+				e.onHit(event.key)
+			} else {
+				e.onMiss(event.key)
+			}
+		}
+	}
+}
+
+// close stops the background goroutine. Events still buffered are discarded.
+func (e *accessEvents) close() {
+	e.once.Do(func() {
+		close(e.done)
+	})
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_OnHitOnMiss(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var (
+		mu     sync.Mutex
+		hits   []string
+		misses []string
+	)
+	rdb := New(client,
+		OnHit(func(key string) {
+			mu.Lock()
+			defer mu.Unlock()
+			hits = append(hits, key)
+		}),
+		OnMiss(func(key string) {
+			mu.Lock()
+			defer mu.Unlock()
+			misses = append(misses, key)
+		}))
+	defer rdb.Close(context.Background())
+
+	assert.NoError(t, rdb.Set(context.Background(), "key1", "value", 0))
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key1", &val))
+	assert.ErrorIs(t, rdb.Get(context.Background(), "key2", &val), ErrKeyNotFound)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(hits) == 1 && len(misses) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"key1"}, hits)
+	assert.Equal(t, []string{"key2"}, misses)
+}
+
+func TestAccessEvents_Drop(t *testing.T) {
+	block := make(chan struct{})
+	events := newAccessEvents(nil, func(key string) {
+		<-block
+	})
+	go events.run()
+	defer events.close()
+	defer close(block)
+
+	// Sending never blocks even when the callback does and the buffer is full
+	assert.NotPanics(t, func() {
+		for i := 0; i < DefaultEventBuffer*2; i++ {
+			events.Miss("key")
+			events.Hit("key")
+		}
+	})
+	assert.Len(t, events.events, DefaultEventBuffer)
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/rueidis"
+)
+
+// GetFirst retrieves the first entry found for the given keys, in order, and
+// unmarshalls the value into dst. The key that was found is returned. This models
+// layered lookups where each layer is a separate key, such as a user override
+// falling back to a team default and then a global default.
+//
+// All the keys are read in a single pipeline, so the keys don't need to hash to
+// the same slot when using Redis Cluster. Keys whose value is stale or fails read
+// validation are skipped like keys that don't exist. If none of the keys exist
+// ErrKeyNotFound will be returned as the error value.
+func (c *Cache) GetFirst(ctx context.Context, keys []string, dst any) (string, error) {
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return "", err
+	}
+
+	// The target Encoding may be stored in a different slot while migrating, so
+	// each key is read with Get instead.
+	if c.migration != nil {
+		for _, key := range keys {
+			err := c.Get(ctx, key, dst)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			return key, nil
+		}
+		return "", ErrKeyNotFound
+	}
+
+	for i, res := range c.getPipelined(ctx, redisKeys) {
+		data, ok := c.buffered(redisKeys[i])
+		if !ok {
+			data, err = res.AsBytes()
+			if errors.Is(err, rueidis.Nil) {
+				c.hooksMixin.miss(keys[i])
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("redis: %w", err)
+			}
+		}
+		err = c.recordRead(keys[i], c.decode(ctx, keys[i], data, dst))
+		if errors.Is(err, ErrKeyNotFound) {
+			// The value failed read validation or is stale and is treated as a miss
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return keys[i], nil
+	}
+	return "", ErrKeyNotFound
+}
+
+// getPipelined reads the keys with a GET per key in a single pipeline, through
+// the near cache if enabled for the keys. The results are in the same order as
+// the keys.
+func (c *Cache) getPipelined(ctx context.Context, redisKeys []string) []rueidis.RedisResult {
+	results := c.doGetPipelined(ctx, redisKeys)
+	for i, res := range results {
+		if msg, err := res.ToMessage(); err == nil {
+			c.observeSize(redisKeys[i], msg)
+		}
+	}
+	return results
+}
+
+func (c *Cache) doGetPipelined(ctx context.Context, redisKeys []string) []rueidis.RedisResult {
+	if c.nearCacheable(redisKeys...) {
+		cmds := make([]rueidis.CacheableTTL, 0, len(redisKeys))
+		for _, redisKey := range redisKeys {
+			cmds = append(cmds, rueidis.CacheableTTL{
+				Cmd: c.redis.B().Get().Key(redisKey).Cache(),
+				TTL: c.nearCacheTTL,
+			})
+		}
+		return c.redis.DoMultiCache(ctx, cmds...)
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys))
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, c.redis.B().Get().Key(redisKey).Build())
+	}
+	return c.redis.DoMulti(ctx, cmds...)
+}
@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_GetFirst(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	keys := []string{"config:user:1", "config:team:1", "config:global"}
+
+	var val string
+	_, err := rdb.GetFirst(context.Background(), keys, &val)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	assert.NoError(t, rdb.Set(context.Background(), "config:global", "global", 0))
+	hit, err := rdb.GetFirst(context.Background(), keys, &val)
+	assert.NoError(t, err)
+	assert.Equal(t, "config:global", hit)
+	assert.Equal(t, "global", val)
+
+	assert.NoError(t, rdb.Set(context.Background(), "config:team:1", "team", 0))
+	hit, err = rdb.GetFirst(context.Background(), keys, &val)
+	assert.NoError(t, err)
+	assert.Equal(t, "config:team:1", hit)
+	assert.Equal(t, "team", val)
+
+	migrating := New(client, WithMigrationMode(MsgpackEncoding(), JSONEncoding()))
+	hit, err = migrating.GetFirst(context.Background(), keys, &val)
+	assert.NoError(t, err)
+	assert.Equal(t, "config:team:1", hit)
+	assert.Equal(t, "team", val)
+}
@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// deleteStaleScript deletes a key only if its value hasn't changed since it was
+// read, so a fresh value written concurrently isn't deleted.
+var deleteStaleScript = rueidis.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// frame prepends a header to the encoded value if write timestamps, a
+// compression predicate, CompressOnlyWhenSmaller, a compression threshold, size
+// classes, or codec headers are enabled, or the provided header has optional
+// fields such as metadata set. Values starting with the header magic byte are
+// always framed, escaping them so reads don't mistake their first bytes for a
+// header. Bare values are never framed.
+func (c *Cache) frame(data []byte, compressed bool, h header) []byte {
+	if c.bare {
+		return data
+	}
+	if !c.writeTimestamps && c.compressWhen == nil && !c.compressSmaller &&
+		c.compressAbove == 0 && len(c.sizeClasses) == 0 && !c.codecHeader && h.flags == 0 &&
+		(len(data) == 0 || data[0] != headerMagic) {
+		return data
+	}
+	if c.writeTimestamps {
+		h.flags |= flagTimestamp
+		h.writtenAt = c.clock.Now()
+	}
+	if !compressed {
+		h.flags |= flagUncompressed
+	}
+	return h.appendTo(make([]byte, 0, h.len()+len(data)), data)
+}
+
+// unframe strips the header from a value retrieved from Redis, and returns the
+// function to decompress the payload with, which is nil if the payload isn't
+// compressed. If a minimum freshness is configured and the value
+// is stale an error wrapping ErrKeyNotFound is returned, and the value is deleted
+// if deleteStale is true. Tombstones written by negative caching are returned as
+// an error wrapping ErrKeyNotFound as well.
+func (c *Cache) unframe(ctx context.Context, key string, data []byte, deleteStale bool) ([]byte, CompressionHook, error) {
+	if c.bare {
+		return data, nil, nil
+	}
+	h, payload, _, err := c.parseValueHeader(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse header: %w", err)
+	}
+	if h.flags&flagTombstone != 0 {
+		return nil, nil, &tombstoneError{misses: h.misses, until: h.negUntil}
+	}
+	decompress, err := c.decompressor(h)
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.minFreshness == nil {
+		return payload, decompress, nil
+	}
+	cutoff := c.minFreshness(key)
+	if cutoff.IsZero() || !h.writtenAt.Before(cutoff) {
+		return payload, decompress, nil
+	}
+	if deleteStale {
+		c.deleteStaleEntry(ctx, key, data)
+	}
+	return nil, nil, fmt.Errorf("%w: written at %s before cutoff %s",
+		ErrKeyNotFound, h.writtenAt.Format(time.RFC3339Nano), cutoff.Format(time.RFC3339Nano))
+}
+
+// shouldCompress reports if values serialized with the named serialization
+// should be compressed.
+func (c *Cache) shouldCompress(serialization string) bool {
+	return c.compressWhen == nil || c.compressWhen(serialization)
+}
+
+// compress compresses a value serialized with the named serialization through
+// the hooks if it should be compressed, and reports if the returned value is
+// compressed. Values smaller than the threshold configured with CompressAbove
+// aren't compressed. With CompressOnlyWhenSmaller the original value is returned if
+// compressing it doesn't make it smaller. If the size class of the value selects
+// a Codec other than the configured Codec, or WithCodecHeader is enabled, the
+// Codec is recorded in h.
+func (c *Cache) compress(serialization string, data []byte, h *header) ([]byte, bool, error) {
+	if c.bare || !c.shouldCompress(serialization) || len(data) < c.compressAbove {
+		return data, false, nil
+	}
+	compress, codec := c.hooksMixin.current.compress, ""
+	if len(c.sizeClasses) > 0 {
+		if compress, codec = c.sizeClassCompressor(len(data)); compress == nil {
+			return data, false, nil
+		}
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("compress value: %w", err)
+	}
+	if c.compressSmaller && len(compressed) >= len(data) {
+		return data, false, nil
+	}
+	c.recordCodec(h, codec)
+	return compressed, true, nil
+}
+
+// recordCodec records the named Codec a value was compressed with in h. An empty
+// name is the configured Codec, which is only recorded if WithCodecHeader is
+// enabled.
+func (c *Cache) recordCodec(h *header, codec string) {
+	if codec == "" && c.codecHeader {
+		codec = codecName(c.codec)
+	}
+	if codec != "" {
+		h.flags |= flagCodec
+		h.codec = codec
+	}
+}
+
+// deleteStaleEntry deletes a stale value from Redis if it is unchanged. Deletion
+// is best-effort and errors are ignored as the value is treated as a miss either
+// way.
+func (c *Cache) deleteStaleEntry(ctx context.Context, key string, data []byte) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return
+	}
+	_ = deleteStaleScript.Exec(ctx, c.redis, []string{redisKey}, []string{string(data)}).Error()
+}
+
+// GetWithMaxAge retrieves an entry from the Cache for the given key and
+// unmarshalls the value into dst if the value was written at most maxAge ago.
+// Otherwise, including on a cache miss, fn is invoked synchronously to load the
+// value, which is stored with the provided TTL and unmarshalled into dst. This
+// decouples the age a caller is willing to accept from the TTL of the entry, so
+// each read can control its freshness precisely.
+//
+// The age of a value is determined by the time it was written, which is always
+// stored by GetWithMaxAge and by other writes when WithWriteTimestamps is
+// enabled. Values written without a timestamp, or read in migration mode, are
+// treated as exceeding maxAge. The age of a value can be read without reloading
+// it with GetFreshness. Errors returned by fn are returned as is and the
+// cached value is left untouched.
+func (c *Cache) GetWithMaxAge(
+	ctx context.Context,
+	key string,
+	dst any,
+	maxAge time.Duration,
+	ttl time.Duration,
+	fn func(ctx context.Context) (any, error)) error {
+
+	_, data, err := c.read(ctx, key, dst, callOptions{})
+	if err == nil && c.writtenWithin(data, maxAge) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	v, err := fn(ctx)
+	if err != nil {
+		return err
+	}
+	if c.migration != nil {
+		err = c.Set(ctx, key, v, ttl)
+	} else {
+		err = c.setWithHeader(ctx, key, v, ttl, header{flags: flagTimestamp, writtenAt: c.clock.Now()})
+	}
+	if err != nil {
+		return err
+	}
+	return c.populate(v, dst)
+}
+
+// writtenWithin reports if the value as stored in Redis has a timestamp that is
+// at most maxAge old.
+func (c *Cache) writtenWithin(data []byte, maxAge time.Duration) bool {
+	result := c.freshness(data)
+	return result.AgeKnown && result.Age <= maxAge
+}
+
+// setWithHeader adds an entry into the cache like Set, and stores the optional
+// fields of the provided header in the header of the value.
+func (c *Cache) setWithHeader(ctx context.Context, key string, v any, ttl time.Duration, h header) error {
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	data, err := c.encodeWithHeader(ctx, key, v, h)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		return newDryRunReport("Set", []DryRunEntry{{Key: key, RedisKey: redisKey, Size: len(data)}})
+	}
+	if c.writeBatch != nil && c.writeBatch.enqueue(bufferedWrite{redisKey: redisKey, data: data, ttl: ttl}) {
+		return nil
+	}
+
+	cmd := c.redis.B().Set().Key(redisKey).Value(string(data))
+	if ttl > 0 {
+		cmd.Ex(ttl)
+	}
+	err = c.redis.Do(ctx, cmd.Build()).Error()
+	if err != nil {
+		err = fmt.Errorf("redis: %w", err)
+	}
+	return err
+}
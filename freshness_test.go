@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_MinFreshness(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var cutoff time.Time
+	rdb := New(client, WithMinFreshness(func(key string) time.Time {
+		if key == "stale" {
+			return cutoff
+		}
+		return time.Time{}
+	}))
+
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "stale", "value", 0))
+	assert.NoError(t, rdb.Set(ctx, "fresh", "value", 0))
+
+	var s string
+	assert.NoError(t, rdb.Get(ctx, "stale", &s))
+	assert.Equal(t, "value", s)
+
+	cutoff = time.Now().Add(time.Second)
+	assert.ErrorIs(t, rdb.Get(ctx, "stale", &s), ErrKeyNotFound)
+	assert.NoError(t, rdb.Get(ctx, "fresh", &s))
+
+	res, err := MGet[string](ctx, rdb, "stale", "fresh")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"fresh": "value"}, map[string]string(res))
+
+	// Without DeleteStaleEntries the value is left in Redis
+	assert.True(t, server.Exists("stale"))
+}
+
+func TestCache_MinFreshness_DeleteStale(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client,
+		WithMinFreshness(func(key string) time.Time {
+			return time.Now().Add(time.Second)
+		}),
+		DeleteStaleEntries())
+
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "key", "value", 0))
+
+	var s string
+	assert.ErrorIs(t, rdb.Get(ctx, "key", &s), ErrKeyNotFound)
+	assert.False(t, server.Exists("key"))
+}
+
+func TestCache_MinFreshness_Legacy(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	legacy := New(client)
+	assert.NoError(t, legacy.Set(ctx, "key", "value", 0))
+	assert.NoError(t, legacy.Set(ctx, "other", "value", 0))
+
+	rdb := New(client, WithMinFreshness(func(key string) time.Time {
+		if key == "key" {
+			return time.Now()
+		}
+		return time.Time{}
+	}))
+
+	// Values without a timestamp are stale for any non-zero cutoff
+	var s string
+	assert.ErrorIs(t, rdb.Get(ctx, "key", &s), ErrKeyNotFound)
+	assert.NoError(t, rdb.Get(ctx, "other", &s))
+	assert.Equal(t, "value", s)
+}
+
+func TestUpsert_Stale(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMinFreshness(func(key string) time.Time {
+		return time.Now().Add(time.Second)
+	}), DeleteStaleEntries())
+
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "key", "old", 0))
+
+	err := Upsert[string](ctx, rdb, "key", "new", func(found bool, oldValue string, newValue string) string {
+		assert.False(t, found)
+		assert.Equal(t, "", oldValue)
+		return newValue
+	}, 0)
+	assert.NoError(t, err)
+}
+
+func TestCache_GetWithMaxAge(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	var calls int
+	fn := func(ctx context.Context) (any, error) {
+		calls++
+		return fmt.Sprintf("loaded-%d", calls), nil
+	}
+
+	var val string
+	assert.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Minute, time.Hour, fn))
+	assert.Equal(t, "loaded-1", val)
+	assert.Equal(t, time.Hour, server.TTL("key"))
+
+	// Values within the max age are served from the cache
+	assert.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Minute, time.Hour, fn))
+	assert.Equal(t, "loaded-1", val)
+	assert.Equal(t, 1, calls)
+
+	// Values older than the max age are reloaded regardless of the TTL
+	time.Sleep(5 * time.Millisecond)
+	assert.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Millisecond, time.Hour, fn))
+	assert.Equal(t, "loaded-2", val)
+
+	// Values without a timestamp are reloaded
+	assert.NoError(t, rdb.Set(context.Background(), "key", "untimed", time.Hour))
+	assert.NoError(t, rdb.GetWithMaxAge(context.Background(), "key", &val, time.Hour, time.Hour, fn))
+	assert.Equal(t, "loaded-3", val)
+
+	// A failed load leaves the cached value untouched
+	time.Sleep(5 * time.Millisecond)
+	err := rdb.GetWithMaxAge(context.Background(), "key", &val, time.Millisecond, time.Hour,
+		func(ctx context.Context) (any, error) {
+			return nil, assert.AnError
+		})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, "loaded-3", val)
+}
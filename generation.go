@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const (
+	// DefaultGenerationKey is the Redis key used to store the generation counter
+	// when generation busting is enabled.
+	DefaultGenerationKey = "rueidis-cache:generation"
+
+	// DefaultGenerationRefresh is the maximum duration the current generation is
+	// cached locally before it is read from Redis again.
+	DefaultGenerationRefresh = time.Second
+)
+
+// The generation is stored as a hash with two fields: active is the generation
+// used for reads and writes, and seq is the last generation allocated. Keeping
+// both in a single key allows them to be updated atomically on Redis Cluster.
+var (
+	// allocateGenerationScript allocates a new generation greater than any
+	// active or previously allocated generation without activating it.
+	allocateGenerationScript = rueidis.NewLuaScript(`
+local active = tonumber(redis.call('HGET', KEYS[1], 'active') or '0')
+local seq = redis.call('HINCRBY', KEYS[1], 'seq', 1)
+if seq <= active then
+	seq = active + 1
+	redis.call('HSET', KEYS[1], 'seq', seq)
+end
+return seq`)
+
+	// activateGenerationScript activates the provided generation unless a newer
+	// generation is already active, and returns the active generation.
+	activateGenerationScript = rueidis.NewLuaScript(`
+local active = tonumber(redis.call('HGET', KEYS[1], 'active') or '0')
+local gen = tonumber(ARGV[1])
+if gen > active then
+	redis.call('HSET', KEYS[1], 'active', gen)
+	return gen
+end
+return active`)
+)
+
+// generation tracks the current generation of the Cache. The generation is stored
+// in Redis so all instances of an application share it, but is cached locally to
+// avoid an additional round trip per operation.
+type generation struct {
+	key     string
+	refresh time.Duration
+	clock   Clock
+
+	mu      sync.Mutex
+	value   int64
+	fetched time.Time
+}
+
+func newGeneration() *generation {
+	return &generation{
+		key:     DefaultGenerationKey,
+		refresh: DefaultGenerationRefresh,
+		clock:   systemClock{},
+	}
+}
+
+// current returns the current generation, reading it from Redis if the locally
+// cached value is older than the refresh interval.
+func (g *generation) current(ctx context.Context, client rueidis.Client) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.fetched.IsZero() && g.clock.Now().Sub(g.fetched) < g.refresh {
+		return g.value, nil
+	}
+
+	val, err := client.Do(ctx, client.B().Hget().Key(g.key).Field("active").Build()).AsInt64()
+	if err != nil && !errors.Is(err, rueidis.Nil) {
+		return 0, fmt.Errorf("redis: %w", err)
+	}
+
+	// If the generation key doesn't exist the Cache has never been busted, and
+	// we are on the initial generation.
+	g.value = val
+	g.fetched = g.clock.Now()
+	return g.value, nil
+}
+
+// bump allocates and activates a new generation.
+func (g *generation) bump(ctx context.Context, client rueidis.Client) error {
+	gen, err := g.allocate(ctx, client)
+	if err != nil {
+		return err
+	}
+	return g.activate(ctx, client, gen)
+}
+
+// allocate returns a new generation without activating it, allowing entries to
+// be written under the generation before they become visible.
+func (g *generation) allocate(ctx context.Context, client rueidis.Client) (int64, error) {
+	gen, err := allocateGenerationScript.Exec(ctx, client, []string{g.key}, nil).AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("redis: %w", err)
+	}
+	return gen, nil
+}
+
+// activate makes the provided generation the active generation and updates the
+// locally cached value.
+func (g *generation) activate(ctx context.Context, client rueidis.Client, gen int64) error {
+	active, err := activateGenerationScript.Exec(ctx, client, []string{g.key},
+		[]string{strconv.FormatInt(gen, 10)}).AsInt64()
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+
+	g.mu.Lock()
+	g.value = active
+	g.fetched = g.clock.Now()
+	g.mu.Unlock()
+	return nil
+}
+
+// prefix returns the key prefix for a given generation.
+func (g *generation) prefix(gen int64) string {
+	return "g" + strconv.FormatInt(gen, 10) + ":"
+}
+
+// BumpGeneration increments the generation of the Cache, instantly invalidating
+// all existing entries. Entries from previous generations are no longer visible
+// through the Cache and are removed by Redis once their TTL expires.
+//
+// Other instances sharing the same Redis observe the new generation within
+// DefaultGenerationRefresh. BumpGeneration returns an error if generation busting
+// was not enabled using the WithGenerationBusting Option.
+func (c *Cache) BumpGeneration(ctx context.Context) error {
+	if c.dryRun {
+		return dryRunUnsupported("BumpGeneration")
+	}
+	if c.generation == nil {
+		return fmt.Errorf("generation busting is not enabled")
+	}
+	return c.generation.bump(ctx, c.redis)
+}
+
+// SwapSnapshot atomically replaces the entire contents of the Cache with the
+// provided items. The items are written under a new generation that isn't visible
+// to readers until all the items have been written, at which point the generation
+// is activated. Readers either observe the previous snapshot or the new snapshot,
+// never a partially applied one.
+//
+// Entries of the previous snapshot are no longer visible once the new snapshot
+// is activated, and are removed by Redis once their TTL expires. For that reason
+// a ttl greater than zero is required. If writing any of the items fails the new
+// snapshot is not activated.
+//
+// SwapSnapshot requires generation busting to be enabled using the
+// WithGenerationBusting Option.
+func (c *Cache) SwapSnapshot(ctx context.Context, items map[string]any, ttl time.Duration) error {
+	if c.dryRun {
+		return dryRunUnsupported("SwapSnapshot")
+	}
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	if c.generation == nil {
+		return fmt.Errorf("generation busting is not enabled")
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("a ttl greater than zero is required")
+	}
+
+	// The values are serialized before allocating the generation so the
+	// pipeline is dispatched without interruption.
+	values := make(map[string][]byte, len(items))
+	for key, v := range items {
+		data, err := c.encode(ctx, key, v)
+		if err != nil {
+			return fmt.Errorf("key %s: %w", key, err)
+		}
+		values[key] = data
+	}
+
+	gen, err := c.generation.allocate(ctx, c.redis)
+	if err != nil {
+		return err
+	}
+
+	cmds := make(rueidis.Commands, 0, len(values))
+	for key, data := range values {
+		redisKey, err := c.generationKey(key, gen)
+		if err != nil {
+			return err
+		}
+		cmds = append(cmds, c.redis.B().Set().Key(redisKey).Value(string(data)).Ex(ttl).Build())
+	}
+	var errs []error
+	for i, res := range c.redis.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			errs = append(errs, fmt.Errorf("redis: %s: %w", cmds[i].Commands()[1], err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return c.generation.activate(ctx, c.redis, gen)
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_BumpGeneration(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithGenerationBusting())
+
+	err := rdb.Set(context.Background(), "user:1", "alice", time.Minute)
+	assert.NoError(t, err)
+
+	// The key stored in Redis is prefixed with the generation.
+	exists, err := client.Do(context.Background(), client.B().Exists().Key("g0:user:1").Build()).AsInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), exists)
+
+	var s string
+	assert.NoError(t, rdb.Get(context.Background(), "user:1", &s))
+	assert.Equal(t, "alice", s)
+
+	keys, err := rdb.ScanKeys(context.Background(), "user:*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:1"}, keys)
+
+	assert.NoError(t, rdb.BumpGeneration(context.Background()))
+
+	assert.ErrorIs(t, rdb.Get(context.Background(), "user:1", &s), ErrKeyNotFound)
+	keys, err = rdb.Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	err = rdb.Set(context.Background(), "user:1", "bob", time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, rdb.Get(context.Background(), "user:1", &s))
+	assert.Equal(t, "bob", s)
+
+	results, err := MGet[string](context.Background(), rdb, "user:1", "user:2")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"user:1": "bob"}, results)
+
+	// Other instances observe the new generation once the refresh interval elapses.
+	other := New(client, WithGenerationBusting())
+	assert.NoError(t, other.Get(context.Background(), "user:1", &s))
+	assert.Equal(t, "bob", s)
+}
+
+func TestCache_BumpGenerationDisabled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.Error(t, rdb.BumpGeneration(context.Background()))
+}
+
+func TestCache_SwapSnapshot(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithGenerationBusting())
+
+	assert.Error(t, rdb.SwapSnapshot(context.Background(), map[string]any{"flag:a": true}, 0))
+
+	err := rdb.SwapSnapshot(context.Background(), map[string]any{
+		"flag:a": true,
+		"flag:b": false,
+	}, time.Minute)
+	assert.NoError(t, err)
+
+	var enabled bool
+	assert.NoError(t, rdb.Get(context.Background(), "flag:a", &enabled))
+	assert.True(t, enabled)
+
+	err = rdb.SwapSnapshot(context.Background(), map[string]any{
+		"flag:b": true,
+		"flag:c": true,
+	}, time.Minute)
+	assert.NoError(t, err)
+
+	results, err := MGet[bool](context.Background(), rdb, "flag:a", "flag:b", "flag:c")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[bool]{"flag:b": true, "flag:c": true}, results)
+
+	// A bump after a swap must move to a generation that was never used
+	assert.NoError(t, rdb.BumpGeneration(context.Background()))
+	keys, err := rdb.Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestCache_SwapSnapshot_KeyTransforms(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithGenerationBusting(), WithKeyTransforms(PrefixKeys("prod:")))
+
+	err := rdb.SwapSnapshot(context.Background(), map[string]any{"a": "alpha"}, time.Minute)
+	assert.NoError(t, err)
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "a", &val))
+	assert.Equal(t, "alpha", val)
+
+	keys, err := rdb.Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+
+	rdb = New(client, WithGenerationBusting(), WithMaxKeyLength(8))
+	err = rdb.SwapSnapshot(context.Background(), map[string]any{"too-long-key": "alpha"}, time.Minute)
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetOrSet retrieves an entry from the Cache for the given key and unmarshalls
+// it into dst. On a miss the loader is invoked, the value it returns is stored
+// with Set using the provided ttl, and the value is unmarshalled into dst.
+//
+// Concurrent misses for the same key within the Cache are deduplicated, so the
+// loader is invoked once while the other callers wait for its result. The loader
+// is invoked with the context of the caller that invoked it, so if that context
+// is cancelled the waiting callers observe the error as well. Deduplication is
+// scoped to the Cache, so different Cache instances never share loads.
+//
+// Errors returned by the loader are returned unchanged and nothing is cached,
+// unless negative caching is enabled with WithNegativeCaching and the error wraps
+// ErrKeyNotFound, in which case a tombstone is cached. While the tombstone is
+// valid GetOrSet returns an error wrapping ErrKeyNotFound without invoking the
+// loader. Errors reading from the Cache other than a miss are returned without
+// invoking the loader. If storing the loaded value fails dst is still populated and the
+// error is returned.
+func (c *Cache) GetOrSet(ctx context.Context, key string, dst any, ttl time.Duration, loader func(ctx context.Context) (any, error)) error {
+	if loader == nil {
+		panic(fmt.Errorf("nil loader not permitted, illegal use of api"))
+	}
+
+	ctx, end := c.hooksMixin.startCacheAside(ctx, "GetOrSet")
+	var stats CacheAsideResult
+	stats.Err = c.getOrSet(ctx, key, dst, ttl, loader, &stats)
+	end(stats)
+	return stats.Err
+}
+
+func (c *Cache) getOrSet(
+	ctx context.Context,
+	key string,
+	dst any,
+	ttl time.Duration,
+	loader func(ctx context.Context) (any, error),
+	stats *CacheAsideResult) error {
+
+	readCtx, endRead := c.hooksMixin.startCacheAsidePhase(ctx, PhaseRead)
+	err := c.Get(readCtx, key, dst)
+	endRead(err)
+	if err == nil {
+		stats.Hits = 1
+		return nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+	var (
+		misses    uint64
+		tombstone *tombstoneError
+	)
+	if errors.As(err, &tombstone) {
+		if c.clock.Now().Before(tombstone.until) {
+			stats.Hits = 1
+			return err
+		}
+		misses = tombstone.misses
+	}
+	stats.Misses = 1
+
+	type loaded struct {
+		data   []byte // the loaded value marshalled
+		setErr error
+	}
+	res, err, _ := c.loadFlights.Do(key, func() (any, error) {
+		start := time.Now()
+		loadCtx, endLoad := c.hooksMixin.startCacheAsidePhase(ctx, PhaseLoad)
+		v, err := loader(loadCtx)
+		endLoad(err)
+		stats.LoadDuration = time.Since(start)
+		if err != nil {
+			if c.negativeCacheable() && errors.Is(err, ErrKeyNotFound) {
+				// Failing to write the tombstone only means the next miss invokes
+				// the loader again, so the loader error is returned as is unless
+				// the write was only reported by dry-run mode
+				terr := c.setTombstone(context.WithoutCancel(ctx), key, misses+1)
+				if errors.Is(terr, ErrDryRun) {
+					return nil, fmt.Errorf("%w: store tombstone: %w", err, terr)
+				}
+			}
+			return nil, err
+		}
+		data, err := c.hooksMixin.current.marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		writeCtx, endWrite := c.hooksMixin.startCacheAsidePhase(ctx, PhaseWrite)
+		err = c.Set(writeCtx, key, v, ttl)
+		endWrite(err)
+		return loaded{data: data, setErr: err}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Every caller unmarshalls its own copy so callers don't share the value
+	l := res.(loaded)
+	if err := c.hooksMixin.current.unmarshall(l.data, dst); err != nil {
+		return err
+	}
+	if l.setErr != nil {
+		return fmt.Errorf("store loaded value: %w", l.setErr)
+	}
+	return nil
+}
+
+// negativeCacheable reports if tombstones are written for keys the loader of
+// GetOrSet reports don't exist.
+func (c *Cache) negativeCacheable() bool {
+	return c.negative != nil && !c.bare && c.migration == nil
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetOrSet(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+
+	var calls atomic.Int32
+	loader := func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		return map[string]int{"count": 1}, nil
+	}
+
+	var v map[string]int
+	require.NoError(t, rdb.GetOrSet(ctx, "key", &v, time.Minute, loader))
+	assert.Equal(t, map[string]int{"count": 1}, v)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.True(t, server.Exists("key"))
+	assert.Equal(t, time.Minute, server.TTL("key"))
+
+	// The stored value is served without invoking the loader
+	v = nil
+	require.NoError(t, rdb.GetOrSet(ctx, "key", &v, time.Minute, loader))
+	assert.Equal(t, map[string]int{"count": 1}, v)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestCache_GetOrSet_LoaderError(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+
+	boom := errors.New("boom")
+	var v string
+	err := rdb.GetOrSet(ctx, "key", &v, time.Minute, func(ctx context.Context) (any, error) {
+		return nil, boom
+	})
+	assert.Equal(t, boom, err)
+	assert.False(t, server.Exists("key"))
+
+	// Errors aren't cached so the next call invokes the loader again
+	require.NoError(t, rdb.GetOrSet(ctx, "key", &v, time.Minute, func(ctx context.Context) (any, error) {
+		return "value", nil
+	}))
+	assert.Equal(t, "value", v)
+}
+
+func TestCache_GetOrSet_Deduplicated(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+	other := New(client)
+
+	var (
+		calls   atomic.Int32
+		release = make(chan struct{})
+	)
+	loader := func(ctx context.Context) (any, error) {
+		calls.Add(1)
+		<-release
+		return "value", nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = rdb.GetOrSet(ctx, "key", &results[i], time.Minute, loader)
+		}(i)
+	}
+
+	// Loads of another Cache aren't shared with the in-flight load
+	var otherResult string
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		assert.NoError(t, other.GetOrSet(ctx, "key", &otherResult, time.Minute, func(ctx context.Context) (any, error) {
+			calls.Add(1)
+			return "other", nil
+		}))
+		close(release)
+	}()
+	wg.Wait()
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, "other", otherResult)
+	for i := 0; i < callers; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "value", results[i])
+	}
+}
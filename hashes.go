@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/rueidis"
+)
+
+// HSet sets the fields of the hash stored at key, creating the hash if it doesn't
+// exist. Each field value is marshalled and compressed like values stored with
+// Set.
+func (c *Cache) HSet(ctx context.Context, key string, fields map[string]any) error {
+	if c.dryRun {
+		return dryRunUnsupported("HSet")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	cmd := c.redis.B().Hset().Key(redisKey).FieldValue()
+	for field, v := range fields {
+		data, err := c.encode(ctx, key, v)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field, err)
+		}
+		cmd = cmd.FieldValue(field, string(data))
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return err
+	}
+
+	if err := c.redis.Do(ctx, cmd.Build()).Error(); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	return nil
+}
+
+// HGetAllMany retrieves all the fields of the hashes stored at the given keys
+// using a pipeline of HGETALL commands, and decodes each field value into an any.
+// The results are keyed by key and then by field. Hashes that don't exist are
+// not included in the results.
+//
+// Each HGETALL only accesses a single key, so the keys don't need to hash to the
+// same slot when using Redis Cluster, and rueidis routes each command to the node
+// owning its slot. If batching is enabled with BatchMultiGets the commands are
+// split into pipelines of at most the batch size.
+//
+// Errors are aggregated per key rather than failing fast. If reading or decoding
+// a hash fails the key is excluded from the results and the error is joined into
+// the returned error, so the results for the remaining keys are still returned.
+func (c *Cache) HGetAllMany(ctx context.Context, keys []string) (map[string]map[string]any, error) {
+	if len(keys) == 0 {
+		return map[string]map[string]any{}, nil
+	}
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := c.mgetBatch
+	if batchSize <= 0 {
+		batchSize = len(keys)
+	}
+
+	results := make(map[string]map[string]any, len(keys))
+	var errs []error
+	for offset, redisChunk := range chunk(redisKeys, batchSize) {
+		cmds := make(rueidis.Commands, 0, len(redisChunk))
+		for _, redisKey := range redisChunk {
+			cmds = append(cmds, c.redis.B().Hgetall().Key(redisKey).Build())
+		}
+		for i, res := range c.redis.DoMulti(ctx, cmds...) {
+			key := keys[offset*batchSize+i]
+			hash, err := c.decodeHash(ctx, key, res)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("key %s: %w", key, err))
+				continue
+			}
+			if hash == nil {
+				c.hooksMixin.miss(key)
+				continue
+			}
+			c.hooksMixin.hit(key)
+			results[key] = hash
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// decodeHash decodes the fields of an HGETALL response. Returns nil if the hash
+// doesn't exist.
+func (c *Cache) decodeHash(ctx context.Context, key string, res rueidis.RedisResult) (map[string]any, error) {
+	fields, err := res.AsStrMap()
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	hash := make(map[string]any, len(fields))
+	for field, data := range fields {
+		var val any
+		if err := c.decode(ctx, key, []byte(data), &val); err != nil {
+			return nil, fmt.Errorf("field %s: %w", field, err)
+		}
+		hash[field] = val
+	}
+	return hash, nil
+}
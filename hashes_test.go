@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_HGetAllMany(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	for _, rdb := range []*Cache{New(client, JSON(), LZ4()), New(client, JSON(), BatchMultiGets(2))} {
+		assert.NoError(t, rdb.HSet(context.Background(), "user:1", map[string]any{"name": "Alice", "team": "a"}))
+		assert.NoError(t, rdb.HSet(context.Background(), "user:2", map[string]any{"name": "Bob"}))
+		assert.NoError(t, client.Do(context.Background(), client.B().Hset().Key("user:4").
+			FieldValue().FieldValue("name", "garbage").Build()).Error())
+
+		res, err := rdb.HGetAllMany(context.Background(), []string{"user:1", "user:2", "user:3", "user:4"})
+		assert.Error(t, err)
+		assert.ErrorContains(t, err, "user:4")
+		assert.Equal(t, map[string]map[string]any{
+			"user:1": {"name": "Alice", "team": "a"},
+			"user:2": {"name": "Bob"},
+		}, res)
+	}
+}
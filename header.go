@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	// headerMagic is the first byte of values stored with a header. 0xC1 is never
+	// used by msgpack and is not valid UTF-8, so it doesn't collide with values
+	// serialized using msgpack or JSON without a header. Values serialized by
+	// other Marshallers that start with the magic byte are always stored with a
+	// header, so they are never mistaken for a header.
+	headerMagic byte = 0xC1
+
+	// headerVersion is the version of the header format.
+	headerVersion byte = 1
+
+	// headerMinLen is the length of a header without optional fields: the magic
+	// byte, version, and flags.
+	headerMinLen = 3
+)
+
+const (
+	// flagTimestamp signals the header contains the time the value was written as
+	// an 8 byte big-endian count of nanoseconds since the Unix epoch.
+	flagTimestamp byte = 1 << iota
+
+	// flagUncompressed signals compression was skipped for the value. Values with
+	// a header that don't set the flag are compressed with the configured Codec.
+	flagUncompressed
+
+	// flagMeta signals the header contains metadata as a uvarint count of fields
+	// followed by each name and value prefixed with its uvarint length. Fields are
+	// sorted by name so the same metadata always encodes to the same bytes.
+	flagMeta
+
+	// flagCodec signals the header contains the name of the Codec the value was
+	// compressed with prefixed with its uvarint length. Values with a header that
+	// don't set the flag are compressed with the configured Codec.
+	flagCodec
+
+	// flagExpiry signals the header contains the time it took to compute the value
+	// and the time the value expires, each as an 8 byte big-endian count of
+	// nanoseconds, the latter since the Unix epoch.
+	flagExpiry
+
+	// flagTombstone signals the value is a tombstone caching that the key doesn't
+	// exist. The header contains the number of consecutive misses as a uvarint
+	// followed by the time the tombstone is valid until as an 8 byte big-endian
+	// count of nanoseconds since the Unix epoch. Tombstones have no payload.
+	flagTombstone
+)
+
+var (
+	// errInvalidHeader signals a value starts with the header magic byte but the
+	// header is malformed.
+	errInvalidHeader = errors.New("invalid header")
+
+	// errBareHeader signals a value requires a header but the Cache stores bare
+	// values configured with BareMsgpack or BareJSON.
+	errBareHeader = errors.New("value header not supported in bare mode")
+)
+
+// header is a small self-describing header prepended to values stored in Redis
+// when a feature requiring it is enabled. The header is applied after compression
+// so it can be read without decompressing or unmarshalling the value.
+type header struct {
+	flags     byte
+	writtenAt time.Time
+	meta      map[string]string
+	codec     string
+	delta     time.Duration
+	expiresAt time.Time
+	misses    uint64
+	negUntil  time.Time
+}
+
+// appendTo appends the encoded header followed by the payload to dst.
+func (h header) appendTo(dst []byte, payload []byte) []byte {
+	dst = append(dst, headerMagic, headerVersion, h.flags)
+	if h.flags&flagTimestamp != 0 {
+		dst = binary.BigEndian.AppendUint64(dst, uint64(h.writtenAt.UnixNano()))
+	}
+	if h.flags&flagMeta != 0 {
+		names := make([]string, 0, len(h.meta))
+		for name := range h.meta {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		dst = binary.AppendUvarint(dst, uint64(len(names)))
+		for _, name := range names {
+			dst = appendString(dst, name)
+			dst = appendString(dst, h.meta[name])
+		}
+	}
+	if h.flags&flagCodec != 0 {
+		dst = appendString(dst, h.codec)
+	}
+	if h.flags&flagExpiry != 0 {
+		dst = binary.BigEndian.AppendUint64(dst, uint64(h.delta))
+		dst = binary.BigEndian.AppendUint64(dst, uint64(h.expiresAt.UnixNano()))
+	}
+	if h.flags&flagTombstone != 0 {
+		dst = binary.AppendUvarint(dst, h.misses)
+		dst = binary.BigEndian.AppendUint64(dst, uint64(h.negUntil.UnixNano()))
+	}
+	return append(dst, payload...)
+}
+
+// len returns the length of the encoded header.
+func (h header) len() int {
+	n := headerMinLen
+	if h.flags&flagTimestamp != 0 {
+		n += 8
+	}
+	if h.flags&flagMeta != 0 {
+		n += uvarintLen(uint64(len(h.meta)))
+		for name, val := range h.meta {
+			n += uvarintLen(uint64(len(name))) + len(name)
+			n += uvarintLen(uint64(len(val))) + len(val)
+		}
+	}
+	if h.flags&flagCodec != 0 {
+		n += uvarintLen(uint64(len(h.codec))) + len(h.codec)
+	}
+	if h.flags&flagExpiry != 0 {
+		n += 16
+	}
+	if h.flags&flagTombstone != 0 {
+		n += uvarintLen(h.misses) + 8
+	}
+	return n
+}
+
+// parseHeader parses the header of the provided value returning the header and
+// the remaining payload. Values that don't start with a header, such as values
+// written before a feature requiring the header was enabled, are returned as is
+// with ok set to false.
+func parseHeader(data []byte) (h header, payload []byte, ok bool, err error) {
+	if len(data) < headerMinLen || data[0] != headerMagic || data[1] != headerVersion {
+		return header{}, data, false, nil
+	}
+	h.flags = data[2]
+	payload = data[headerMinLen:]
+	if h.flags&flagTimestamp != 0 {
+		if len(payload) < 8 {
+			return header{}, nil, false, errInvalidHeader
+		}
+		h.writtenAt = time.Unix(0, int64(binary.BigEndian.Uint64(payload)))
+		payload = payload[8:]
+	}
+	if h.flags&flagMeta != 0 {
+		count, n := binary.Uvarint(payload)
+		if n <= 0 || count > uint64(len(payload)) {
+			return header{}, nil, false, errInvalidHeader
+		}
+		payload = payload[n:]
+		h.meta = make(map[string]string, count)
+		for i := uint64(0); i < count; i++ {
+			var name, val string
+			if name, payload, ok = readString(payload); !ok {
+				return header{}, nil, false, errInvalidHeader
+			}
+			if val, payload, ok = readString(payload); !ok {
+				return header{}, nil, false, errInvalidHeader
+			}
+			h.meta[name] = val
+		}
+	}
+	if h.flags&flagCodec != 0 {
+		if h.codec, payload, ok = readString(payload); !ok {
+			return header{}, nil, false, errInvalidHeader
+		}
+	}
+	if h.flags&flagExpiry != 0 {
+		if len(payload) < 16 {
+			return header{}, nil, false, errInvalidHeader
+		}
+		h.delta = time.Duration(binary.BigEndian.Uint64(payload))
+		h.expiresAt = time.Unix(0, int64(binary.BigEndian.Uint64(payload[8:])))
+		payload = payload[16:]
+	}
+	if h.flags&flagTombstone != 0 {
+		misses, n := binary.Uvarint(payload)
+		if n <= 0 || len(payload)-n < 8 {
+			return header{}, nil, false, errInvalidHeader
+		}
+		h.misses = misses
+		h.negUntil = time.Unix(0, int64(binary.BigEndian.Uint64(payload[n:])))
+		payload = payload[n+8:]
+	}
+	return h, payload, true, nil
+}
+
+// appendString appends s to dst prefixed with its uvarint length.
+func appendString(dst []byte, s string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// readString reads a string prefixed with its uvarint length from data returning
+// the string and the remaining data.
+func readString(data []byte) (string, []byte, bool) {
+	l, n := binary.Uvarint(data)
+	if n <= 0 || l > uint64(len(data)-n) {
+		return "", nil, false
+	}
+	data = data[n:]
+	return string(data[:l]), data[l:], true
+}
+
+// uvarintLen returns the number of bytes needed to encode x as a uvarint.
+func uvarintLen(x uint64) int {
+	n := 1
+	for x >= 0x80 {
+		x >>= 7
+		n++
+	}
+	return n
+}
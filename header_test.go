@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestHeader(t *testing.T) {
+	now := time.Unix(0, time.Now().UnixNano())
+
+	h := header{flags: flagTimestamp, writtenAt: now}
+	data := h.appendTo(nil, []byte("payload"))
+	assert.Equal(t, h.len()+len("payload"), len(data))
+
+	parsed, payload, ok, err := parseHeader(data)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("payload"), payload)
+	assert.True(t, now.Equal(parsed.writtenAt))
+
+	// Values without a header are returned as is
+	legacy, _ := msgpack.Marshal("value")
+	_, payload, ok, err = parseHeader(legacy)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, legacy, payload)
+
+	_, _, _, err = parseHeader([]byte{headerMagic, headerVersion, flagTimestamp, 0x01})
+	assert.ErrorIs(t, err, errInvalidHeader)
+
+	h = header{flags: flagTimestamp | flagMeta, writtenAt: now, meta: map[string]string{"source": "db", "a": ""}}
+	data = h.appendTo(nil, []byte("payload"))
+	assert.Equal(t, h.len()+len("payload"), len(data))
+
+	parsed, payload, ok, err = parseHeader(data)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("payload"), payload)
+	assert.Equal(t, h.meta, parsed.meta)
+
+	_, _, _, err = parseHeader([]byte{headerMagic, headerVersion, flagMeta, 0x01, 0x05, 'a'})
+	assert.ErrorIs(t, err, errInvalidHeader)
+
+	h = header{flags: flagCodec, codec: "brotli"}
+	data = h.appendTo(nil, []byte("payload"))
+	assert.Equal(t, h.len()+len("payload"), len(data))
+
+	parsed, payload, _, err = parseHeader(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+	assert.Equal(t, "brotli", parsed.codec)
+
+	h = header{flags: flagCodec | flagExpiry, codec: "lz4", delta: time.Second, expiresAt: now}
+	data = h.appendTo(nil, []byte("payload"))
+	assert.Equal(t, h.len()+len("payload"), len(data))
+
+	parsed, payload, _, err = parseHeader(data)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("payload"), payload)
+	assert.Equal(t, time.Second, parsed.delta)
+	assert.True(t, now.Equal(parsed.expiresAt))
+
+	_, _, _, err = parseHeader([]byte{headerMagic, headerVersion, flagExpiry, 0x01})
+	assert.ErrorIs(t, err, errInvalidHeader)
+}
+
+func TestCache_HeaderMagicEscaped(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	raw := Serialization(func(v any) ([]byte, error) {
+		return v.([]byte), nil
+	}, func(b []byte, v any) error {
+		*v.(*[]byte) = append([]byte(nil), b...)
+		return nil
+	})
+	rdb := New(client, raw)
+
+	// Values that look like they start with a header are stored with a header
+	value := []byte{headerMagic, headerVersion, 0x00, 'h', 'i'}
+	require.NoError(t, rdb.Set(ctx, "key", value, 0))
+	stored, err := server.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, headerMinLen+len(value), len(stored))
+
+	var got []byte
+	require.NoError(t, rdb.Get(ctx, "key", &got))
+	assert.Equal(t, value, got)
+
+	// Other values are stored as is
+	require.NoError(t, rdb.Set(ctx, "plain", []byte("hi"), 0))
+	stored, err = server.Get("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "hi", stored)
+}
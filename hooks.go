@@ -1,5 +1,9 @@
 package cache
 
+import (
+	"time"
+)
+
 // CompressionHook is a function type that is invoked prior to compressing or
 // decompressing data.
 type CompressionHook func(data []byte) ([]byte, error)
@@ -19,18 +23,171 @@ type Hook interface {
 	DecompressHook(next CompressionHook) CompressionHook
 }
 
+// AccessHook is an optional interface a Hook can implement to be notified when
+// a read operation results in a cache hit or a cache miss.
+//
+// Implementations are invoked synchronously on the read path and should be
+// cheap and non-blocking.
+type AccessHook interface {
+	Hit(key string)
+	Miss(key string)
+}
+
+// NearCacheHook is an optional interface a Hook can implement to be notified when
+// a read bypasses the near cache because the value of the key exceeds the size
+// configured with NearCacheMaxValueSize. The key is provided as stored in Redis,
+// including any generation prefix or key compaction.
+//
+// Implementations are invoked synchronously on the read path and should be
+// cheap and non-blocking.
+type NearCacheHook interface {
+	NearCacheSkipped(redisKey string)
+}
+
+// CodecErrorHook is an optional interface a Hook can implement to be notified when
+// serializing or compressing a value fails, along with the name of the
+// serialization or Codec that failed. The operation is one of marshal, unmarshal,
+// compress, or decompress.
+//
+// Unlike the errors observed by wrapping the Marshaller, Unmarshaller, and
+// CompressionHook, CodecErrorHook is also notified of failures of serializations
+// and Codecs that bypass the Hook chain: the target Encoding of
+// WithMigrationMode, Codecs recorded in the header of values compressed with a
+// different Codec than configured, and serializations detected by
+// WithFormatSniffing. This allows pinpointing which serialization or Codec is
+// producing corrupt data when several are in use.
+//
+// Implementations are invoked synchronously and should be cheap and
+// non-blocking.
+type CodecErrorHook interface {
+	SerializationError(serialization string, operation string)
+	CompressionError(codec string, operation string)
+}
+
+// RefreshHook is an optional interface a Hook can implement to be notified when
+// a Scheduler refreshes a registered set of keys, with the number of keys, how
+// long the refresh took, and the error if any key failed to refresh.
+//
+// Implementations are invoked from the goroutines of the Scheduler and should be
+// cheap and non-blocking.
+type RefreshHook interface {
+	Refreshed(keys int, duration time.Duration, err error)
+}
+
 type hooksMixin struct {
-	hooks   []Hook
-	initial hooks
-	current hooks
+	hooks       []Hook
+	access      []AccessHook
+	nearCache   []NearCacheHook
+	codecErrors []CodecErrorHook
+	cacheAside  []CacheAsideHook
+	refresh     []RefreshHook
+	initial     hooks
+	current     hooks
+
+	serialization string // name of the configured serialization
+	codec         string // name of the configured Codec
 }
 
 // AddHook adds a Hook to the processing chain.
+//
+// If the Hook also implements AccessHook it will be notified of cache hits and
+// misses, and if it implements NearCacheHook it will be notified of reads that
+// bypass the near cache. If it implements CodecErrorHook it will be notified of
+// serialization and compression errors, and if it implements CacheAsideHook it
+// will observe the lifecycle of cache-aside operations. If it implements
+// RefreshHook it will be notified of refreshes performed by a Scheduler.
 func (hs *hooksMixin) AddHook(hook Hook) {
 	hs.hooks = append(hs.hooks, hook)
+	if ah, ok := hook.(AccessHook); ok {
+		hs.access = append(hs.access, ah)
+	}
+	if nh, ok := hook.(NearCacheHook); ok {
+		hs.nearCache = append(hs.nearCache, nh)
+	}
+	if eh, ok := hook.(CodecErrorHook); ok {
+		hs.codecErrors = append(hs.codecErrors, eh)
+	}
+	if ch, ok := hook.(CacheAsideHook); ok {
+		hs.cacheAside = append(hs.cacheAside, ch)
+	}
+	if rh, ok := hook.(RefreshHook); ok {
+		hs.refresh = append(hs.refresh, rh)
+	}
 	hs.chain()
 }
 
+func (hs *hooksMixin) hit(key string) {
+	for _, ah := range hs.access {
+		ah.Hit(key)
+	}
+}
+
+func (hs *hooksMixin) miss(key string) {
+	for _, ah := range hs.access {
+		ah.Miss(key)
+	}
+}
+
+func (hs *hooksMixin) nearCacheSkipped(redisKey string) {
+	for _, nh := range hs.nearCache {
+		nh.NearCacheSkipped(redisKey)
+	}
+}
+
+func (hs *hooksMixin) refreshed(keys int, duration time.Duration, err error) {
+	for _, rh := range hs.refresh {
+		rh.Refreshed(keys, duration, err)
+	}
+}
+
+func (hs *hooksMixin) serializationError(serialization, operation string) {
+	for _, eh := range hs.codecErrors {
+		eh.SerializationError(serialization, operation)
+	}
+}
+
+func (hs *hooksMixin) compressionError(codec, operation string) {
+	for _, eh := range hs.codecErrors {
+		eh.CompressionError(codec, operation)
+	}
+}
+
+// reportingMarshaller wraps fn to report errors to the CodecErrorHooks with the
+// name of the serialization.
+func (hs *hooksMixin) reportingMarshaller(serialization string, fn Marshaller) Marshaller {
+	return func(v any) ([]byte, error) {
+		data, err := fn(v)
+		if err != nil {
+			hs.serializationError(serialization, "marshal")
+		}
+		return data, err
+	}
+}
+
+// reportingUnmarshaller wraps fn to report errors to the CodecErrorHooks with the
+// name of the serialization.
+func (hs *hooksMixin) reportingUnmarshaller(serialization string, fn Unmarshaller) Unmarshaller {
+	return func(b []byte, v any) error {
+		err := fn(b, v)
+		if err != nil {
+			hs.serializationError(serialization, "unmarshal")
+		}
+		return err
+	}
+}
+
+// reportingCompression wraps fn to report errors of the operation to the
+// CodecErrorHooks with the name of the Codec.
+func (hs *hooksMixin) reportingCompression(codec, operation string, fn CompressionHook) CompressionHook {
+	return func(data []byte) ([]byte, error) {
+		res, err := fn(data)
+		if err != nil {
+			hs.compressionError(codec, operation)
+		}
+		return res, err
+	}
+}
+
 func (hs *hooksMixin) initHooks(hooks hooks) {
 	hs.initial = hooks
 	hs.chain()
@@ -58,6 +215,15 @@ func (hs *hooksMixin) chain() {
 			hs.current.decompress = wrapped
 		}
 	}
+
+	// Errors are reported after every Hook so errors returned by Hooks are
+	// reported as well
+	if len(hs.codecErrors) > 0 {
+		hs.current.marshal = hs.reportingMarshaller(hs.serialization, hs.current.marshal)
+		hs.current.unmarshall = hs.reportingUnmarshaller(hs.serialization, hs.current.unmarshall)
+		hs.current.compress = hs.reportingCompression(hs.codec, "compress", hs.current.compress)
+		hs.current.decompress = hs.reportingCompression(hs.codec, "decompress", hs.current.decompress)
+	}
 }
 
 type hooks struct {
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const (
+	// DefaultIdempotencyTTL is the default duration an idempotency key is
+	// remembered after it is processed by SetOnce.
+	DefaultIdempotencyTTL = 24 * time.Hour
+
+	// idempotencyPrefix is the prefix of the keys used to record processed
+	// idempotency keys.
+	idempotencyPrefix = "rueidis-cache:idempotency:"
+)
+
+// setOnceScript records the idempotency key and writes the value only if the
+// idempotency key hasn't been recorded. Returns 1 if the value was written and 0
+// if the idempotency key was already processed.
+//
+// KEYS[1] is the idempotency record, KEYS[2] the key of the value. ARGV[1] is the
+// TTL of the idempotency record in milliseconds, ARGV[2] the value, and ARGV[3]
+// the TTL of the value in milliseconds, or 0 to persist the value.
+var setOnceScript = rueidis.NewLuaScript(`
+if not redis.call('SET', KEYS[1], '1', 'NX', 'PX', ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[2], ARGV[2])
+end
+return 1`)
+
+// SetOnce adds an entry into the cache like Set, unless the idempotencyKey has
+// already been processed for the key. This deduplicates writes when the same
+// event is processed more than once, such as with at-least-once message delivery.
+// SetOnce returns true if the value was written, and false if the write was
+// skipped.
+//
+// Processed idempotency keys are remembered for DefaultIdempotencyTTL unless
+// configured otherwise with the WithIdempotencyTTL Option. Recording the
+// idempotency key and writing the value happen atomically.
+//
+// On Redis Cluster the idempotency record is stored in the same slot as the key
+// using a hash tag. If the key contains braces that don't form a valid hash tag
+// the record can't be placed in the same slot and an error wrapping ErrCrossSlot
+// is returned.
+func (c *Cache) SetOnce(ctx context.Context, key string, v any, ttl time.Duration, idempotencyKey string) (bool, error) {
+	if c.dryRun {
+		return false, dryRunUnsupported("SetOnce")
+	}
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return false, err
+	}
+	if idempotencyKey == "" {
+		return false, fmt.Errorf("idempotency key is required")
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	recordKey := idempotencyPrefix + "{" + hashTag(redisKey) + "}:" + redisKey + ":" + idempotencyKey
+	if c.cluster && !sameSlot(recordKey, redisKey) {
+		return false, fmt.Errorf("idempotency record for key %s: %w", key, ErrCrossSlot)
+	}
+
+	if c.migration != nil {
+		return c.setOnceMigrating(ctx, key, recordKey, redisKey, v, ttl)
+	}
+
+	data, err := c.encode(ctx, key, v)
+	if err != nil {
+		return false, err
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	written, err := setOnceScript.Exec(ctx, c.redis, []string{recordKey, redisKey}, []string{
+		fmt.Sprint(c.idempotencyTTL.Milliseconds()),
+		string(data),
+		fmt.Sprint(ttl.Milliseconds()),
+	}).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return written == 1, nil
+}
+
+// setOnceMigrating claims the idempotency key and then writes the value with
+// setMigrating. The target Encoding can be stored in a different slot, so unlike
+// SetOnce the claim and write aren't atomic. If the write fails the claim is
+// released so the write can be retried.
+func (c *Cache) setOnceMigrating(ctx context.Context, key, recordKey, redisKey string, v any, ttl time.Duration) (bool, error) {
+	err := c.redis.Do(ctx, c.redis.B().Set().Key(recordKey).Value("1").Nx().
+		Px(c.idempotencyTTL).Build()).Error()
+	if errors.Is(err, rueidis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	if err := c.setMigrating(ctx, key, redisKey, v, ttl, nil); err != nil {
+		_ = c.redis.Do(context.WithoutCancel(ctx), c.redis.B().Del().Key(recordKey).Build()).Error()
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_SetOnce(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithIdempotencyTTL(time.Hour))
+
+	written, err := rdb.SetOnce(ctx, "key", "first", time.Minute, "event-1")
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	written, err = rdb.SetOnce(ctx, "key", "duplicate", time.Minute, "event-1")
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	var s string
+	assert.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, "first", s)
+	assert.Equal(t, time.Minute, server.TTL("key"))
+	assert.Equal(t, time.Hour, server.TTL("rueidis-cache:idempotency:{key}:key:event-1"))
+
+	written, err = rdb.SetOnce(ctx, "key", "second", 0, "event-2")
+	assert.NoError(t, err)
+	assert.True(t, written)
+	assert.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, "second", s)
+	assert.Equal(t, time.Duration(0), server.TTL("key"))
+
+	// Idempotency keys are scoped to the key
+	written, err = rdb.SetOnce(ctx, "other", "value", 0, "event-1")
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	_, err = rdb.SetOnce(ctx, "key", "value", 0, "")
+	assert.Error(t, err)
+}
+
+func TestCache_SetOnce_Migrating(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithMigrationMode(MsgpackEncoding(), JSONEncoding()))
+
+	written, err := rdb.SetOnce(ctx, "key", "first", 0, "event-1")
+	assert.NoError(t, err)
+	assert.True(t, written)
+
+	written, err = rdb.SetOnce(ctx, "key", "duplicate", 0, "event-1")
+	assert.NoError(t, err)
+	assert.False(t, written)
+
+	var s string
+	assert.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, "first", s)
+}
+
+func TestHashTag(t *testing.T) {
+	assert.Equal(t, "user:1", hashTag("{user:1}:profile"))
+	assert.Equal(t, "key", hashTag("key"))
+	assert.Equal(t, "a{}b", hashTag("a{}b"))
+}
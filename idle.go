@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// IdleTime returns the duration since the entry for the given key was last read
+// or written, as reported by OBJECT IDLETIME. This informs decisions such as
+// tuning TTLs or which entries to proactively drop. The idle time has a
+// resolution of seconds.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+// Reads served from the near cache don't reach Redis and don't reset the idle
+// time. Redis doesn't track idle times when configured with an LFU
+// maxmemory-policy and an error is returned.
+func (c *Cache) IdleTime(ctx context.Context, key string) (time.Duration, error) {
+	idle, err := c.IdleTimeMany(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	dur, ok := idle[key]
+	if !ok {
+		return 0, ErrKeyNotFound
+	}
+	return dur, nil
+}
+
+// IdleTimeMany returns the idle time of the entries for the given keys like
+// IdleTime, which is useful to profile the access patterns of a family of keys.
+// Keys that don't exist are omitted from the result. The keys are queried in a
+// single pipeline, so the keys don't need to hash to the same slot when using
+// Redis Cluster.
+func (c *Cache) IdleTimeMany(ctx context.Context, keys ...string) (map[string]time.Duration, error) {
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return map[string]time.Duration{}, nil
+	}
+
+	// While migrating an entry may be stored under the key of either Encoding,
+	// the most recently accessed one is reported.
+	perKey := 1
+	if c.migration != nil {
+		perKey = 2
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys)*perKey)
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, c.redis.B().ObjectIdletime().Key(redisKey).Build())
+		if c.migration != nil {
+			cmds = append(cmds, c.redis.B().ObjectIdletime().Key(c.migration.key(redisKey)).Build())
+		}
+	}
+
+	results := c.redis.DoMulti(ctx, cmds...)
+	idle := make(map[string]time.Duration, len(keys))
+	for i, key := range keys {
+		for _, res := range results[i*perKey : (i+1)*perKey] {
+			secs, err := res.AsInt64()
+			if errors.Is(err, rueidis.Nil) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("redis: %w", err)
+			}
+			dur := time.Duration(secs) * time.Second
+			if current, ok := idle[key]; !ok || dur < current {
+				idle[key] = dur
+			}
+		}
+	}
+	return idle, nil
+}
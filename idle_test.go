@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_IdleTime(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	now := time.Now()
+	server.SetTime(now)
+	rdb := New(client)
+	require.NoError(t, rdb.Set(context.Background(), "a", "alice", 0))
+	require.NoError(t, rdb.Set(context.Background(), "b", "bob", 0))
+
+	server.SetTime(now.Add(time.Minute))
+	var val string
+	require.NoError(t, rdb.Get(context.Background(), "b", &val))
+
+	idle, err := rdb.IdleTime(context.Background(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, idle)
+
+	_, err = rdb.IdleTime(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	many, err := rdb.IdleTimeMany(context.Background(), "a", "b", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"a": time.Minute, "b": 0}, many)
+}
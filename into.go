@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/redis/rueidis"
+)
+
+// MissingKeysError is returned by GetInto and GetConsistent when some of the keys
+// don't exist.
+// MissingKeysError matches ErrKeyNotFound using errors.Is.
+type MissingKeysError struct {
+	// Keys are the keys that didn't exist, in sorted order for GetInto and in
+	// the order requested for GetConsistent.
+	Keys []string
+}
+
+func (e *MissingKeysError) Error() string {
+	return "keys not found: " + strings.Join(e.Keys, ", ")
+}
+
+func (e *MissingKeysError) Is(target error) bool {
+	return target == ErrKeyNotFound
+}
+
+// GetInto retrieves multiple entries from the Cache and unmarshalls each value
+// into the destination mapped to its key. This reduces the boilerplate of
+// assembling a composite object from several cached pieces, such as mapping
+// each key to a field of a single struct:
+//
+//	var view struct {
+//		User     User
+//		Settings Settings
+//	}
+//	err := c.GetInto(ctx, map[string]any{
+//		"user:1":     &view.User,
+//		"settings:1": &view.Settings,
+//	})
+//
+// All the keys are read in a single pipeline, so the keys don't need to hash to
+// the same slot when using Redis Cluster. The destinations of keys that don't
+// exist are set to their zero value, and a *MissingKeysError reporting those keys
+// is returned. Errors reading or decoding individual keys are joined with it, so
+// the remaining destinations are still populated.
+func (c *Cache) GetInto(ctx context.Context, mapping map[string]any) error {
+	if len(mapping) == 0 {
+		return nil
+	}
+	for key, dst := range mapping {
+		if rv := reflect.ValueOf(dst); rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return fmt.Errorf("key %s: destination must be a non-nil pointer, got %T", key, dst)
+		}
+	}
+
+	missing, _, errs := c.getInto(ctx, mapping)
+	if len(missing) > 0 {
+		errs = append(errs, &MissingKeysError{Keys: missing})
+	}
+	return errors.Join(errs...)
+}
+
+// getInto implements GetInto, returning the keys that don't exist and the keys
+// that failed to be read or decoded in sorted order, along with the errors.
+func (c *Cache) getInto(ctx context.Context, mapping map[string]any) (missing, failed []string, errs []error) {
+	keys := sortedKeys(mapping)
+	record := func(key string, err error) {
+		switch {
+		case errors.Is(err, ErrKeyNotFound):
+			reflect.ValueOf(mapping[key]).Elem().SetZero()
+			missing = append(missing, key)
+		case err != nil:
+			failed = append(failed, key)
+			errs = append(errs, fmt.Errorf("key %s: %w", key, err))
+		}
+	}
+
+	// The target Encoding may be stored in a different slot while migrating, so
+	// each key is read with Get instead.
+	if c.migration != nil {
+		for _, key := range keys {
+			record(key, c.Get(ctx, key, mapping[key]))
+		}
+	} else {
+		redisKeys, err := c.keys(ctx, keys)
+		if err != nil {
+			return nil, nil, []error{err}
+		}
+		for i, res := range c.getPipelined(ctx, redisKeys) {
+			key := keys[i]
+			data, ok := c.buffered(redisKeys[i])
+			if !ok {
+				data, err = res.AsBytes()
+				if errors.Is(err, rueidis.Nil) {
+					c.hooksMixin.miss(key)
+					record(key, ErrKeyNotFound)
+					continue
+				}
+				if err != nil {
+					record(key, fmt.Errorf("redis: %w", err))
+					continue
+				}
+			}
+			record(key, c.recordRead(key, c.decode(ctx, key, data, mapping[key])))
+		}
+	}
+	return missing, failed, errs
+}
+
+// MGetMap retrieves multiple entries from the Cache, unmarshalling each value
+// into a new destination created by factory, and returns the destinations keyed
+// by their key. The factory must return a non-nil pointer, such as
+// func() any { return new(User) }. For a statically typed result use MGet
+// instead.
+//
+// Keys that don't exist are absent from the returned map. Like GetInto all the
+// keys are read in a single pipeline, so the keys don't need to hash to the same
+// slot when using Redis Cluster, and each value is decoded with the
+// serialization, compression, and Hooks of the Cache. Errors reading or decoding
+// individual keys identify the key and are joined into the returned error, while
+// the keys that were decoded successfully are still returned.
+func (c *Cache) MGetMap(ctx context.Context, keys []string, factory func() any) (map[string]any, error) {
+	if factory == nil {
+		panic(fmt.Errorf("nil factory not permitted, illegal use of api"))
+	}
+	mapping := make(map[string]any, len(keys))
+	for _, key := range keys {
+		dst := factory()
+		if rv := reflect.ValueOf(dst); rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return nil, fmt.Errorf("factory must return a non-nil pointer, got %T", dst)
+		}
+		mapping[key] = dst
+	}
+	if len(mapping) == 0 {
+		return map[string]any{}, nil
+	}
+
+	missing, failed, errs := c.getInto(ctx, mapping)
+	if len(failed) != len(errs) {
+		// The keys couldn't be mapped so none were read
+		return nil, errors.Join(errs...)
+	}
+	// Keys that failed are reported by the error rather than returned with a
+	// partially decoded value
+	for _, key := range append(missing, failed...) {
+		delete(mapping, key)
+	}
+	return mapping, errors.Join(errs...)
+}
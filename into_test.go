@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetInto(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	require.NoError(t, rdb.Set(context.Background(), "user", "alice", 0))
+	require.NoError(t, rdb.Set(context.Background(), "age", 42, 0))
+	require.NoError(t, server.Set("invalid", "\xc1\x00"))
+
+	var view struct {
+		User    string
+		Age     int
+		Team    string
+		Invalid int
+	}
+	view.Team = "stale"
+	err := rdb.GetInto(context.Background(), map[string]any{
+		"user": &view.User,
+		"age":  &view.Age,
+		"team": &view.Team,
+	})
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	var missingErr *MissingKeysError
+	require.True(t, errors.As(err, &missingErr))
+	assert.Equal(t, []string{"team"}, missingErr.Keys)
+	assert.Equal(t, "alice", view.User)
+	assert.Equal(t, 42, view.Age)
+	assert.Equal(t, "", view.Team)
+
+	err = rdb.GetInto(context.Background(), map[string]any{
+		"user":    &view.User,
+		"invalid": &view.Invalid,
+	})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, "alice", view.User)
+
+	assert.NoError(t, rdb.GetInto(context.Background(), map[string]any{"user": &view.User}))
+	assert.Error(t, rdb.GetInto(context.Background(), map[string]any{"user": view.User}))
+}
+
+func TestCache_MGetMap(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+	require.NoError(t, rdb.Set(ctx, "a", "alice", 0))
+	require.NoError(t, rdb.Set(ctx, "b", "bob", 0))
+	require.NoError(t, server.Set("invalid", "\xc1\x00"))
+
+	values, err := rdb.MGetMap(ctx, []string{"a", "b", "missing"}, func() any { return new(string) })
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	assert.Equal(t, "alice", *values["a"].(*string))
+	assert.Equal(t, "bob", *values["b"].(*string))
+
+	values, err = rdb.MGetMap(ctx, []string{"a", "invalid"}, func() any { return new(string) })
+	assert.ErrorContains(t, err, "key invalid")
+	assert.False(t, errors.Is(err, ErrKeyNotFound))
+	require.Len(t, values, 1)
+	assert.Equal(t, "alice", *values["a"].(*string))
+
+	values, err = rdb.MGetMap(ctx, nil, func() any { return new(string) })
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = rdb.MGetMap(ctx, []string{"a"}, func() any { return "not a pointer" })
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultKeySeparator is the separator used to join the parts of keys built by
+// Key, and by Cache.Key unless configured otherwise with WithKeySeparator.
+const DefaultKeySeparator = ':'
+
+// keyEscape escapes occurrences of the separator, and of itself, in the parts
+// of a composite key.
+const keyEscape = '\\'
+
+// ErrMalformedKey is an error value that signals a key can't be split into its
+// parts because it ends with an incomplete escape sequence.
+var ErrMalformedKey = errors.New("malformed key")
+
+// Key builds a composite key by joining parts with DefaultKeySeparator. See
+// Cache.Key for the escaping scheme that keeps composite keys unambiguous.
+func Key(parts ...string) string {
+	return joinKey(DefaultKeySeparator, parts)
+}
+
+// SplitKey splits a key built by Key back into its parts.
+func SplitKey(key string) ([]string, error) {
+	return splitKey(DefaultKeySeparator, key)
+}
+
+// Key builds a composite key by joining parts with the separator configured
+// with WithKeySeparator, DefaultKeySeparator by default.
+//
+// Occurrences of the separator within a part are escaped with a backslash, as
+// are backslashes themselves, so distinct parts always build distinct keys.
+// For example Key("a:b", "c") builds a\:b:c while Key("a", "b:c") builds
+// a:b\:c. This prevents keys built from user-influenced data from colliding.
+// Parts without a separator or backslash are joined as is, so keys built from
+// plain parts match keys built by hand.
+//
+// Since backslash is also the escape character of Redis glob patterns, keys
+// containing escaped parts must be escaped again when matched with ScanKeys.
+func (c *Cache) Key(parts ...string) string {
+	return joinKey(c.keySeparator, parts)
+}
+
+// SplitKey splits a key built by Key back into its parts, removing the escaping.
+// If the key ends with an incomplete escape sequence an error wrapping
+// ErrMalformedKey is returned.
+func (c *Cache) SplitKey(key string) ([]string, error) {
+	return splitKey(c.keySeparator, key)
+}
+
+func joinKey(sep rune, parts []string) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		if i > 0 {
+			sb.WriteRune(sep)
+		}
+		for _, r := range part {
+			if r == sep || r == keyEscape {
+				sb.WriteRune(keyEscape)
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func splitKey(sep rune, key string) ([]string, error) {
+	var (
+		parts   []string
+		sb      strings.Builder
+		escaped bool
+	)
+	for _, r := range key {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == keyEscape:
+			escaped = true
+		case r == sep:
+			parts = append(parts, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("key %s: %w", key, ErrMalformedKey)
+	}
+	return append(parts, sb.String()), nil
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		want  string
+	}{
+		{name: "plain", parts: []string{"user", "42", "profile"}, want: "user:42:profile"},
+		{name: "separator", parts: []string{"a:b", "c"}, want: `a\:b:c`},
+		{name: "escape", parts: []string{`a\`, "b"}, want: `a\\:b`},
+		{name: "empty part", parts: []string{"a", "", "b"}, want: "a::b"},
+		{name: "single", parts: []string{"a"}, want: "a"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key := Key(tc.parts...)
+			assert.Equal(t, tc.want, key)
+			parts, err := SplitKey(key)
+			require.NoError(t, err)
+			assert.Equal(t, tc.parts, parts)
+		})
+	}
+}
+
+func TestKey_Collisions(t *testing.T) {
+	collisions := [][2][]string{
+		{{"a:b", "c"}, {"a", "b:c"}},
+		{{`a\`, "b"}, {`a\:b`}},
+		{{`a\`, ":b"}, {`a\:`, "b"}},
+		{{"a", ""}, {"a:"}},
+	}
+	for _, tc := range collisions {
+		assert.NotEqual(t, Key(tc[0]...), Key(tc[1]...), "%q and %q", tc[0], tc[1])
+	}
+}
+
+func TestSplitKey_Malformed(t *testing.T) {
+	_, err := SplitKey(`a:b\`)
+	assert.ErrorIs(t, err, ErrMalformedKey)
+}
+
+func TestCache_Key(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.Equal(t, `a\:b:c`, rdb.Key("a:b", "c"))
+
+	rdb = New(client, WithKeySeparator('|'))
+	assert.Equal(t, "a:b|c", rdb.Key("a:b", "c"))
+	assert.Equal(t, `a\|b|c`, rdb.Key("a|b", "c"))
+	assert.NotEqual(t, rdb.Key("a|b", "c"), rdb.Key("a", "b|c"))
+	parts, err := rdb.SplitKey(`a\|b|c`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a|b", "c"}, parts)
+
+	assert.Panics(t, func() { WithKeySeparator('\\') })
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxKeyLength is the maximum length of keys used when WithMaxKeyLength is
+// provided a length <= 0.
+const DefaultMaxKeyLength = 1024
+
+// ErrKeyTooLong is an error value that signals a key exceeds the maximum length
+// configured with WithMaxKeyLength.
+var ErrKeyTooLong = errors.New("key too long")
+
+// key maps the key provided by the caller to the key stored in Redis.
+func (c *Cache) key(ctx context.Context, key string) (string, error) {
+	if c.generation != nil {
+		gen, err := c.generation.current(ctx, c.redis)
+		if err != nil {
+			return "", err
+		}
+		return c.generationKey(key, gen)
+	}
+	redisKey := c.transformKey(key)
+	if err := c.checkKeyLength(key, redisKey); err != nil {
+		return "", err
+	}
+	return redisKey, nil
+}
+
+// generationKey maps the key provided by the caller to the key stored in Redis
+// for the given generation.
+func (c *Cache) generationKey(key string, gen int64) (string, error) {
+	redisKey := c.generation.prefix(gen) + c.transformKey(key)
+	if err := c.checkKeyLength(key, redisKey); err != nil {
+		return "", err
+	}
+	return redisKey, nil
+}
+
+// keys maps the keys provided by the caller to the keys stored in Redis. The
+// keys returned are in the same order as provided.
+func (c *Cache) keys(ctx context.Context, keys []string) ([]string, error) {
+	if c.generation == nil && len(c.keyTransforms) == 0 {
+		for _, key := range keys {
+			if err := c.checkKeyLength(key, key); err != nil {
+				return nil, err
+			}
+		}
+		return keys, nil
+	}
+	prefix := ""
+	if c.generation != nil {
+		gen, err := c.generation.current(ctx, c.redis)
+		if err != nil {
+			return nil, err
+		}
+		prefix = c.generation.prefix(gen)
+	}
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = prefix + c.transformKey(key)
+		if err := c.checkKeyLength(key, redisKeys[i]); err != nil {
+			return nil, err
+		}
+	}
+	return redisKeys, nil
+}
+
+// checkKeyLength returns an error wrapping ErrKeyTooLong if a maximum key length
+// is configured and the key stored in Redis exceeds it.
+func (c *Cache) checkKeyLength(key, redisKey string) error {
+	if c.maxKeyLength <= 0 || len(redisKey) <= c.maxKeyLength {
+		return nil
+	}
+	if len(key) > 64 {
+		key = key[:64] + "..."
+	}
+	return fmt.Errorf("%w: key %s is %d bytes, maximum is %d", ErrKeyTooLong, key, len(redisKey), c.maxKeyLength)
+}
+
+// pattern maps a SCAN pattern provided by the caller to a pattern matching the
+// keys stored in Redis, and returns a function to restore the keys returned by
+// SCAN to the keys the caller is aware of. The function reports false for keys
+// that can't be restored, which should be skipped.
+//
+// The pattern is transformed like a key, so when key compaction is enabled only
+// segments of the pattern that are literal matches for the dictionary of the
+// KeyCompactor match compacted keys. If any KeyTransform is irreversible an
+// error wrapping ErrIrreversibleKeys is returned.
+func (c *Cache) pattern(ctx context.Context, pattern string) (string, func(string) (string, bool), error) {
+	if c.generation == nil && len(c.keyTransforms) == 0 {
+		return pattern, func(key string) (string, bool) { return key, true }, nil
+	}
+	pattern, err := c.transformPattern(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	prefix := ""
+	if c.generation != nil {
+		gen, err := c.generation.current(ctx, c.redis)
+		if err != nil {
+			return "", nil, err
+		}
+		prefix = c.generation.prefix(gen)
+	}
+	return prefix + pattern, func(key string) (string, bool) {
+		key, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			return "", false
+		}
+		return c.restoreKey(key)
+	}, nil
+}
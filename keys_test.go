@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_MaxKeyLength(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMaxKeyLength(8))
+	assert.NoError(t, rdb.Set(context.Background(), "user:123", "alice", time.Minute))
+
+	err := rdb.Set(context.Background(), "user:1234", "bob", time.Minute)
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+	assert.Contains(t, err.Error(), "9 bytes")
+	assert.False(t, server.Exists("user:1234"))
+
+	_, err = MGet[string](context.Background(), rdb, "user:123", "user:1234")
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+
+	// The length is checked after the generation prefix is applied
+	rdb = New(client, WithMaxKeyLength(8), WithGenerationBusting())
+	var val string
+	assert.ErrorIs(t, rdb.Get(context.Background(), "user:123", &val), ErrKeyTooLong)
+
+	rdb = New(client, WithMaxKeyLength(0))
+	assert.Equal(t, DefaultMaxKeyLength, rdb.maxKeyLength)
+	assert.ErrorIs(t, rdb.Set(context.Background(), strings.Repeat("k", DefaultMaxKeyLength+1), "v", 0), ErrKeyTooLong)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// LazyValue is a value retrieved from the Cache by GetLazy that defers
+// decompressing and unmarshalling the value until Into is called. For large
+// values where callers often short-circuit before needing the value, this
+// avoids the cost of decoding it entirely.
+//
+// LazyValue is safe for concurrent use. The value is decompressed once by the
+// first call to Into and the decompressed bytes are retained, so subsequent
+// calls only unmarshall the value. While migrating between Encodings the value
+// is decompressed on every call instead.
+type LazyValue struct {
+	cache        *Cache
+	key          string
+	raw          []byte          // value as stored in Redis
+	payload      []byte          // value without the header
+	decompressor CompressionHook // nil if the payload isn't compressed
+	unmarshall   Unmarshaller    // non-nil if read in migration mode
+
+	once sync.Once
+	data []byte
+	err  error
+}
+
+// Size returns the size in bytes of the value as stored in Redis.
+func (lv *LazyValue) Size() int {
+	return len(lv.raw)
+}
+
+// Into decompresses the value, if it hasn't been already, and unmarshalls it
+// into dst. Errors decompressing the value are returned by every call to Into.
+func (lv *LazyValue) Into(dst any) error {
+	if lv.unmarshall != nil {
+		return lv.cache.decodeMigrating(context.Background(), lv.key, lv.raw, dst, lv.unmarshall)
+	}
+	lv.once.Do(func() {
+		lv.data, lv.err = lv.cache.decompress(lv.key, lv.raw, lv.payload, lv.decompressor)
+	})
+	if lv.err != nil {
+		return lv.err
+	}
+	return lv.cache.unmarshall(lv.key, lv.raw, lv.data, dst)
+}
+
+// GetLazy retrieves an entry from the Cache for the given key without
+// decompressing or unmarshalling it. The value is decoded when Into is called
+// on the returned LazyValue. The header of the value is still read eagerly, so
+// values older than the minimum freshness configured with WithMinFreshness are
+// treated as a miss.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+//
+// CallOptions such as WithoutNearCache can be provided to change the behavior of
+// this call only.
+func (c *Cache) GetLazy(ctx context.Context, key string, opts ...CallOption) (*LazyValue, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if c.migration != nil {
+		raw, unmarshall, err := c.fetchMigrating(ctx, key, redisKey)
+		if err != nil {
+			return nil, err
+		}
+		c.hooksMixin.hit(key)
+		return &LazyValue{cache: c, key: key, raw: raw, unmarshall: unmarshall}, nil
+	}
+
+	_, raw, err := c.fetch(ctx, key, redisKey, newCallOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	c.capture(key, StageRead, raw)
+	payload, decompressor, err := c.unframe(ctx, key, raw, c.deleteStale)
+	if err != nil {
+		return nil, c.recordRead(key, c.poisoned(key, raw, err))
+	}
+	c.hooksMixin.hit(key)
+	return &LazyValue{
+		cache:        c,
+		key:          key,
+		raw:          raw,
+		payload:      payload,
+		decompressor: decompressor,
+	}, nil
+}
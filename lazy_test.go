@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetLazy(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	codec := &countingCodec{}
+	rdb := New(client, Compression(codec))
+	long := strings.Repeat("value", 100)
+	require.NoError(t, rdb.Set(context.Background(), "key", long, 0))
+
+	lv, err := rdb.GetLazy(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, 0, codec.deflated)
+	stored, err := server.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, len(stored), lv.Size())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var val string
+			assert.NoError(t, lv.Into(&val))
+			assert.Equal(t, long, val)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, codec.deflated)
+
+	var wrongType int
+	assert.Error(t, lv.Into(&wrongType))
+
+	_, err = rdb.GetLazy(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestCache_GetLazy_Migration(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMigrationMode(MsgpackEncoding(), JSONEncoding()))
+	require.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+
+	lv, err := rdb.GetLazy(context.Background(), "key")
+	require.NoError(t, err)
+	var val string
+	require.NoError(t, lv.Into(&val))
+	assert.Equal(t, "value", val)
+}
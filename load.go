@@ -0,0 +1,215 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// LoaderFunc loads the values for a set of keys from the source of truth. Keys
+// that don't exist in the source of truth should be omitted from the returned
+// map.
+type LoaderFunc[T any] func(ctx context.Context, keys []string) (map[string]T, error)
+
+// MGetOrLoad retrieves multiple keys from the Cache and invokes the loader once
+// for all the keys that were not found. The loaded values are written to the
+// Cache with the provided TTL and the combined results are returned.
+//
+// MGetOrLoad is equivalent to calling MGetOrLoadBatched with a loaderBatchSize
+// of zero.
+func MGetOrLoad[T any](
+	ctx context.Context,
+	c *Cache,
+	keys []string,
+	ttl time.Duration,
+	loader LoaderFunc[T]) (MultiResult[T], error) {
+
+	return MGetOrLoadBatched(ctx, c, keys, ttl, 0, 1, loader)
+}
+
+// MGetOrLoadBatched retrieves multiple keys from the Cache and loads the keys
+// that were not found using the loader. The missing keys are split into chunks
+// of at most loaderBatchSize keys, and up to loaderConcurrency chunks are loaded
+// concurrently. This avoids querying the source of truth with a single huge
+// request when a large number of keys miss. A loaderBatchSize <= 0 loads all the
+// missing keys in a single call, and a loaderConcurrency <= 0 is treated as 1.
+//
+// The loaded values are written to the Cache with the provided TTL using a single
+// pipeline once all chunks have been loaded. If the ttl value is <= 0 the keys
+// will be persisted indefinitely.
+//
+// Errors are aggregated rather than failing fast. If loading a chunk fails the
+// error for the chunk is joined into the returned error, but the values from the
+// chunks that were loaded successfully are still written to the Cache and
+// returned. Callers should check the MultiResult for the keys they require even
+// when a non-nil error is returned. An error reading from the Cache is returned
+// immediately without invoking the loader.
+func MGetOrLoadBatched[T any](
+	ctx context.Context,
+	c *Cache,
+	keys []string,
+	ttl time.Duration,
+	loaderBatchSize int,
+	loaderConcurrency int,
+	loader LoaderFunc[T]) (MultiResult[T], error) {
+
+	if loader == nil {
+		panic(fmt.Errorf("nil LoaderFunc not permitted, illegal use of API"))
+	}
+
+	ctx, end := c.hooksMixin.startCacheAside(ctx, "MGetOrLoad")
+	var stats CacheAsideResult
+	results, err := mGetOrLoad(ctx, c, keys, ttl, loaderBatchSize, loaderConcurrency, loader, &stats)
+	stats.Err = err
+	end(stats)
+	return results, err
+}
+
+// mGetOrLoad implements MGetOrLoadBatched, recording the outcome of each phase
+// in stats.
+func mGetOrLoad[T any](
+	ctx context.Context,
+	c *Cache,
+	keys []string,
+	ttl time.Duration,
+	loaderBatchSize int,
+	loaderConcurrency int,
+	loader LoaderFunc[T],
+	stats *CacheAsideResult) (MultiResult[T], error) {
+
+	readCtx, endRead := c.hooksMixin.startCacheAsidePhase(ctx, PhaseRead)
+	results, err := MGet[T](readCtx, c, keys...)
+	endRead(err)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0, len(keys)-len(results))
+	for _, key := range keys {
+		if _, ok := results[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	stats.Hits, stats.Misses = len(keys)-len(missing), len(missing)
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	if loaderBatchSize <= 0 {
+		loaderBatchSize = len(missing)
+	}
+	if loaderConcurrency <= 0 {
+		loaderConcurrency = 1
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		loaded = make(map[string]T, len(missing))
+		errs   []error
+		sem    = make(chan struct{}, loaderConcurrency)
+		start  = time.Now()
+	)
+	loadCtx, endLoad := c.hooksMixin.startCacheAsidePhase(ctx, PhaseLoad)
+	for _, batch := range chunk(missing, loaderBatchSize) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(batch []string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			values, err := loader(loadCtx, batch)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("load %d keys starting at %s: %w", len(batch), batch[0], err))
+				return
+			}
+			for key, val := range values {
+				loaded[key] = val
+			}
+		}(batch)
+	}
+	wg.Wait()
+	stats.LoadDuration = time.Since(start)
+	endLoad(errors.Join(errs...))
+
+	writeCtx, endWrite := c.hooksMixin.startCacheAsidePhase(ctx, PhaseWrite)
+	err = setMany(writeCtx, c, loaded, ttl)
+	endWrite(err)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for key, val := range loaded {
+		results[key] = val
+	}
+	return results, errors.Join(errs...)
+}
+
+// setMany writes the entries to Redis with the provided TTL using pipelined SET
+// commands. Unlike MSet the entries can be stored in different slots and are set
+// with a TTL, but the writes are not atomic.
+func setMany[T any](ctx context.Context, c *Cache, keyvalues map[string]T, ttl time.Duration) error {
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	if len(keyvalues) == 0 {
+		return nil
+	}
+
+	cmds := make(rueidis.Commands, 0, len(keyvalues))
+	redisKeys := make([]string, 0, len(keyvalues))
+	var entries []DryRunEntry
+	for key, val := range keyvalues {
+		redisKey, err := c.key(ctx, key)
+		if err != nil {
+			return err
+		}
+		if c.dryRun {
+			data, err := c.encode(ctx, key, val)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, DryRunEntry{Key: key, RedisKey: redisKey, Size: len(data)})
+			continue
+		}
+		if c.migration != nil {
+			if err := c.setMigrating(ctx, key, redisKey, val, ttl, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := c.encode(ctx, key, val)
+		if err != nil {
+			return err
+		}
+		cmd := c.redis.B().Set().Key(redisKey).Value(string(data))
+		if ttl > 0 {
+			cmd.Ex(ttl)
+		}
+		cmds = append(cmds, cmd.Build())
+		redisKeys = append(redisKeys, redisKey)
+	}
+	if c.dryRun {
+		return newDryRunReport("MSetWithTTL", entries)
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	if err := c.flushBuffered(ctx, redisKeys...); err != nil {
+		return err
+	}
+
+	for _, res := range c.redis.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	return nil
+}
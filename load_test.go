@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMGetOrLoad(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.NoError(t, rdb.Set(context.Background(), "key1", "cached", 0))
+
+	var calls atomic.Int32
+	res, err := MGetOrLoad(context.Background(), rdb, []string{"key1", "key2", "key3"}, time.Minute,
+		func(ctx context.Context, keys []string) (map[string]string, error) {
+			calls.Add(1)
+			assert.ElementsMatch(t, []string{"key2", "key3"}, keys)
+			return map[string]string{"key2": "loaded"}, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"key1": "cached", "key2": "loaded"}, res)
+	assert.Equal(t, int32(1), calls.Load())
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key2", &val))
+	assert.Equal(t, "loaded", val)
+	assert.Equal(t, time.Minute, server.TTL("key2"))
+}
+
+func TestMGetOrLoadBatched(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	keys := []string{"key1", "key2", "key3", "key4", "key5"}
+
+	var (
+		mu      sync.Mutex
+		batches [][]string
+		active  atomic.Int32
+		peak    atomic.Int32
+	)
+	res, err := MGetOrLoadBatched(context.Background(), rdb, keys, 0, 2, 2,
+		func(ctx context.Context, keys []string) (map[string]int, error) {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			batches = append(batches, keys)
+			mu.Unlock()
+
+			if keys[0] == "key3" {
+				return nil, assert.AnError
+			}
+			values := make(map[string]int, len(keys))
+			for _, key := range keys {
+				values[key] = len(key)
+			}
+			return values, nil
+		})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Len(t, batches, 3)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+	assert.Equal(t, MultiResult[int]{"key1": 4, "key2": 4, "key5": 4}, res)
+
+	stored, err := MGet[int](context.Background(), rdb, keys...)
+	assert.NoError(t, err)
+	assert.Equal(t, res, stored)
+}
@@ -0,0 +1,69 @@
+// Package memfallback provides an in-memory store for Caches created with
+// cache.NewWithFallback, so applications can run without Redis during local
+// development and tests.
+//
+// The store emulates Redis within the process using miniredis, which is why it
+// lives in its own package: applications that never fall back don't link it.
+package memfallback
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/rueidis"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+// clockInterval is how often the clock of the in-memory store is advanced,
+// bounding how late keys expire.
+const clockInterval = 100 * time.Millisecond
+
+// Store is a cache.FallbackStore emulating Redis in memory. Values go through
+// the same serialization and compression as with Redis, and TTLs are honored
+// with a resolution of 100ms. Data is lost once the process exits.
+//
+//	rdb, err := cache.NewWithFallback(clientOpt, memfallback.Store)
+var Store cache.FallbackStore = start
+
+func start() (rueidis.Client, func(), error) {
+	server, err := miniredis.Run()
+	if err != nil {
+		return nil, nil, fmt.Errorf("start in-memory store: %w", err)
+	}
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	})
+	if err != nil {
+		server.Close()
+		return nil, nil, fmt.Errorf("connect in-memory store: %w", err)
+	}
+	done := make(chan struct{})
+	go run(server, done)
+	return client, func() {
+		close(done)
+		server.Close()
+	}, nil
+}
+
+// run advances the clock of the in-memory store, which otherwise never expires
+// keys, in step with the wall clock.
+func run(server *miniredis.Miniredis, done <-chan struct{}) {
+	ticker := time.NewTicker(clockInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	server.SetTime(last)
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			server.SetTime(now)
+			server.FastForward(now.Sub(last))
+			last = now
+		}
+	}
+}
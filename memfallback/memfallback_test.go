@@ -0,0 +1,37 @@
+package memfallback
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cache "github.com/jkratz55/rueidis-cache"
+)
+
+func TestStore(t *testing.T) {
+	rdb, err := cache.NewWithFallback(rueidis.ClientOption{
+		InitAddress:       []string{"127.0.0.1:1"},
+		ForceSingleClient: true,
+	}, Store, cache.JSON())
+	require.NoError(t, err)
+	defer rdb.Close(context.Background())
+	assert.True(t, rdb.InMemory())
+	assert.True(t, rdb.Config().InMemory)
+	assert.Equal(t, "json", rdb.Config().Serialization)
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "key", map[string]int{"count": 1}, time.Second))
+	var v map[string]int
+	require.NoError(t, rdb.Get(ctx, "key", &v))
+	assert.Equal(t, map[string]int{"count": 1}, v)
+
+	// TTLs are honored
+	assert.Eventually(t, func() bool {
+		return errors.Is(rdb.Get(ctx, "key", &v), cache.ErrKeyNotFound)
+	}, 3*time.Second, 50*time.Millisecond)
+}
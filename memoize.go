@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// memoizePrefix is the prefix of the keys derived by MemoizeAuto.
+const memoizePrefix = "memoize:"
+
+// Memoize wraps fn so its results are cached with the provided TTL under the key
+// returned by keyFn for the argument. Calls with an argument whose result is
+// cached return the cached result without invoking fn. On a cache miss or read
+// error fn is invoked and its result is stored in the cache.
+//
+// Errors returned by fn are returned to the caller and are not cached. Errors
+// encountered while storing the result are logged, but not returned to the
+// caller, as the result was still computed successfully.
+func Memoize[Arg, Ret any](
+	c *Cache,
+	keyFn func(arg Arg) string,
+	ttl time.Duration,
+	fn func(ctx context.Context, arg Arg) (Ret, error)) func(ctx context.Context, arg Arg) (Ret, error) {
+
+	if keyFn == nil {
+		panic(fmt.Errorf("nil keyFn not permitted, illegal use of api"))
+	}
+	if fn == nil {
+		panic(fmt.Errorf("nil fn not permitted, illegal use of api"))
+	}
+	return func(ctx context.Context, arg Arg) (Ret, error) {
+		return memoized(ctx, c, keyFn(arg), arg, ttl, fn)
+	}
+}
+
+// MemoizeAuto is like Memoize but derives the key automatically by marshalling
+// the argument with the Marshaller configured for the Cache and hashing it with
+// SHA-256. The key is namespaced with the name of fn so different functions
+// memoized with arguments of the same type don't collide. Anonymous functions
+// are named after the function they are declared in and their position in it,
+// so the name is stable across calls and processes running the same build.
+//
+// The key is only stable if marshalling the argument is deterministic, equal
+// arguments must always marshal to the same bytes. The default msgpack
+// serialization doesn't sort map keys, so arguments containing maps produce a
+// different key on each call and are never served from the cache. JSON sorts
+// map keys, but neither serialization is deterministic for values such as
+// pointers to cyclic structures or types with custom marshalling. Use Memoize
+// with a keyFn for such arguments.
+//
+// If the argument can't be marshalled fn is invoked without caching the result.
+func MemoizeAuto[Arg, Ret any](
+	c *Cache,
+	ttl time.Duration,
+	fn func(ctx context.Context, arg Arg) (Ret, error)) func(ctx context.Context, arg Arg) (Ret, error) {
+
+	if fn == nil {
+		panic(fmt.Errorf("nil fn not permitted, illegal use of api"))
+	}
+	prefix := memoizePrefix + runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name() + ":"
+	return func(ctx context.Context, arg Arg) (Ret, error) {
+		data, err := c.marshaller(arg)
+		if err != nil {
+			slog.Error("Failed to marshall argument of memoized function", slog.Any("err", err))
+			return fn(ctx, arg)
+		}
+		sum := sha256.Sum256(data)
+		return memoized(ctx, c, prefix+hex.EncodeToString(sum[:]), arg, ttl, fn)
+	}
+}
+
+// memoized returns the result of fn cached under the key, invoking fn and
+// storing the result on a cache miss.
+func memoized[Arg, Ret any](
+	ctx context.Context,
+	c *Cache,
+	key string,
+	arg Arg,
+	ttl time.Duration,
+	fn func(ctx context.Context, arg Arg) (Ret, error)) (Ret, error) {
+
+	var ret Ret
+	err := c.Get(ctx, key, &ret)
+	if err == nil {
+		return ret, nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		slog.Error(fmt.Sprintf("Failed to read memoized result for key %s", key), slog.Any("err", err))
+	}
+
+	ret, err = fn(ctx, arg)
+	if err != nil {
+		return ret, err
+	}
+	if err := c.Set(ctx, key, ret, ttl); err != nil {
+		slog.Error(fmt.Sprintf("Failed to cache memoized result for key %s", key), slog.Any("err", err))
+	}
+	return ret, nil
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoizeQuery struct {
+	Name  string
+	Limit int
+}
+
+func TestMemoize(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	var calls int
+	lookup := Memoize(rdb, func(id int) string { return fmt.Sprintf("user:%d", id) }, time.Minute,
+		func(ctx context.Context, id int) (string, error) {
+			calls++
+			if id < 0 {
+				return "", assert.AnError
+			}
+			return fmt.Sprintf("user-%d", id), nil
+		})
+
+	for i := 0; i < 3; i++ {
+		val, err := lookup(context.Background(), 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", val)
+	}
+	assert.Equal(t, 1, calls)
+	assert.True(t, server.Exists("user:1"))
+
+	_, err := lookup(context.Background(), -1)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.False(t, server.Exists("user:-1"))
+}
+
+func TestMemoizeAuto(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, JSON())
+	var calls int
+	search := MemoizeAuto(rdb, time.Minute, func(ctx context.Context, q memoizeQuery) ([]string, error) {
+		calls++
+		return []string{q.Name + "-1", q.Name + "-2"}[:q.Limit], nil
+	})
+	count := MemoizeAuto(rdb, time.Minute, func(ctx context.Context, q memoizeQuery) (int, error) {
+		return q.Limit, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		val, err := search(context.Background(), memoizeQuery{Name: "alice", Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice-1", "alice-2"}, val)
+	}
+	assert.Equal(t, 1, calls)
+
+	val, err := search(context.Background(), memoizeQuery{Name: "alice", Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice-1"}, val)
+	assert.Equal(t, 2, calls)
+
+	// Functions memoized with the same argument type don't share keys
+	n, err := count(context.Background(), memoizeQuery{Name: "alice", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	keys := server.Keys()
+	assert.Len(t, keys, 3)
+	for _, key := range keys {
+		assert.True(t, strings.HasPrefix(key, memoizePrefix), key)
+	}
+}
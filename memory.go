@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/redis/rueidis"
+)
+
+// FallbackStore starts the in-process store backing a Cache created by
+// NewWithFallback when Redis is unavailable. It returns a client connected to
+// the store and a function releasing the store, which is called by Close.
+//
+// The memfallback package provides a FallbackStore emulating Redis in memory.
+type FallbackStore func() (rueidis.Client, func(), error)
+
+// memoryStore is the in-process store started by NewWithFallback when Redis is
+// unreachable.
+type memoryStore struct {
+	client  rueidis.Client
+	release func()
+}
+
+func (ms *memoryStore) close() {
+	ms.client.Close()
+	ms.release()
+}
+
+// NewWithFallback creates a Cache backed by a rueidis.Client created with
+// clientOpt. If the client can't be created, typically because Redis is
+// unreachable, the Cache is backed by the in-process store started by fallback
+// instead. If the store can't be started the error creating the client is
+// returned.
+//
+// Falling back is intended for local development and tests without Redis, and
+// should never be used in production, where running memory-only would silently
+// stop sharing the cache between instances. Values go through the same
+// serialization and compression as with Redis, but data isn't shared with other
+// processes. Running in fallback mode is logged as a warning, and is reported by
+// InMemory and CacheConfig. A nil fallback panics.
+//
+//	rdb, err := cache.NewWithFallback(clientOpt, memfallback.Store)
+//
+// Close must be called to release the in-process store once the Cache is no
+// longer used.
+func NewWithFallback(clientOpt rueidis.ClientOption, fallback FallbackStore, opts ...Option) (*Cache, error) {
+	if fallback == nil {
+		panic(fmt.Errorf("nil FallbackStore not permitted, illegal use of api"))
+	}
+	client, err := rueidis.NewClient(clientOpt)
+	if err == nil {
+		return New(client, opts...), nil
+	}
+
+	storeClient, release, storeErr := fallback()
+	if storeErr != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	c := New(storeClient, opts...)
+	c.memory = &memoryStore{client: storeClient, release: release}
+	slog.Warn("Redis is unavailable, the cache is running in memory and isn't shared with other processes",
+		slog.Any("err", err))
+	return c, nil
+}
+
+// InMemory reports if the Cache was created by NewWithFallback with an in-process
+// store because Redis was unavailable.
+func (c *Cache) InMemory() bool {
+	return c.memory != nil
+}
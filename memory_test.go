@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/redis/rueidis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachable is a ClientOption for a Redis that can't be connected to.
+var unreachable = rueidis.ClientOption{
+	InitAddress:       []string{"127.0.0.1:1"},
+	ForceSingleClient: true,
+}
+
+func TestNewWithFallback(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	released := false
+	store := func() (rueidis.Client, func(), error) {
+		client, err := rueidis.NewClient(rueidis.ClientOption{
+			InitAddress:       []string{server.Addr()},
+			DisableCache:      true,
+			ForceSingleClient: true,
+		})
+		return client, func() { released = true }, err
+	}
+
+	rdb, err := NewWithFallback(unreachable, store, JSON())
+	require.NoError(t, err)
+	assert.True(t, rdb.InMemory())
+	assert.True(t, rdb.Config().InMemory)
+	assert.Equal(t, "json", rdb.Config().Serialization)
+
+	require.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.True(t, server.Exists("key"))
+
+	require.NoError(t, rdb.Close(context.Background()))
+	assert.True(t, released)
+
+	// The error creating the client is returned if the store can't be started
+	failing := func() (rueidis.Client, func(), error) {
+		return nil, nil, errors.New("boom")
+	}
+	_, err = NewWithFallback(unreachable, failing)
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "boom")
+
+	assert.Panics(t, func() {
+		_, _ = NewWithFallback(unreachable, nil)
+	})
+}
+
+func TestNewWithFallback_Connected(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	store := func() (rueidis.Client, func(), error) {
+		t.Fatal("the fallback store must not be started when Redis is available")
+		return nil, nil, nil
+	}
+	rdb, err := NewWithFallback(rueidis.ClientOption{
+		InitAddress:       []string{server.Addr()},
+		DisableCache:      true,
+		ForceSingleClient: true,
+	}, store)
+	require.NoError(t, err)
+	defer rdb.Close(context.Background())
+	assert.False(t, rdb.InMemory())
+
+	require.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.True(t, server.Exists("key"))
+}
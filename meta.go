@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// SetWithMeta adds an entry into the cache like Set, and stores the metadata
+// alongside the value. Metadata is intended for small operational fields such as
+// the source of the value or the schema version, which can be read using GetMeta
+// without decoding the value. If the ttl value is <= 0 the key will be persisted
+// indefinitely.
+//
+// The metadata is stored in the header of the value, so every instance of Cache
+// sharing a Redis keyspace should be upgraded to a version supporting metadata
+// before using SetWithMeta. Metadata is replaced on every write, a subsequent Set
+// of the same key removes it.
+func (c *Cache) SetWithMeta(ctx context.Context, key string, v any, meta map[string]string, ttl time.Duration) error {
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	if c.migration != nil && !c.dryRun {
+		return c.setMigrating(ctx, key, redisKey, v, ttl, meta)
+	}
+	data, err := c.encodeWithMeta(ctx, key, v, meta)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		return newDryRunReport("SetWithMeta", []DryRunEntry{{Key: key, RedisKey: redisKey, Size: len(data)}})
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return err
+	}
+
+	cmd := c.redis.B().Set().Key(redisKey).Value(string(data))
+	if ttl > 0 {
+		cmd.Ex(ttl)
+	}
+
+	err = c.redis.Do(ctx, cmd.Build()).Error()
+	if err != nil {
+		err = fmt.Errorf("redis: %w", err)
+	}
+	return err
+}
+
+// metaHeader returns a header storing the metadata, or an empty header if there
+// is no metadata.
+func metaHeader(meta map[string]string) header {
+	if len(meta) == 0 {
+		return header{}
+	}
+	return header{flags: flagMeta, meta: meta}
+}
+
+// GetMeta retrieves the metadata stored with the entry for the given key using
+// SetWithMeta. The value is neither decompressed nor unmarshalled. If the entry
+// was stored without metadata an empty map is returned.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+func (c *Cache) GetMeta(ctx context.Context, key string) (map[string]string, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make(rueidis.Commands, 0, 2)
+	if c.migration != nil {
+		cmds = append(cmds, c.redis.B().Get().Key(c.migration.key(redisKey)).Build())
+	}
+	cmds = append(cmds, c.redis.B().Get().Key(redisKey).Build())
+
+	for _, res := range c.redis.DoMulti(ctx, cmds...) {
+		data, err := res.AsBytes()
+		if errors.Is(err, rueidis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		h, _, _, err := c.parseValueHeader(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse header: %w", err)
+		}
+		if h.meta == nil {
+			return map[string]string{}, nil
+		}
+		return h.meta, nil
+	}
+	return nil, ErrKeyNotFound
+}
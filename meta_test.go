@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_SetWithMeta(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, LZ4())
+
+	meta := map[string]string{"source": "db", "schema": "2"}
+	assert.NoError(t, rdb.SetWithMeta(context.Background(), "key", "value", meta, 0))
+
+	got, err := rdb.GetMeta(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, meta, got)
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, "value", val)
+
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	got, err = rdb.GetMeta(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+
+	_, err = rdb.GetMeta(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestCache_SetWithMeta_MigrationMode(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMigrationMode(JSONEncoding(), MsgpackEncoding()))
+	rdb.CompleteMigration()
+
+	meta := map[string]string{"source": "db"}
+	assert.NoError(t, rdb.SetWithMeta(context.Background(), "key", "value", meta, 0))
+
+	got, err := rdb.GetMeta(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, meta, got)
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, "value", val)
+}
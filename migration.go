@@ -0,0 +1,338 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// migration holds the state of an in-progress migration from one Encoding to
+// another.
+type migration struct {
+	to        Encoding
+	suffix    string
+	dualWrite atomic.Bool
+}
+
+// key returns the key the target Encoding is stored under.
+func (m *migration) key(redisKey string) string {
+	return redisKey + m.suffix
+}
+
+// CompleteMigration stops dual-writing the source Encoding when the Cache is in
+// migration mode. Once complete, Set only writes the target Encoding and deletes
+// the source Encoding of the key, while Get continues to fall back to entries
+// written with the source Encoding that haven't been overwritten since.
+//
+// CompleteMigration should only be called once every reader of the Cache prefers
+// the target Encoding. CompleteMigration is a no-op if the Cache isn't in
+// migration mode.
+func (c *Cache) CompleteMigration() {
+	if c.migration != nil {
+		c.migration.dualWrite.Store(false)
+	}
+}
+
+// setMigrating writes the value with the target Encoding, and writes or deletes
+// the source Encoding, in a single pipeline. The metadata is stored with both
+// Encodings and may be nil.
+func (c *Cache) setMigrating(ctx context.Context, key, redisKey string, v any, ttl time.Duration, meta map[string]string) error {
+	cmds, err := c.setMigratingCmds(ctx, c.redis.B(), key, redisKey, v, ttl, meta)
+	if err != nil {
+		return err
+	}
+	for _, res := range c.redis.DoMulti(ctx, cmds...) {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// setMigratingCmds encodes the value and builds the commands writing it with the
+// target Encoding, and if still dual-writing the source Encoding. Once dual
+// writes stop the source Encoding is deleted instead.
+func (c *Cache) setMigratingCmds(ctx context.Context, b rueidis.Builder, key, redisKey string, v any, ttl time.Duration, meta map[string]string) (rueidis.Commands, error) {
+	if err := c.checkType(v); err != nil {
+		return nil, err
+	}
+	if err := c.validate(v); err != nil {
+		return nil, err
+	}
+
+	cmds := make(rueidis.Commands, 0, 2)
+
+	// The target Encoding bypasses the marshal hooks as those are bound to the
+	// source Encoding, but values are still compressed through the hooks.
+	data, err := c.migration.to.Marshaller(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshall value: %w", err)
+	}
+	c.capture(key, StageMarshalled, data)
+	if err := c.inspect(key, data); err != nil {
+		return nil, err
+	}
+	h := metaHeader(meta)
+	data, compressed, err := c.compress(c.migration.to.Name, data, &h)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		c.capture(key, StageCompressed, data)
+	}
+	data = c.prefixReadable(v, c.frame(data, compressed, h))
+	c.capture(key, StageStored, data)
+	cmd := b.Set().Key(c.migration.key(redisKey)).Value(string(data))
+	if ttl > 0 {
+		cmd.Ex(ttl)
+	}
+	cmds = append(cmds, cmd.Build())
+
+	if !c.migration.dualWrite.Load() {
+		// The source Encoding is no longer written, so it is removed to prevent
+		// reads falling back to a stale value once the target Encoding expires.
+		return append(cmds, b.Del().Key(redisKey).Build()), nil
+	}
+	data, err = c.encodeWithMeta(ctx, key, v, meta)
+	if err != nil {
+		return nil, err
+	}
+	cmd = b.Set().Key(redisKey).Value(string(data))
+	if ttl > 0 {
+		cmd.Ex(ttl)
+	}
+	return append(cmds, cmd.Build()), nil
+}
+
+// getMigrating reads both the target and source Encoding of a key in a single
+// pipeline, preferring the target Encoding.
+func (c *Cache) getMigrating(ctx context.Context, key string, redisKey string, v any) error {
+	data, unmarshall, err := c.fetchMigrating(ctx, key, redisKey)
+	if err != nil {
+		return err
+	}
+	return c.recordRead(key, c.decodeMigrating(ctx, key, data, v, unmarshall))
+}
+
+// fetchMigrating reads the value of the key as stored in Redis while in
+// migration mode, preferring the target Encoding, and returns the Unmarshaller
+// of the Encoding the value was stored with. If the key does not exist in either
+// Encoding ErrKeyNotFound is returned and the read is recorded as a miss, like
+// fetch.
+func (c *Cache) fetchMigrating(ctx context.Context, key string, redisKey string) ([]byte, Unmarshaller, error) {
+	results := c.redis.DoMulti(ctx,
+		c.redis.B().Get().Key(c.migration.key(redisKey)).Build(),
+		c.redis.B().Get().Key(redisKey).Build())
+
+	unmarshallers := []Unmarshaller{
+		c.migration.to.Unmarshaller,
+		c.hooksMixin.current.unmarshall,
+	}
+	for i, res := range results {
+		data, err := res.AsBytes()
+		if errors.Is(err, rueidis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("redis: %w", err)
+		}
+		return data, unmarshallers[i], nil
+	}
+
+	c.hooksMixin.miss(key)
+	return nil, nil, ErrKeyNotFound
+}
+
+// decodeMigrating decompresses and unmarshalls a value read while in migration
+// mode using the Unmarshaller of the Encoding the value was stored with.
+func (c *Cache) decodeMigrating(ctx context.Context, key string, data []byte, v any, unmarshall Unmarshaller) error {
+	raw := data
+	data, decompress, err := c.unframe(ctx, key, data, false)
+	if err != nil {
+		return c.poisoned(key, raw, err)
+	}
+	if decompress != nil {
+		data, err = decompress(data)
+		if err != nil {
+			return c.poisoned(key, raw, fmt.Errorf("decompress value: %w", err))
+		}
+	}
+	if err := unmarshall(data, v); err != nil {
+		return c.poisoned(key, raw, fmt.Errorf("unmarshall value: %w", err))
+	}
+	if err := c.schemaMigrations.apply(v); err != nil {
+		return fmt.Errorf("migrate value: %w", err)
+	}
+	if c.validateReads {
+		if err := c.validateRead(v); err != nil {
+			return fmt.Errorf("%w: %w", ErrKeyNotFound, err)
+		}
+	}
+	return nil
+}
+
+// deleteMigrating deletes the keys for both the source and target Encoding. The
+// target Encoding is stored under a different key which may hash to a different
+// slot, so it is deleted with a separate command in the same pipeline.
+func (c *Cache) deleteMigrating(ctx context.Context, redisKeys []string) error {
+	targetKeys := make([]string, len(redisKeys))
+	for i, key := range redisKeys {
+		targetKeys[i] = c.migration.key(key)
+	}
+	for _, res := range c.redis.DoMulti(ctx,
+		c.redis.B().Del().Key(redisKeys...).Build(),
+		c.redis.B().Del().Key(targetKeys...).Build()) {
+		if err := res.Error(); err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// mGetMigrating implements MGet while in migration mode. The target Encoding may
+// be stored in a different slot than the source Encoding, so both are read with
+// GET commands in a single pipeline rather than MGET.
+func mGetMigrating[R any](ctx context.Context, c *Cache, keys ...string) (MultiResult[R], error) {
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys)*2)
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds,
+			c.redis.B().Get().Key(c.migration.key(redisKey)).Build(),
+			c.redis.B().Get().Key(redisKey).Build())
+	}
+	return decodeMultiMigrating[R](ctx, c, keys, c.redis.DoMulti(ctx, cmds...))
+}
+
+// mGetExMigrating implements MGetEx while in migration mode. The TTL of both
+// Encodings is updated, and the target Encoding is preferred like Get.
+func mGetExMigrating[R any](ctx context.Context, c *Cache, ttl time.Duration, keys, redisKeys []string) (MultiResult[R], error) {
+	getex := func(redisKey string) rueidis.Completed {
+		cmd := c.redis.B().Getex().Key(redisKey)
+		if ttl > 0 {
+			return cmd.Ex(ttl).Build()
+		}
+		return cmd.Persist().Build()
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys)*2)
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, getex(c.migration.key(redisKey)), getex(redisKey))
+	}
+	return decodeMultiMigrating[R](ctx, c, keys, c.redis.DoMulti(ctx, cmds...))
+}
+
+// decodeMultiMigrating decodes the results of reading the target and source
+// Encoding of each key, in that order, preferring the target Encoding. Keys that
+// don't exist in either Encoding, or fail read validation, are left out of the
+// MultiResult.
+func decodeMultiMigrating[R any](ctx context.Context, c *Cache, keys []string, results []rueidis.RedisResult) (MultiResult[R], error) {
+	unmarshallers := []Unmarshaller{
+		c.migration.to.Unmarshaller,
+		c.hooksMixin.current.unmarshall,
+	}
+	resultMap := make(map[string]R)
+	for i, key := range keys {
+		found := false
+		for j, res := range results[i*2 : i*2+2] {
+			data, err := res.AsBytes()
+			if errors.Is(err, rueidis.Nil) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("redis: %w", err)
+			}
+			found = true
+			var val R
+			err = c.recordRead(key, c.decodeMigrating(ctx, key, data, &val, unmarshallers[j]))
+			if errors.Is(err, ErrKeyNotFound) {
+				// The value failed read validation and is treated as a miss
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			resultMap[key] = val
+			break
+		}
+		if !found {
+			c.hooksMixin.miss(key)
+		}
+	}
+	return resultMap, nil
+}
+
+// upsertMigrating implements Upsert while in migration mode. Both Encodings are
+// watched and read, preferring the target Encoding, and the value returned by the
+// UpsertCallback is written like setMigrating. As both keys are watched they must
+// hash to the same slot on Redis Cluster, otherwise an error wrapping ErrCrossSlot
+// is returned.
+func upsertMigrating[T any](ctx context.Context, c *Cache, key, redisKey string, val T, cb UpsertCallback[T], ttl time.Duration) error {
+	targetKey := c.migration.key(redisKey)
+	if c.cluster && !sameSlot(targetKey, redisKey) {
+		return fmt.Errorf("upsert: %w", ErrCrossSlot)
+	}
+
+	return c.redis.Dedicated(func(client rueidis.DedicatedClient) error {
+
+		// Watches both Encodings to detect changes during the transaction
+		err := client.Do(ctx, client.B().Watch().Key(targetKey, redisKey).Build()).Error()
+		if err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+
+		results := client.DoMulti(ctx,
+			client.B().Get().Key(targetKey).Build(),
+			client.B().Get().Key(redisKey).Build())
+		unmarshallers := []Unmarshaller{
+			c.migration.to.Unmarshaller,
+			c.hooksMixin.current.unmarshall,
+		}
+
+		found := false
+		var oldVal T
+		for i, res := range results {
+			data, err := res.AsBytes()
+			if errors.Is(err, rueidis.Nil) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("redis: %w", err)
+			}
+			err = c.decodeMigrating(ctx, key, data, &oldVal, unmarshallers[i])
+			if errors.Is(err, ErrKeyNotFound) {
+				oldVal = *new(T)
+				break
+			}
+			if err != nil {
+				return err
+			}
+			found = true
+			break
+		}
+
+		// Invoke the callback to determine the value that should be set
+		newVal := cb(found, oldVal, val)
+
+		cmds, err := c.setMigratingCmds(ctx, client.B(), key, redisKey, newVal, ttl, nil)
+		if err != nil {
+			return err
+		}
+		cmds = append(append(rueidis.Commands{client.B().Multi().Build()}, cmds...), client.B().Exec().Build())
+		results = client.DoMulti(ctx, cmds...)
+
+		// Check if Exec succeeded or if the transaction failed
+		if err := results[len(results)-1].Error(); err != nil {
+			return RetryableError{
+				retryable: true,
+				cause:     fmt.Errorf("redis transaction failed: %w", err),
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCache_MigrationMode(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	type person struct {
+		Name string
+		Age  int
+	}
+
+	// Entry written prior to the migration using JSON
+	legacy, _ := json.Marshal(person{Name: "Alice", Age: 30})
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("person:1").Value(string(legacy)).Build()).Error())
+
+	rdb := New(client, WithMigrationMode(JSONEncoding(), MsgpackEncoding()))
+
+	var p person
+	assert.NoError(t, rdb.Get(context.Background(), "person:1", &p))
+	assert.Equal(t, person{Name: "Alice", Age: 30}, p)
+
+	assert.NoError(t, rdb.Set(context.Background(), "person:2", person{Name: "Bob", Age: 40}, 0))
+
+	// Both encodings are written while dual-writing
+	raw, err := client.Do(context.Background(), client.B().Get().Key("person:2").Build()).AsBytes()
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(raw, &p))
+	assert.Equal(t, person{Name: "Bob", Age: 40}, p)
+
+	raw, err = client.Do(context.Background(), client.B().Get().Key("person:2:msgpack").Build()).AsBytes()
+	assert.NoError(t, err)
+	assert.NoError(t, msgpack.Unmarshal(raw, &p))
+	assert.Equal(t, person{Name: "Bob", Age: 40}, p)
+
+	rdb.CompleteMigration()
+	assert.NoError(t, rdb.Set(context.Background(), "person:3", person{Name: "Carl", Age: 50}, 0))
+
+	exists, err := client.Do(context.Background(), client.B().Exists().Key("person:3").Build()).AsInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+
+	assert.NoError(t, rdb.Get(context.Background(), "person:3", &p))
+	assert.Equal(t, person{Name: "Carl", Age: 50}, p)
+
+	assert.NoError(t, rdb.Delete(context.Background(), "person:1", "person:2", "person:3"))
+	keys, err := rdb.Keys(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	assert.ErrorIs(t, rdb.Get(context.Background(), "person:2", &p), ErrKeyNotFound)
+}
+
+func TestCache_MigrationMode_Multi(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	legacy, _ := json.Marshal("v1")
+	assert.NoError(t, client.Do(ctx, client.B().Set().Key("k").Value(string(legacy)).Build()).Error())
+
+	rdb := New(client, WithMigrationMode(JSONEncoding(), MsgpackEncoding()))
+	rdb.CompleteMigration()
+
+	res, err := MGet[string](ctx, rdb, "k", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"k": "v1"}, res)
+
+	// After completing the migration only the target Encoding is written, which
+	// must be preferred over the stale source Encoding
+	assert.NoError(t, rdb.Set(ctx, "k", "v2", 0))
+
+	res, err = MGet[string](ctx, rdb, "k", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"k": "v2"}, res)
+
+	values, err := MGetValues[string](ctx, rdb, "k", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v2"}, values)
+
+	res, err = MGetEx[string](ctx, rdb, time.Minute, "k", "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"k": "v2"}, res)
+	assert.Equal(t, time.Minute, server.TTL("k:msgpack"))
+	// The stale source Encoding was deleted by Set
+	assert.False(t, server.Exists("k"))
+
+	cb := func(found bool, oldValue string, newValue string) string {
+		assert.True(t, found)
+		assert.Equal(t, "v2", oldValue)
+		return oldValue + newValue
+	}
+	assert.NoError(t, Upsert[string](ctx, rdb, "k", "v3", cb, 0))
+
+	var val string
+	assert.NoError(t, rdb.Get(ctx, "k", &val))
+	assert.Equal(t, "v2v3", val)
+
+	keys, err := rdb.Keys(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k"}, keys)
+
+	keys, err = rdb.ScanKeys(ctx, "k*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k"}, keys)
+}
+
+func TestCache_MigrationMode_CompleteDeletesSource(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithMigrationMode(JSONEncoding(), MsgpackEncoding()))
+
+	assert.NoError(t, rdb.Set(ctx, "k", "v1", 0))
+	rdb.CompleteMigration()
+	assert.NoError(t, rdb.Set(ctx, "k", "v2", time.Minute))
+	assert.False(t, server.Exists("k"))
+
+	// Once the target Encoding expires the value written prior to the last Set
+	// must not be read from the source Encoding
+	server.FastForward(time.Minute)
+	var val string
+	assert.ErrorIs(t, rdb.Get(ctx, "k", &val), ErrKeyNotFound)
+	res, err := MGet[string](ctx, rdb, "k")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
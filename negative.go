@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// negativeCaching configures the TTL of tombstones written by GetOrSet when the
+// loader reports the key doesn't exist.
+type negativeCaching struct {
+	min        time.Duration
+	max        time.Duration
+	multiplier float64
+}
+
+// ttl returns how long a tombstone is valid for after the given number of
+// consecutive misses, starting from one. The TTL grows exponentially from min
+// with every consecutive miss and is capped at max.
+func (n negativeCaching) ttl(misses uint64) time.Duration {
+	ttl := float64(n.min) * math.Pow(n.multiplier, float64(misses-1))
+	if ttl >= float64(n.max) {
+		return n.max
+	}
+	return time.Duration(ttl)
+}
+
+// liveLua defines the Lua function live, which reports if a key exists and
+// doesn't hold a tombstone. Tombstones are recognized by the first three bytes
+// of their header, so the value itself isn't transferred to the script. Keys
+// holding other data types are always live.
+var liveLua = fmt.Sprintf(`
+local function live(key)
+	if redis.call('EXISTS', key) == 0 then
+		return false
+	end
+	local h = redis.pcall('GETRANGE', key, 0, 2)
+	if type(h) ~= 'string' or #h < 3 then
+		return true
+	end
+	return not (string.byte(h, 1) == %d and string.byte(h, 2) == %d and
+		math.floor(string.byte(h, 3) / %d) %% 2 == 1)
+end
+`, headerMagic, headerVersion, flagTombstone)
+
+// existsScript returns 1 if the key exists and doesn't hold a tombstone, and 0
+// otherwise. KEYS[1] is the key.
+var existsScript = rueidis.NewLuaScript(liveLua + `
+if live(KEYS[1]) then
+	return 1
+end
+return 0`)
+
+// setAbsentScript sets the key unless it exists and doesn't hold a tombstone, so
+// a tombstone is overwritten like a missing key. KEYS[1] is the key, ARGV[1] the
+// value, and ARGV[2] the TTL in milliseconds, or 0 to persist the value. Returns
+// 1 if the value was set and 0 otherwise.
+var setAbsentScript = rueidis.NewLuaScript(liveLua + `
+if live(KEYS[1]) then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1`)
+
+// tombstoneError is the error returned reading a tombstone. It wraps
+// ErrKeyNotFound so tombstones are misses to every read.
+type tombstoneError struct {
+	misses uint64
+	until  time.Time
+}
+
+func (e *tombstoneError) Error() string {
+	return fmt.Sprintf("%s: negatively cached after %d misses", ErrKeyNotFound, e.misses)
+}
+
+func (e *tombstoneError) Unwrap() error {
+	return ErrKeyNotFound
+}
+
+// setTombstone writes a tombstone for the key recording the number of
+// consecutive misses. The tombstone is valid for the TTL computed from the
+// misses, but is kept in Redis for up to the maximum TTL longer so the misses
+// are still counted if the key is missing again shortly after the tombstone
+// expires.
+func (c *Cache) setTombstone(ctx context.Context, key string, misses uint64) error {
+	defer c.serializeWrites(key)()
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	ttl := c.negative.ttl(misses)
+	h := header{
+		flags:    flagTombstone | flagUncompressed,
+		misses:   misses,
+		negUntil: c.clock.Now().Add(ttl),
+	}
+	data := h.appendTo(make([]byte, 0, h.len()), nil)
+	if c.dryRun {
+		return newDryRunReport("SetTombstone", []DryRunEntry{{Key: key, RedisKey: redisKey, Size: len(data)}})
+	}
+	if err := c.flushBuffered(ctx, redisKey); err != nil {
+		return err
+	}
+	cmd := c.redis.B().Set().Key(redisKey).Value(string(data)).Px(ttl + c.negative.max)
+	if err := c.redis.Do(ctx, cmd.Build()).Error(); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	return nil
+}
+
+// existing reports which of the keys exist in Redis. When negative caching is
+// enabled keys holding a tombstone are reported as missing. Each key is checked
+// with its own command so the keys may hash to different slots.
+func (c *Cache) existing(ctx context.Context, redisKeys []string) ([]bool, error) {
+	if len(redisKeys) == 0 {
+		return nil, nil
+	}
+	var results []rueidis.RedisResult
+	if c.negative != nil {
+		execs := make([]rueidis.LuaExec, 0, len(redisKeys))
+		for _, redisKey := range redisKeys {
+			execs = append(execs, rueidis.LuaExec{Keys: []string{redisKey}})
+		}
+		results = existsScript.ExecMulti(ctx, c.redis, execs...)
+	} else {
+		cmds := make(rueidis.Commands, 0, len(redisKeys))
+		for _, redisKey := range redisKeys {
+			cmds = append(cmds, c.redis.B().Exists().Key(redisKey).Build())
+		}
+		results = c.redis.DoMulti(ctx, cmds...)
+	}
+	exists := make([]bool, len(results))
+	for i, res := range results {
+		n, err := res.AsInt64()
+		if err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		exists[i] = n > 0
+	}
+	return exists, nil
+}
+
+// setAbsent sets the key with SET NX, or when negative caching is enabled with
+// setAbsentScript so tombstones are overwritten. It returns true if the value
+// was set.
+func (c *Cache) setAbsent(ctx context.Context, redisKey string, data []byte, ttl time.Duration) (bool, error) {
+	// A positive TTL under a millisecond is rounded up, as it would otherwise be
+	// sent as 0 which persists the key
+	if ttl > 0 && ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+	if c.negative != nil {
+		if ttl < 0 {
+			ttl = 0
+		}
+		set, err := setAbsentScript.Exec(ctx, c.redis, []string{redisKey},
+			[]string{string(data), fmt.Sprint(ttl.Milliseconds())}).AsInt64()
+		if err != nil {
+			return false, fmt.Errorf("redis: %w", err)
+		}
+		return set == 1, nil
+	}
+
+	cmd := c.redis.B().Set().Key(redisKey).Value(string(data)).Nx()
+	if ttl > 0 {
+		cmd.Px(ttl)
+	}
+	err := c.redis.Do(ctx, cmd.Build()).Error()
+	if errors.Is(err, rueidis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return true, nil
+}
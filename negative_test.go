@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegativeCaching_TTL(t *testing.T) {
+	n := negativeCaching{min: time.Second, max: 10 * time.Second, multiplier: 2}
+	assert.Equal(t, time.Second, n.ttl(1))
+	assert.Equal(t, 2*time.Second, n.ttl(2))
+	assert.Equal(t, 8*time.Second, n.ttl(4))
+	assert.Equal(t, 10*time.Second, n.ttl(5))
+	assert.Equal(t, 10*time.Second, n.ttl(1000))
+}
+
+func TestCache_GetOrSet_NegativeCaching(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	rdb := New(client, WithClock(clock), WithNegativeCaching(time.Second, 5*time.Second, 2))
+
+	var (
+		calls int
+		found bool
+	)
+	loader := func(ctx context.Context) (any, error) {
+		calls++
+		if !found {
+			return nil, fmt.Errorf("user 1: %w", ErrKeyNotFound)
+		}
+		return "value", nil
+	}
+
+	var v string
+	err := rdb.GetOrSet(ctx, "key", &v, time.Minute, loader)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 6*time.Second, server.TTL("key"))
+
+	// The tombstone is served without invoking the loader, and is a miss to
+	// other reads
+	err = rdb.GetOrSet(ctx, "key", &v, time.Minute, loader)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, rdb.Get(ctx, "key", &v), ErrKeyNotFound)
+	values, err := MGet[string](ctx, rdb, "key")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	// Consecutive misses grow the TTL up to the maximum
+	valid := time.Second
+	for i, ttl := range []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		clock.Advance(valid)
+		err = rdb.GetOrSet(ctx, "key", &v, time.Minute, loader)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Equal(t, i+2, calls)
+		assert.Equal(t, ttl+5*time.Second, server.TTL("key"))
+		valid = ttl
+	}
+
+	// The value replaces the tombstone once it exists
+	found = true
+	clock.Advance(5 * time.Second)
+	require.NoError(t, rdb.GetOrSet(ctx, "key", &v, time.Minute, loader))
+	assert.Equal(t, "value", v)
+	assert.Equal(t, time.Minute, server.TTL("key"))
+}
+
+func TestCache_GetOrSet_NegativeCachingDisabled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+
+	var v string
+	err := rdb.GetOrSet(ctx, "key", &v, time.Minute, func(ctx context.Context) (any, error) {
+		return nil, ErrKeyNotFound
+	})
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.False(t, server.Exists("key"))
+}
+
+func TestWithNegativeCaching_Invalid(t *testing.T) {
+	assert.Panics(t, func() { WithNegativeCaching(0, time.Second, 2) })
+	assert.Panics(t, func() { WithNegativeCaching(time.Second, time.Millisecond, 2) })
+	assert.Panics(t, func() { WithNegativeCaching(time.Second, time.Minute, 0.5) })
+}
+
+func TestCache_NegativeCaching_Tombstones(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithNegativeCaching(time.Second, 5*time.Second, 2))
+	loader := func(ctx context.Context) (any, error) {
+		return nil, ErrKeyNotFound
+	}
+
+	var v string
+	for _, key := range []string{"a", "b"} {
+		assert.ErrorIs(t, rdb.GetOrSet(ctx, key, &v, time.Minute, loader), ErrKeyNotFound)
+	}
+	require.NoError(t, rdb.Set(ctx, "c", "value", time.Minute))
+	require.NoError(t, client.Do(ctx, client.B().Hset().Key("h").FieldValue().FieldValue("f", "v").Build()).Error())
+
+	// Tombstones don't exist, while other data types still do
+	exists, err := rdb.Exists(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, exists)
+	multi, err := rdb.ExistsMulti(ctx, "a", "c", "h", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": false, "c": true, "h": true, "missing": false}, multi)
+
+	// Tombstones are overwritten like missing keys
+	set, err := rdb.SetIfAbsent(ctx, "a", "a", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, set)
+	assert.Equal(t, time.Minute, server.TTL("a"))
+	set, err = rdb.SetNX(ctx, "b", "b", 0)
+	require.NoError(t, err)
+	assert.True(t, set)
+	assert.Equal(t, time.Duration(0), server.TTL("b"))
+
+	require.NoError(t, rdb.Get(ctx, "a", &v))
+	assert.Equal(t, "a", v)
+	require.NoError(t, rdb.Get(ctx, "b", &v))
+	assert.Equal(t, "b", v)
+
+	// Live values aren't overwritten
+	set, err = rdb.SetIfAbsent(ctx, "c", "other", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, set)
+	set, err = rdb.SetNX(ctx, "a", "other", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, set)
+	require.NoError(t, rdb.Get(ctx, "c", &v))
+	assert.Equal(t, "value", v)
+}
+
+func TestCache_NegativeCaching_SetAbsentSubMillisecond(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	for _, rdb := range []*Cache{
+		New(client),
+		New(client, WithNegativeCaching(time.Second, 5*time.Second, 2)),
+	} {
+		set, err := rdb.SetNX(ctx, "key", "value", time.Microsecond)
+		require.NoError(t, err)
+		assert.True(t, set)
+		// The TTL is rounded up rather than persisting the key
+		assert.Equal(t, time.Millisecond, server.TTL("key"))
+		server.FlushAll()
+	}
+}
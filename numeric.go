@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+)
+
+// numericCoercion wraps the JSON Unmarshaller fn so numbers decoded into
+// dynamic destinations are int64 when integral and float64 otherwise. Values
+// decoded into any other destination are passed to fn unchanged.
+func numericCoercion(fn Unmarshaller) Unmarshaller {
+	return func(data []byte, v any) error {
+		switch dst := v.(type) {
+		case *any:
+			if err := decodeNumbers(data, dst); err != nil {
+				return err
+			}
+			*dst = coerceNumbers(*dst)
+		case *map[string]any:
+			if err := decodeNumbers(data, dst); err != nil {
+				return err
+			}
+			coerceNumbers(*dst)
+		case *[]any:
+			if err := decodeNumbers(data, dst); err != nil {
+				return err
+			}
+			coerceNumbers(*dst)
+		default:
+			return fn(data, v)
+		}
+		return nil
+	}
+}
+
+// decodeNumbers decodes the JSON in data into v like json.Unmarshal, except
+// numbers in dynamic values are decoded as json.Number.
+func decodeNumbers(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// coerceNumbers replaces every json.Number in val, traversing nested maps and
+// slices in place, with an int64 if the number is integral and fits in an
+// int64, or a float64 otherwise.
+func coerceNumbers(val any) any {
+	switch val := val.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			// Integral numbers in exponent notation such as 1e3
+			return int64(f)
+		}
+		return f
+	case map[string]any:
+		for k, v := range val {
+			val[k] = coerceNumbers(v)
+		}
+	case []any:
+		for i, v := range val {
+			val[i] = coerceNumbers(v)
+		}
+	}
+	return val
+}
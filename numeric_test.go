@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_WithNumericCoercion(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, JSON(), WithNumericCoercion())
+	require.NoError(t, rdb.Set(ctx, "key", map[string]any{
+		"count": 42,
+		"big":   int64(math.MaxInt64),
+		"ratio": 0.5,
+		"exp":   1e3,
+		"items": []any{1, 2.5, map[string]any{"id": 7}},
+		"name":  "gopher",
+	}, 0))
+
+	var m map[string]any
+	require.NoError(t, rdb.Get(ctx, "key", &m))
+	assert.Equal(t, int64(42), m["count"])
+	assert.Equal(t, int64(math.MaxInt64), m["big"])
+	assert.Equal(t, 0.5, m["ratio"])
+	assert.Equal(t, int64(1000), m["exp"])
+	assert.Equal(t, []any{int64(1), 2.5, map[string]any{"id": int64(7)}}, m["items"])
+	assert.Equal(t, "gopher", m["name"])
+
+	var v any
+	require.NoError(t, rdb.Get(ctx, "key", &v))
+	assert.Equal(t, int64(42), v.(map[string]any)["count"])
+
+	require.NoError(t, rdb.Set(ctx, "list", []int{1, 2}, 0))
+	var list []any
+	require.NoError(t, rdb.Get(ctx, "list", &list))
+	assert.Equal(t, []any{int64(1), int64(2)}, list)
+
+	// Typed destinations are decoded as usual
+	var s struct {
+		Count float64 `json:"count"`
+		Ratio any     `json:"ratio"`
+	}
+	require.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, 42.0, s.Count)
+	assert.Equal(t, 0.5, s.Ratio)
+
+	require.NoError(t, server.Set("invalid", `{"count": 1} trailing`))
+	assert.Error(t, rdb.Get(ctx, "invalid", &m))
+}
+
+func TestCache_WithNumericCoercion_Disabled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, JSON())
+	require.NoError(t, rdb.Set(ctx, "key", map[string]any{"count": 42}, 0))
+
+	var m map[string]any
+	require.NoError(t, rdb.Get(ctx, "key", &m))
+	assert.Equal(t, 42.0, m["count"])
+}
@@ -1,14 +1,20 @@
 package cache
 
 import (
+	"cmp"
+	stdgzip "compress/gzip"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"time"
 
 	"github.com/jkratz55/rueidis-cache/compression/brotli"
 	"github.com/jkratz55/rueidis-cache/compression/flate"
 	"github.com/jkratz55/rueidis-cache/compression/gzip"
 	"github.com/jkratz55/rueidis-cache/compression/lz4"
+	"github.com/jkratz55/rueidis-cache/compression/zstd"
 )
 
 // Option allows for the Cache behavior/configuration to be customized.
@@ -26,6 +32,7 @@ func Serialization(mar Marshaller, unmar Unmarshaller) Option {
 	return func(c *Cache) {
 		c.marshaller = mar
 		c.unmarshaller = unmar
+		c.serialization = "custom"
 	}
 }
 
@@ -41,7 +48,48 @@ func JSON() Option {
 	unmar := func(data []byte, v any) error {
 		return json.Unmarshal(data, v)
 	}
-	return Serialization(mar, unmar)
+	opt := Serialization(mar, unmar)
+	return func(c *Cache) {
+		opt(c)
+		c.serialization = "json"
+	}
+}
+
+// BareMsgpack configures the Cache to store values serialized with msgpack
+// exactly as marshalled, without a header or any other framing specific to this
+// package, so values can be exchanged with clients written in other languages
+// using plain msgpack libraries.
+//
+// Bare mode forfeits every feature that relies on the header of values, in
+// exchange for cross-language compatibility:
+//
+//   - Values are never compressed, as other clients couldn't tell if a value is
+//     compressed or with which Codec. Compression Options are ignored.
+//   - WithWriteTimestamps and WithMinFreshness have no effect, as the time values
+//     were written isn't stored.
+//   - SetWithMeta with metadata, SetSoftHard, and early expiration with
+//     WithEarlyExpiration return an error, as they store fields in the header.
+//   - Values are never parsed for a header, so values written by this package
+//     with a header, or compressed, can't be read in bare mode. Migrating
+//     between bare and framed values requires rewriting the values.
+func BareMsgpack() Option {
+	return func(c *Cache) {
+		c.marshaller = DefaultMarshaller()
+		c.unmarshaller = DefaultUnmarshaller()
+		c.serialization = "msgpack"
+		c.bare = true
+	}
+}
+
+// BareJSON configures the Cache to store values serialized with JSON exactly as
+// marshalled, without a header or any other framing specific to this package.
+// See BareMsgpack for the features forfeited by bare mode.
+func BareJSON() Option {
+	opt := JSON()
+	return func(c *Cache) {
+		opt(c)
+		c.bare = true
+	}
 }
 
 // Compression allows for the values to be flated and deflated to conserve bandwidth
@@ -66,12 +114,34 @@ func Flate() Option {
 
 // GZip configures the Cache to use gzip for compressing and decompressing values
 // stored in Redis. GZip uses a default configuration favoring compression size
-// over speed
+// over speed, use GZipLevel to configure the compression level.
 func GZip() Option {
 	codec := gzip.NewCodec(9) // Best Compression
 	return Compression(codec)
 }
 
+// GZipLevel configures the Cache to use gzip with the provided compression
+// level for compressing and decompressing values stored in Redis. GZip is
+// equivalent to GZipLevel(gzip.BestCompression). Writers and readers are pooled
+// and reused across operations.
+//
+// Values are stored as standard gzip streams, so other systems can read them
+// with any gzip decoder, as long as they also understand the serialization and
+// the Cache doesn't store a header with the value. A header is only stored when
+// a feature requiring it is enabled, such as WithWriteTimestamps, CompressWhen,
+// or size classes. Mixing Codecs on the same keyspace, for example while
+// switching from another Codec to gzip, requires the header recording the Codec
+// of each value, which is stored by size classes and Recompress.
+//
+// Providing a level outside gzip.BestSpeed to gzip.BestCompression panics.
+func GZipLevel(level int) Option {
+	if level < stdgzip.BestSpeed || level > stdgzip.BestCompression {
+		panic(fmt.Errorf("gzip level %d not in range [%d, %d], illegal use of api",
+			level, stdgzip.BestSpeed, stdgzip.BestCompression))
+	}
+	return Compression(gzip.NewCodec(level))
+}
+
 // LZ4 configures the Cache to use lz4 for compressing and decompressing values
 // stored in Redis.
 func LZ4() Option {
@@ -79,6 +149,20 @@ func LZ4() Option {
 	return Compression(codec)
 }
 
+// Zstd configures the Cache to use zstd for compressing and decompressing values
+// stored in Redis. zstd typically achieves a much better compression ratio than
+// LZ4 at a moderate CPU cost, which suits large JSON payloads. Levels range from
+// 1, favoring speed, to 22, favoring compression size, with 3 being the zstd
+// default.
+//
+// Values compressed with zstd are stored as zstd frames starting with the zstd
+// magic number, so a value isn't mistaken for another Codec's: decompressing it
+// with a different Codec fails rather than returning corrupt data.
+func Zstd(level int) Option {
+	codec := zstd.NewCodec(level)
+	return Compression(codec)
+}
+
 // Brotli configures the Cache to use Brotli for compressing and decompressing
 // values stored in Redis. The default Brotli configuration uses a balanced
 // approach between speed and compression level.
@@ -90,6 +174,178 @@ func Brotli() Option {
 	return Compression(codec)
 }
 
+// CompressWhen configures the Cache to only compress values when the predicate
+// returns true for the serialization used, avoiding spending CPU on data that
+// won't benefit from compression. The serialization is "msgpack", "json", the
+// Name of an Encoding, or "custom" for Marshallers configured with
+// Serialization.
+//
+// Values are stored with a small header recording if they were compressed, so
+// every instance of Cache sharing a Redis keyspace should be upgraded to a
+// version supporting the header before enabling CompressWhen. Values written
+// without the header are assumed to be compressed.
+func CompressWhen(predicate CompressionPredicate) Option {
+	if predicate == nil {
+		panic(fmt.Errorf("nil CompressionPredicate not permitted, illegal use of API"))
+	}
+	return func(c *Cache) {
+		c.compressWhen = predicate
+	}
+}
+
+// CompressOnlyWhenSmaller configures the Cache to store values uncompressed when
+// compressing them doesn't make them smaller, such as data that is already
+// compressed. The compressed and original sizes are compared for every value and
+// the smaller one is stored, so compression never increases storage at the cost
+// of retaining the original value while compressing.
+//
+// Values are stored with a small header recording if they were compressed, so
+// every instance of Cache sharing a Redis keyspace should be upgraded to a
+// version supporting the header before enabling CompressOnlyWhenSmaller.
+func CompressOnlyWhenSmaller() Option {
+	return func(c *Cache) {
+		c.compressSmaller = true
+	}
+}
+
+// CompressAbove configures the Cache to store values uncompressed when their
+// size once marshalled is below threshold bytes, as compressing tiny values
+// wastes CPU and often makes them larger. Values at or above the threshold are
+// compressed with the configured Codec. Unlike CompressOnlyWhenSmaller the
+// decision is made before compressing, so no CPU is spent on small values.
+//
+// Values are stored with a small header recording if they were compressed, so
+// reads decompress only values that were compressed. Every instance of Cache
+// sharing a Redis keyspace should be upgraded to a version supporting the header
+// before enabling CompressAbove. Values written without the header are assumed
+// to be compressed. CompressAbove composes with CompressWhen, which decides if a
+// value is compressed at all, and CompressOnlyWhenSmaller.
+//
+// Providing a threshold < 0 panics.
+func CompressAbove(threshold int) Option {
+	if threshold < 0 {
+		panic(fmt.Errorf("compression threshold must be >= 0, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.compressAbove = threshold
+	}
+}
+
+// StreamingCompression configures the Cache to marshal values directly into the
+// compressor instead of buffering the marshalled value before compressing it.
+// For very large values this roughly halves peak memory while writing, as the
+// uncompressed value is never held in memory in its entirety. The compressed
+// values are decompressed by Deflate like any other value, so reads are
+// unaffected. The context is checked each time the marshalled value is written
+// to the compressor, so writes of large values stop shortly after the context
+// is canceled.
+//
+// Streaming requires a Codec implementing StreamCodec, such as the gzip, flate,
+// and zstd Codecs, and msgpack serialization. Values are marshalled and
+// compressed with the buffered path, as if StreamingCompression wasn't
+// configured, when the Codec or serialization don't support streaming, when a
+// Hook is registered, since Hooks wrap the buffered Marshaller and compression,
+// or when an Option needing the marshalled value is configured, such as
+// CompressWhen rejecting the serialization, CompressOnlyWhenSmaller,
+// CompressAbove, WithSizeClassStrategy, CaptureHook, or
+// WithPreCompressInspector.
+func StreamingCompression() Option {
+	return func(c *Cache) {
+		c.streamingCompression = true
+	}
+}
+
+// WithCodecHeader configures the Cache to record the name of the Codec every
+// value is compressed with in the header of the value, so values remain readable
+// when the configured Codec changes, such as switching from LZ4 to zstd. Reads
+// decompress values with the Codec recorded in their header: the configured
+// Codec, a Codec registered with WithCodecs, or a Codec built into this package.
+// Values compressed with any other Codec fail to read with an error wrapping
+// ErrUnknownCodec rather than returning corrupt data. This allows rolling out a
+// new Codec without flushing the cache, as instances with the new Codec read
+// values written by instances with the old one, and vice versa.
+//
+// Values written without the Codec recorded are assumed to be compressed with the
+// configured Codec, so WithCodecHeader should be enabled on every instance of
+// Cache sharing a Redis keyspace before the Codec is changed. Every instance
+// should be upgraded to a version supporting the header before enabling it.
+func WithCodecHeader() Option {
+	return func(c *Cache) {
+		c.codecHeader = true
+	}
+}
+
+// WithCodecs registers Codecs values recorded as compressed with them can be
+// decompressed with, in addition to the configured Codec and the Codecs built
+// into this package. The name recorded for a Codec is the name of the built-in
+// Codec, the result of its Name method if it implements one, or its type name
+// otherwise. Registered Codecs take precedence over the built-in Codecs of the
+// same name, for example to decompress with a custom configuration.
+//
+// Codecs are recorded in the header of values written with WithCodecHeader,
+// WithSizeClassStrategy, or Recompress. Providing a nil Codec panics.
+func WithCodecs(codecs ...Codec) Option {
+	for _, codec := range codecs {
+		if codec == nil {
+			panic(fmt.Errorf("nil Codec not permitted, illegal use of api"))
+		}
+	}
+	return func(c *Cache) {
+		for _, codec := range codecs {
+			c.codecs.Store(codecName(codec), codec)
+		}
+	}
+}
+
+// WithSizeClassStrategy configures the Cache to choose how values are compressed
+// by their size once marshalled, rather than compressing every value with the
+// Codec configured with Compression. For example small values are often best
+// stored uncompressed, medium values compressed with a fast Codec such as LZ4,
+// and large values with a Codec with a better ratio such as Brotli.
+//
+// A value is compressed according to the rule with the smallest MaxSize it
+// fits in, and rules with a MaxSize <= 0 apply to values larger than every
+// other rule. Values that fit no rule are compressed with the configured Codec.
+// Only compression is chosen per size class, as the size of a value is only
+// known once it's serialized.
+//
+// The Codec of each value is recorded in its header, so values are decompressed
+// correctly regardless of the size class rules of the reader. Like Recompress,
+// the Codecs built into this package can be read by any instance of Cache
+// supporting the header, while other Codecs can only be read by a Cache
+// configured with them. Size classes compose with CompressWhen, which decides if
+// a value is compressed at all, and CompressOnlyWhenSmaller.
+//
+// Providing a rule with a nil Codec stores the values in the size class
+// uncompressed.
+func WithSizeClassStrategy(rules []SizeClassRule) Option {
+	sorted := slices.Clone(rules)
+	slices.SortStableFunc(sorted, func(a, b SizeClassRule) int {
+		switch {
+		case a.MaxSize <= 0 && b.MaxSize <= 0:
+			return 0
+		case a.MaxSize <= 0:
+			return 1
+		case b.MaxSize <= 0:
+			return -1
+		}
+		return cmp.Compare(a.MaxSize, b.MaxSize)
+	})
+	return func(c *Cache) {
+		c.sizeClasses = sorted
+		for _, rule := range sorted {
+			if rule.Codec == nil {
+				continue
+			}
+			// Codecs built into this package are always known to the reader
+			name := codecName(rule.Codec)
+			if _, ok := builtinCodec(name); !ok {
+				c.codecs.Store(name, rule.Codec)
+			}
+		}
+	}
+}
+
 // BatchMultiGets configures the Cache to use pipelining and split keys up into
 // multiple MGET commands for increased throughput and lower latency when dealing
 // with MGet operations with very large sets of keys.
@@ -111,3 +367,805 @@ func NearCache(ttl time.Duration) Option {
 		}
 	}
 }
+
+// NearCacheMode configures the tracking mode used with NearCache and restricts
+// near caching to keys starting with one of the prefixes. Reads of keys outside
+// the prefixes always go to Redis. When no prefixes are provided all keys are
+// cached locally.
+//
+// In OptIn mode, the default, Redis tracks every key the client reads which is
+// precise but consumes memory in Redis proportional to the number of keys and
+// clients. In Broadcast mode Redis tracks prefixes instead, using no memory per
+// key, but sends the client invalidations for every modified key matching the
+// prefixes, including keys it never read. Broadcast is typically the better
+// choice for wide keyspaces with a small number of hot prefixes. Broadcast mode
+// with no prefixes makes every client receive invalidations for every key.
+//
+// The prefixes are matched against keys as stored in Redis, including any
+// generation prefix or key compaction. The tracking mode is configured on the
+// rueidis client, and the client must be created with the options returned by
+// TrackingOptions for the same mode and prefixes. NearCacheMode has no effect
+// unless NearCache is also provided.
+func NearCacheMode(mode TrackingMode, prefixes ...string) Option {
+	return func(c *Cache) {
+		c.nearCacheMode = mode
+		c.nearCachePrefix = append([]string(nil), prefixes...)
+	}
+}
+
+// NearCacheMaxMemory records the maximum number of bytes the client side cache
+// used by NearCache may consume per connection.
+//
+// The client side cache is owned by the rueidis client, which keeps a cache for
+// each connection bounded by ClientOption.CacheSizeEachConn and evicts the least
+// recently used entries once the bound is reached. Since the Cache can't change
+// the configuration of the client it was created with, the client must be
+// created with CacheSizeEachConn set to the same number of bytes. rueidis holds a
+// connection per Redis node, so the total memory used is bounded by bytes times
+// the number of nodes. rueidis doesn't report evictions, entries evicted from the
+// client side cache are simply read from Redis on the next access.
+//
+//	client, err := rueidis.NewClient(rueidis.ClientOption{
+//		InitAddress:       []string{"127.0.0.1:6379"},
+//		CacheSizeEachConn: 32 << 20,
+//	})
+//	rdb := cache.New(client, cache.NearCache(time.Minute), cache.NearCacheMaxMemory(32<<20))
+//
+// Providing bytes <= 0 is a no-op and the rueidis default of
+// rueidis.DefaultCacheBytes is assumed.
+func NearCacheMaxMemory(bytes int) Option {
+	return func(c *Cache) {
+		if bytes > 0 {
+			c.nearCacheMaxMem = bytes
+		}
+	}
+}
+
+// NearCacheMaxValueSize configures the maximum size in bytes of a value, as stored
+// in Redis, that is cached in the client side cache used by NearCache. A few
+// large values can evict many small, frequently read values from the client side
+// cache, which is bounded by NearCacheMaxMemory.
+//
+// The size of a value is only known once it's read, so the first read of an
+// oversized value is still cached locally. From then on the key is remembered as
+// oversized and read with a normal GET, bypassing the client side cache, until a
+// read finds its value within the limit or the key no longer exists. Hooks
+// implementing NearCacheHook are notified of reads that bypass the client side
+// cache. Reads of multiple keys, such as MGet, bypass the client side cache if
+// any of the keys is oversized.
+//
+// Providing bytes <= 0 is a no-op and the size of values isn't limited.
+func NearCacheMaxValueSize(bytes int) Option {
+	return func(c *Cache) {
+		if bytes > 0 {
+			c.nearCacheMaxVal = bytes
+		}
+	}
+}
+
+// NearCacheWriteThrough configures Set to populate the near cache with the value
+// written, so reads of the key right after writing it are served locally instead
+// of missing the near cache, which benefits read-your-writes workloads.
+//
+// rueidis doesn't allow inserting into the client side cache directly, so once
+// the write succeeds the key is read back through the near cache, costing an
+// extra round trip on Set. This is best-effort: errors reading the key back are
+// ignored, and a concurrent write by another client may land between the write
+// and the read, in which case the newer value is cached. Server invalidation
+// remains the source of truth, any later modification of the key still
+// invalidates the local copy. Writes buffered by WithWriteBatching and writes
+// other than Set aren't written through.
+//
+// NearCacheWriteThrough has no effect unless NearCache is also provided. Keys
+// excluded from near caching by NearCacheMode or NearCacheMaxValueSize aren't
+// written through.
+func NearCacheWriteThrough() Option {
+	return func(c *Cache) {
+		c.nearWriteThrough = true
+	}
+}
+
+// WithGenerationBusting enables invalidating all entries in the Cache in O(1)
+// time. The Cache maintains a generation counter in Redis and prefixes every key
+// with the current generation. Calling BumpGeneration increments the generation
+// which instantly orphans all entries from previous generations.
+//
+// Orphaned entries are not deleted and remain in Redis until their TTL expires,
+// so generation busting should only be used when entries are set with a TTL.
+// The current generation is cached locally and refreshed from Redis at most every
+// DefaultGenerationRefresh.
+func WithGenerationBusting() Option {
+	return func(c *Cache) {
+		c.generation = newGeneration()
+	}
+}
+
+// WithMigrationMode configures the Cache to migrate the serialization of values
+// from one Encoding to another without downtime.
+//
+// While migrating, Set writes the value under the key using the source Encoding,
+// so readers that have not been migrated continue to work, and under the key
+// suffixed with the name of the target Encoding (key:name) using the target
+// Encoding. Get prefers the target Encoding and falls back to the source Encoding.
+// Delete removes both. Once all readers prefer the target Encoding, calling
+// CompleteMigration stops dual-writing the source Encoding.
+//
+// All other operations use the source Encoding and the key as is. The source
+// Encoding replaces any serialization configured with the Serialization Option.
+func WithMigrationMode(from, to Encoding) Option {
+	if from.Marshaller == nil || from.Unmarshaller == nil || to.Marshaller == nil || to.Unmarshaller == nil {
+		panic(fmt.Errorf("nil Marshaller and/or Unmarshaller not permitted, illegal use of api"))
+	}
+	if to.Name == "" {
+		panic(fmt.Errorf("target Encoding requires a name, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.marshaller = from.Marshaller
+		c.unmarshaller = from.Unmarshaller
+		c.serialization = from.Name
+		if from.Name == "" {
+			c.serialization = "custom"
+		}
+		c.migration = &migration{
+			to:     to,
+			suffix: ":" + to.Name,
+		}
+		c.migration.dualWrite.Store(true)
+	}
+}
+
+// WithValidator configures a Validator that is invoked on every value prior to
+// it being written to the cache. Values the Validator rejects are not written,
+// and the operation returns an error wrapping ErrValidation.
+//
+// The Validator runs before marshalling, so it composes with the configured
+// serialization, compression, and Hooks.
+func WithValidator(validator Validator) Option {
+	if validator == nil {
+		panic(fmt.Errorf("nil Validator not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.validator = validator
+	}
+}
+
+// WithPreCompressInspector configures a PreCompressInspector that is invoked on
+// every write with the key and the size of the marshalled value before it is
+// compressed. Returning a non-nil error aborts the write, and the operation
+// returns an error wrapping the error returned by the inspector. This allows
+// implementing policies such as rejecting oversized values for a family of keys,
+// or recording the size of values as custom metrics.
+//
+// The inspector runs after marshalling and before compression on the write path,
+// so it should be fast. For MSet and SwapSnapshot a rejected value aborts the
+// entire operation.
+func WithPreCompressInspector(inspector PreCompressInspector) Option {
+	if inspector == nil {
+		panic(fmt.Errorf("nil PreCompressInspector not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.inspector = inspector
+	}
+}
+
+// WithAllowedTypes restricts the types of values that can be written to the
+// Cache to the provided types, or pointers to them. Writing a value of any other
+// type returns an error wrapping ErrTypeNotAllowed without writing the value.
+// This is a guardrail against inadvertently caching huge or unsuitable types in
+// large codebases.
+//
+// The check is a map lookup on the type of the value and happens before the
+// Validator and marshalling. Calling WithAllowedTypes multiple times adds to the
+// allowed types.
+func WithAllowedTypes(types ...reflect.Type) Option {
+	return func(c *Cache) {
+		if c.allowedTypes == nil {
+			c.allowedTypes = make(map[reflect.Type]struct{}, len(types))
+		}
+		for _, t := range types {
+			c.allowedTypes[t] = struct{}{}
+		}
+	}
+}
+
+// WithReadValidation configures the Cache to also invoke the Validator on values
+// read from the cache. Invalid values are treated as a cache miss: Get returns
+// an error wrapping both ErrKeyNotFound and ErrValidation, and MGet excludes the
+// key from the results. Invalid values are reported to Hooks as misses.
+//
+// Values are read into a pointer provided by the caller, but the Validator is
+// invoked with the value the pointer points to, so a Validator reading into a
+// *T receives a T like when the T was written.
+//
+// WithReadValidation has no effect unless a Validator is configured using the
+// WithValidator Option.
+func WithReadValidation() Option {
+	return func(c *Cache) {
+		c.validateReads = true
+	}
+}
+
+// WithWriteTimestamps configures the Cache to store the time a value was written
+// in a small header alongside the value.
+//
+// Values written with a header can't be read by instances of Cache without the
+// header enabled, so every instance sharing a Redis keyspace should enable it
+// before it is relied on. Values written without a header remain readable.
+func WithWriteTimestamps() Option {
+	return func(c *Cache) {
+		c.writeTimestamps = true
+	}
+}
+
+// WithMinFreshness configures a cutoff for each key. Values for a key written
+// before the cutoff returned by fn are treated as a cache miss. Returning the
+// zero value for time.Time disables the cutoff for a key.
+//
+// WithMinFreshness enables WithWriteTimestamps. Values written without a
+// timestamp, such as values written before timestamps were enabled, are treated
+// as being written at the zero time and are considered stale for any non-zero
+// cutoff.
+//
+// fn is invoked on every read and should be fast.
+func WithMinFreshness(fn func(key string) time.Time) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil freshness func not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.writeTimestamps = true
+		c.minFreshness = fn
+	}
+}
+
+// DeleteStaleEntries configures the Cache to delete values that are stale
+// according to the cutoff configured with WithMinFreshness when they are read.
+// Deletion is best-effort, and a value is only deleted if it hasn't been
+// overwritten since it was read.
+//
+// DeleteStaleEntries has no effect unless WithMinFreshness is configured.
+func DeleteStaleEntries() Option {
+	return func(c *Cache) {
+		c.deleteStale = true
+	}
+}
+
+// WithKeyCompaction configures the Cache to store keys in the compact form
+// returned by the KeyCompactor, which can significantly reduce memory usage when
+// keys are long and structured. Key compaction is disabled by default.
+//
+// Key compaction has tradeoffs that should be considered before enabling it:
+//
+//   - Keys in Redis no longer match the keys used by the application, making
+//     debugging with redis-cli or other tools harder.
+//   - Patterns passed to ScanKeys and Scan are compacted like keys, so only
+//     literal segments are compacted and a pattern such as "user-*" won't match
+//     compacted segments.
+//   - Keys and ScanKeys must expand every key returned by SCAN.
+//   - Every instance sharing a Redis keyspace must use the same KeyCompactor, and
+//     changing it effectively invalidates the cache.
+func WithKeyCompaction(compactor KeyCompactor) Option {
+	if compactor == nil {
+		panic(fmt.Errorf("nil KeyCompactor not permitted, illegal use of api"))
+	}
+	return WithKeyTransforms(CompactKeys(compactor))
+}
+
+// WithKeyTransforms configures the Cache to transform keys with the provided
+// KeyTransforms before they are stored in Redis, such as PrefixKeys, HashTagKeys,
+// HashKeys, and CompactKeys. The transforms are composed into a single pipeline
+// applied in order to the key of every operation, so "user:1" transformed by
+// PrefixKeys("prod:") followed by HashTagKeys("users") is stored as
+// "{users}prod:user:1". Transforms provided by multiple options, including
+// WithKeyCompaction, are appended to the pipeline in the order the options are
+// provided. The generation prefix added by WithGenerationBusting is always
+// applied last.
+//
+// Keys returned by Keys, ScanKeys, and ScanValues are restored by reversing the
+// pipeline, and SCAN patterns are transformed like keys. If any transform is
+// irreversible, such as HashKeys, scanning returns an error wrapping
+// ErrIrreversibleKeys.
+//
+// Every instance sharing a Redis keyspace must use the same transforms, and
+// changing them effectively invalidates the cache. Providing a nil KeyTransform
+// will panic.
+func WithKeyTransforms(transforms ...KeyTransform) Option {
+	for _, t := range transforms {
+		if t == nil {
+			panic(fmt.Errorf("nil KeyTransform not permitted, illegal use of api"))
+		}
+	}
+	return func(c *Cache) {
+		c.keyTransforms = append(c.keyTransforms, transforms...)
+	}
+}
+
+// WithIdempotencyTTL configures how long idempotency keys processed by SetOnce
+// are remembered. The TTL should exceed the longest period a duplicate may be
+// redelivered. Providing a TTL <= 0 is a no-op.
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		if ttl > 0 {
+			c.idempotencyTTL = ttl
+		}
+	}
+}
+
+// WithPoisonHandler configures a PoisonHandler that is invoked whenever a value
+// read from Redis fails to decode, either because the header is malformed or the
+// value can't be decompressed or unmarshalled. The handler receives the raw bytes
+// as stored in Redis, allowing corrupt entries to be preserved for analysis, for
+// example by copying them to a dead-letter key or logging them.
+//
+// The handler is invoked synchronously on the read path and should be fast. The
+// read still returns the decoding error. Values treated as a miss, such as stale
+// values or values rejected by read validation, are not passed to the handler.
+func WithPoisonHandler(handler PoisonHandler) Option {
+	if handler == nil {
+		panic(fmt.Errorf("nil PoisonHandler not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.poisonHandler = handler
+	}
+}
+
+// WithComputeLockTTL configures how long the lock acquired by
+// GetOrComputeDistributed is held before it expires. The TTL should exceed the
+// longest time it takes to compute a value, but is also the longest time waiting
+// processes are blocked if the computing process crashes. Providing a TTL <= 0 is
+// a no-op.
+func WithComputeLockTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		if ttl > 0 {
+			c.computeLockTTL = ttl
+		}
+	}
+}
+
+// WithStreamChunkSize configures the number of bytes SetStream reads and
+// compresses at a time, and GetStream reads from Redis at a time. Larger chunks
+// compress better and require fewer round trips to Redis, while smaller chunks
+// use less memory. The default is DefaultStreamChunkSize. Providing a size <= 0
+// is a no-op.
+func WithStreamChunkSize(bytes int) Option {
+	return func(c *Cache) {
+		if bytes > 0 {
+			c.streamChunkSize = bytes
+		}
+	}
+}
+
+// WithMaxKeyLength configures the Cache to reject keys longer than n bytes with
+// an error wrapping ErrKeyTooLong before any command is sent to Redis. The length
+// is checked after key compaction and generation prefixes are applied, so the
+// length of the key stored in Redis is what's validated. Excessively long keys
+// degrade Redis performance and usually indicate a bug, such as a key built from
+// unbounded user input. Providing a length <= 0 uses DefaultMaxKeyLength.
+func WithMaxKeyLength(n int) Option {
+	return func(c *Cache) {
+		if n <= 0 {
+			n = DefaultMaxKeyLength
+		}
+		c.maxKeyLength = n
+	}
+}
+
+// WithContentHasher configures the ContentHasher used by Put to derive the key
+// of an entry from its content. The default, SHA256ContentHasher, is
+// collision-resistant, so distinct values are practically guaranteed to be
+// stored under distinct keys. A faster non-cryptographic hash such as xxhash
+// improves throughput, but a collision causes a value to overwrite a different
+// value stored under the same key, and collisions can be crafted by anyone
+// controlling the content. Only use a non-cryptographic hash for trusted content.
+// A custom ContentHasher also allows aligning keys with an external
+// content-addressing scheme.
+func WithContentHasher(hasher ContentHasher) Option {
+	if hasher == nil {
+		panic(fmt.Errorf("nil ContentHasher not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.contentHasher = hasher
+	}
+}
+
+// WithBucketing configures the BucketFunc used by SetBucketed, GetBucketed, and
+// DeleteBucketed to determine the hash a field of a bucket is stored in, such as
+// HashBuckets to spread a large bucket across multiple small hashes. By default
+// the bucket key is used as the key of the hash.
+func WithBucketing(fn BucketFunc) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil BucketFunc not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.bucketFunc = fn
+	}
+}
+
+// WithScanConcurrency configures the maximum number of batches of values
+// ScanValues fetches from Redis concurrently. The default is
+// DefaultScanConcurrency. Providing a value <= 0 is a no-op.
+func WithScanConcurrency(n int) Option {
+	return func(c *Cache) {
+		if n > 0 {
+			c.scanConcurrency = n
+		}
+	}
+}
+
+// WithEarlyExpiration enables probabilistic early expiration of values computed
+// by GetOrComputeDistributed using the XFetch algorithm. The time it took to
+// compute a value is stored alongside it, and reads treat the value as expired
+// ahead of its TTL with a probability that increases as the value approaches its
+// expiry and with the time it took to compute. This spreads out refreshes of
+// popular keys so a single caller recomputes the value before it expires, while
+// other callers keep using the cached value.
+//
+// beta scales how early values expire, 1.0 is a reasonable default while values
+// greater than 1.0 favor earlier refreshes. Providing a beta <= 0 is a no-op.
+//
+// The time it took to compute the value is stored in the header of the value, so
+// every instance of Cache sharing a Redis keyspace should be upgraded to a
+// version supporting it before enabling early expiration.
+func WithEarlyExpiration(beta float64) Option {
+	return func(c *Cache) {
+		if beta > 0 {
+			c.earlyExpiration = beta
+		}
+	}
+}
+
+// WithZeroTTLPolicy configures how set-like operations treat a ttl value <= 0.
+// By default such entries are persisted indefinitely, see ZeroTTLNoExpiry. Teams
+// that consider a missing TTL a bug can use ZeroTTLDefault to apply a default TTL
+// instead, or ZeroTTLReject to fail such writes with ErrNoTTL.
+func WithZeroTTLPolicy(policy ZeroTTLPolicy) Option {
+	return func(c *Cache) {
+		c.zeroTTLPolicy = policy
+	}
+}
+
+// WithClock configures the Clock the Cache uses to read the current time. By
+// default the system clock is used. This is primarily useful in tests to control
+// time-dependent behavior such as write timestamps, minimum freshness, early
+// expiration and refreshing the generation.
+//
+// Providing a nil Clock will panic.
+func WithClock(clock Clock) Option {
+	if clock == nil {
+		panic(fmt.Errorf("nil Clock not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.clock = clock
+	}
+}
+
+// WithTags configures tags that are added to the context of every command the
+// Cache sends to Redis, alongside tags added to the context with
+// ContextWithTags, which take precedence. This allows instrumentation of the
+// Redis client, such as cacheotel.InstrumentClient, to attribute commands to the
+// Cache that issued them when multiple Caches share a client. The tags are also
+// available from Config so instrumentation of the Cache records the same
+// dimensions.
+func WithTags(tags map[string]string) Option {
+	return func(c *Cache) {
+		c.tags = maps.Clone(tags)
+	}
+}
+
+// CaptureHook configures the Cache to invoke fn with the bytes of values after
+// each stage of encoding and decoding, such as after marshalling, after
+// compression, and as stored in or read from Redis. This gives visibility into
+// the transformations applied to values when diagnosing why a value fails to
+// decode, without inspecting Redis directly. When CaptureHook isn't configured
+// values aren't captured at no cost.
+//
+// The captured bytes contain the values stored in the cache, which may include
+// sensitive data. CaptureHook is intended for debugging and tests, and captured
+// bytes shouldn't be logged or exported in production.
+//
+// Providing a nil CaptureFunc will panic.
+func CaptureHook(fn CaptureFunc) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil CaptureFunc not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.captureFn = fn
+	}
+}
+
+// WithAuditLogger configures the Cache to invoke fn with an AuditEntry for each
+// key mutated with Set, MSet, and Delete, including mutations that fail. Entries
+// report the operation, key, size of the stored value, time, and the actor
+// carried by the context with ContextWithActor, but never the value itself.
+//
+// Providing a nil AuditLogger will panic.
+func WithAuditLogger(fn AuditLogger) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil AuditLogger not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.auditFn = fn
+	}
+}
+
+// WithReadCoalescing configures the Cache to collapse concurrent reads of the same
+// key into a single Redis round trip. While a read of a key is in flight, other
+// reads of the key wait for it and share its result, which reduces redundant
+// network traffic for hot keys without a near cache.
+//
+// Only the value as stored in Redis is shared. Each caller decompresses and
+// unmarshalls the value into its own destination, so callers never share
+// memory. Reads of multiple keys, such as MGet, and reads while migrating between
+// Encodings aren't coalesced.
+//
+// The shared read uses the context of the caller that started it, so if that
+// context is canceled every caller waiting on the read receives the error.
+// Callers waiting on a read stop waiting once their own context is done.
+func WithReadCoalescing() Option {
+	return func(c *Cache) {
+		c.readFlights = newReadGroup()
+	}
+}
+
+// WithDryRun configures the Cache to report mutations instead of performing
+// them, which is useful to verify what a migration script or backfill would do
+// against a production Redis before running it for real. Reads are unaffected.
+//
+// The following methods honor dry run, returning a *DryRunReport matching
+// ErrDryRun as the error instead of writing to Redis: Set, SetIfAbsent, SetNX,
+// SetIfPresent, SetWithMeta, SetSoftHard, MSet, MSetWithResult, MSetWithTTL,
+// Delete, DeleteWithResult, Flush and FlushAsync. The report includes the keys,
+// the size of each encoded value and the number of keys that would have been
+// affected. Functions built on these methods, such as GetOrSet, Memoize, Write
+// and WarmFromLoader, see the report as an error returned by the underlying
+// call. When negative caching is enabled with WithNegativeCaching, a miss
+// reported by the loader of GetOrSet returns the report of the tombstone it
+// would have written, with the Operation "SetTombstone".
+//
+// Mutating methods that can't report what they would have done return an error
+// wrapping ErrDryRunUnsupported without writing to Redis. These are Upsert,
+// GetAndUpdateTTL, MGetEx, Expire, Persist, ExtendTTL, SetOnce,
+// SetVersionedTime, DeleteVersionedTime, SwapSnapshot, BumpGeneration,
+// Recompress, SetStream, HSet, SetBucketed, DeleteBucketed, SInterStore,
+// SUnionStore and SDiffStore.
+//
+// Locks and rate limiters only coordinate callers rather than store entries,
+// so they ignore dry run and use Redis as usual.
+func WithDryRun() Option {
+	return func(c *Cache) {
+		c.dryRun = true
+	}
+}
+
+// WithNumericCoercion configures a Cache using the JSON serialization to decode
+// numbers in values read into dynamic destinations, *any, *map[string]any, and
+// *[]any, as int64 when the number is integral and float64 otherwise, instead
+// of always as float64. This avoids integers silently becoming float64, and
+// losing precision beyond 2^53, when reading values without a fixed shape.
+// Numbers decoded into structs and other typed destinations are unaffected.
+//
+// Coercion happens in the Unmarshaller beneath any Hooks, so it is transparent
+// to callers. Values read into dynamic destinations are decoded with a
+// json.Decoder and every nested map and slice is traversed afterwards, which
+// allocates more and costs noticeably more CPU than json.Unmarshal for large
+// values. WithNumericCoercion has no effect with other serializations, such as
+// msgpack, which already preserve integer types.
+func WithNumericCoercion() Option {
+	return func(c *Cache) {
+		c.coercion = true
+	}
+}
+
+// WithKeySeparator configures the separator used by Cache.Key to join the parts
+// of composite keys, which defaults to DefaultKeySeparator. The separator is a
+// single character so escaped separators can't be mistaken for separators.
+// Backslash is reserved as the escape character and panics if provided.
+func WithKeySeparator(sep rune) Option {
+	if sep == keyEscape {
+		panic(fmt.Errorf("backslash key separator not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.keySeparator = sep
+	}
+}
+
+// WithWriteSerialization configures the Cache to serialize writes of the same
+// key within the process. Without it two goroutines writing the same key race,
+// and which write Redis applies last is undefined. With it each write holds an
+// in-process lock for the key until Redis acknowledges it, so the write that
+// acquired the lock last is deterministically the one left in Redis.
+//
+// Set, SetIfAbsent, SetNX, SetIfPresent, MSet, MSetWithResult, MSetWithTTL,
+// Delete, and DeleteWithResult are serialized, including the functions built on them
+// such as Memoize and Write. Batch writes lock every key of the batch.
+//
+// Locks are striped by the hash of the key across the provided number of
+// stripes, or DefaultWriteStripes if stripes is <= 0, so unrelated keys sharing
+// a stripe are serialized as well. Serialization adds contention and holds a
+// lock for a round trip to Redis, so it should only be enabled by write-heavy
+// applications that need defined ordering.
+//
+// Serialization is in-process only. Writes from other processes or instances
+// sharing Redis still race with the writes of this Cache. Use conditional
+// writes such as SetVersionedTime to order writes across processes.
+func WithWriteSerialization(stripes int) Option {
+	return func(c *Cache) {
+		c.writeLocks = newWriteStripes(stripes)
+	}
+}
+
+// WithReadablePrefix configures the Cache to store a short plaintext prefix
+// returned by fn, such as the name of the type of the value, followed by a '|'
+// delimiter before the serialized and compressed value, so values can be
+// identified in redis-cli without decompressing them. The prefix is stripped when
+// reading before the value is decoded.
+//
+//	rdb := cache.New(client, cache.WithReadablePrefix(func(v any) string {
+//		return fmt.Sprintf("%T", v)
+//	}))
+//
+// Prefixes are limited to 64 bytes and longer prefixes are truncated. Characters
+// other than printable ASCII, the delimiter and double quotes are replaced with
+// an underscore. If fn returns an empty string the value is stored without a
+// prefix. Every value stored costs the length of its prefix plus one byte for the
+// delimiter in Redis and on the network.
+//
+// Values written before the prefix was configured are still read. Readers must
+// be configured with WithReadablePrefix to read prefixed values, the function
+// they are configured with doesn't need to match the writers'. The prefix isn't
+// stored when the Cache stores bare values configured with BareMsgpack or
+// BareJSON.
+//
+// Providing a nil function panics.
+func WithReadablePrefix(fn func(v any) string) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil readable prefix function not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.readablePrefix = fn
+	}
+}
+
+// WithNegativeCaching enables caching that keys don't exist in the source of
+// truth. When the loader of GetOrSet returns an error wrapping ErrKeyNotFound a
+// tombstone is stored for the key, and until the tombstone expires GetOrSet
+// returns an error wrapping ErrKeyNotFound without invoking the loader. Other
+// reads, such as Get and MGet, treat tombstones as misses, Exists and
+// ExistsMulti report keys holding a tombstone as missing, and SetIfAbsent and
+// SetNX overwrite tombstones.
+//
+// The tombstone records the number of consecutive misses of the key and is
+// valid for min after the first miss. Every consecutive miss multiplies the TTL
+// by multiplier up to max, so keys that are persistently missing are loaded less
+// and less often while keys missing only briefly are loaded again quickly. Once
+// the loader returns a value it replaces the tombstone, resetting the count.
+// Tombstones are kept in Redis for up to max after they expire so consecutive
+// misses are counted, and a miss after that starts again from min.
+//
+// Negative caching has no effect when the Cache stores bare values configured
+// with BareMsgpack or BareJSON, or while a migration is in progress.
+//
+// Providing a min <= 0, a max < min, or a multiplier < 1 panics.
+func WithNegativeCaching(min, max time.Duration, multiplier float64) Option {
+	if min <= 0 || max < min || multiplier < 1 {
+		panic(fmt.Errorf("negative caching requires 0 < min <= max and multiplier >= 1, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.negative = &negativeCaching{
+			min:        min,
+			max:        max,
+			multiplier: multiplier,
+		}
+	}
+}
+
+// WithRetry configures the Cache to retry commands sent to Redis that fail with
+// an error retryable reports as retryable, according to the RetryPolicy. Only
+// the commands of a pipeline that failed are retried. Commands aren't retried
+// once the context of the operation is done.
+//
+// If retryable is nil DefaultRetryable is used, which retries cluster
+// redirections, LOADING, TRYAGAIN and CLUSTERDOWN errors and network timeouts,
+// but never errors that will never succeed such as OOM or WRONGTYPE. The
+// predicates IsClusterRedirect, IsLoading, IsTryAgain and IsTimeout can be
+// combined to retry a subset of those. Retries are performed in addition to any
+// retries of the rueidis client, which retries read-only commands on network
+// and LOADING errors unless ClientOption.DisableRetry is set.
+//
+// A policy with MaxAttempts <= 1 is a no-op.
+func WithRetry(policy RetryPolicy, retryable func(err error) bool) Option {
+	return func(c *Cache) {
+		if policy.MaxAttempts <= 1 {
+			return
+		}
+		if retryable == nil {
+			retryable = DefaultRetryable
+		}
+		c.retry = &retryHook{policy: policy, retryable: retryable}
+	}
+}
+
+// WithWriteBatching configures the Cache to buffer writes made with Set and
+// flush them to Redis in a single pipeline once maxEntries writes are buffered
+// or maxDelay has elapsed, whichever comes first. This greatly reduces the number
+// of round trips for write heavy workloads at the cost of writes being applied to
+// Redis asynchronously.
+//
+// Buffered writes are applied in the order they were made. Writes that fail
+// with a connection error are kept in the buffer and retried on the next flush,
+// so each write is applied at least once, but an error writing to Redis is logged
+// rather than returned from Set. While Redis is unavailable the buffer grows
+// without bound. Writes rejected by Redis are dropped and logged rather than
+// retried. The TTL of a buffered write starts when Set is called: reads stop
+// seeing the write once its TTL elapses, and it is written to Redis with the TTL
+// remaining, or dropped if none remains.
+//
+// Get, GetWithSource, MGet, and MGetValues see buffered writes, while other reads
+// only see writes once they are flushed. Every other mutation of a key with a
+// buffered write, such as Delete, MSet, SetIfAbsent, Expire, or Upsert, flushes
+// the buffer first, so writes to a key are applied in the order they were made
+// and a buffered write never overwrites a later mutation. Set bypasses the buffer
+// while the Cache is in migration mode.
+//
+// Close must be called to stop the background flusher and flush any remaining
+// writes before the application exits, otherwise buffered writes are lost.
+//
+// Providing maxEntries <= 0 or maxDelay <= 0 is a no-op.
+func WithWriteBatching(maxEntries int, maxDelay time.Duration) Option {
+	return func(c *Cache) {
+		if maxEntries > 0 && maxDelay > 0 {
+			c.writeBatch = newWriteBatcher(maxEntries, maxDelay)
+		}
+	}
+}
+
+// WithFormatSniffing configures the Cache to detect the serialization of values
+// from their leading bytes and decode them with the detected serialization. This
+// allows reading values written by other tools or earlier versions of this
+// package using a different serialization than the one configured.
+//
+// Only values stored without a header are sniffed, and only JSON and msgpack can
+// be detected. JSON objects, arrays, and strings, and msgpack values starting with
+// a byte >= 0x80 such as maps, arrays, and strings are detected. Any other value,
+// including JSON numbers, booleans, and null, and single byte msgpack values, is
+// decoded using the configured serialization. Other formats such as protobuf
+// can't be reliably detected and are never sniffed.
+//
+// Sniffing is heuristic and can misfire. A custom serialization producing values
+// that look like JSON or msgpack will have those values decoded as JSON or
+// msgpack. When sniffing, values that fail to decompress are assumed to be
+// uncompressed rather than treated as an error.
+func WithFormatSniffing() Option {
+	return func(c *Cache) {
+		c.formatSniffing = true
+	}
+}
+
+// OnHit registers a callback that is invoked with the key of every read that
+// results in a cache hit. OnHit is intended for lightweight analytics without the
+// need for full metrics infrastructure.
+//
+// The callback is invoked asynchronously from a single background goroutine so
+// the read path is never blocked. Events are buffered in a channel holding
+// DefaultEventBuffer events, and events are dropped when the buffer is full, so
+// the callback must not block and may not observe every hit under load. Close
+// stops dispatching events.
+func OnHit(fn func(key string)) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil OnHit callback not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.onHit = fn
+	}
+}
+
+// OnMiss registers a callback that is invoked with the key of every read that
+// results in a cache miss. Like OnHit, the callback is invoked asynchronously,
+// must not block, and events may be dropped under load.
+func OnMiss(fn func(key string)) Option {
+	if fn == nil {
+		panic(fmt.Errorf("nil OnMiss callback not permitted, illegal use of api"))
+	}
+	return func(c *Cache) {
+		c.onMiss = fn
+	}
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// pagedMeta is the metadata of a collection stored with SetPaged.
+type pagedMeta struct {
+	Pages    int
+	Items    int
+	PageSize int
+}
+
+// pageKey returns the key page n of the collection stored at key is stored at.
+func pageKey(key string, n int) string {
+	return key + ":page:" + strconv.Itoa(n)
+}
+
+// pageMetaKey returns the key the metadata of the collection stored at key is
+// stored at.
+func pageMetaKey(key string) string {
+	return key + ":meta"
+}
+
+// SetPaged stores a large collection as pages of at most pageSize items, so
+// pages can be read individually with GetPage without transferring and decoding
+// the entire collection. Page n is stored at key:page:n, starting from zero, and
+// the number of pages and items at key:meta. Every page and the metadata are
+// written in a single pipeline with the provided ttl. If the ttl value is <= 0
+// the keys will be persisted indefinitely.
+//
+// If a collection was already stored at key the pages beyond the end of the new
+// collection are deleted once the new collection is written. Overwriting isn't
+// atomic, so a concurrent reader may observe pages of both collections.
+//
+// On Redis Cluster the key must contain a hash tag, such as {users}:all, so the
+// pages and the metadata hash to the same slot, otherwise an error wrapping
+// ErrCrossSlot is returned. Providing a pageSize < 1 returns an error.
+func SetPaged[T any](ctx context.Context, c *Cache, key string, items []T, pageSize int, ttl time.Duration) error {
+	if pageSize < 1 {
+		return fmt.Errorf("paged collection requires page size >= 1")
+	}
+	meta := pagedMeta{
+		Pages:    (len(items) + pageSize - 1) / pageSize,
+		Items:    len(items),
+		PageSize: pageSize,
+	}
+	if c.cluster && !sameSlot(pageKey(key, 0), pageMetaKey(key)) {
+		return fmt.Errorf("paged collection: %w", ErrCrossSlot)
+	}
+
+	var old pagedMeta
+	if err := c.Get(ctx, pageMetaKey(key), &old); err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	entries := make(map[string]any, meta.Pages+1)
+	for n := 0; n < meta.Pages; n++ {
+		entries[pageKey(key, n)] = items[n*pageSize : min((n+1)*pageSize, len(items))]
+	}
+	entries[pageMetaKey(key)] = meta
+	if err := setMany(ctx, c, entries, ttl); err != nil {
+		return err
+	}
+
+	if old.Pages > meta.Pages {
+		stale := make([]string, 0, old.Pages-meta.Pages)
+		for n := meta.Pages; n < old.Pages; n++ {
+			stale = append(stale, pageKey(key, n))
+		}
+		if err := c.Delete(ctx, stale...); err != nil {
+			return fmt.Errorf("delete stale pages: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetPage retrieves page n, starting from zero, of a collection stored with
+// SetPaged and unmarshalls its items into dst.
+//
+// If the collection or the page does not exist ErrKeyNotFound will be returned
+// as the error value.
+func GetPage[T any](ctx context.Context, c *Cache, key string, page int, dst *[]T) error {
+	if page < 0 {
+		return fmt.Errorf("page %d: %w", page, ErrKeyNotFound)
+	}
+	return c.Get(ctx, pageKey(key, page), dst)
+}
+
+// GetAllPaged retrieves every page of a collection stored with SetPaged and
+// returns the items of the collection in order. The pages are read with a
+// single MGET.
+//
+// If the collection does not exist ErrKeyNotFound will be returned as the error
+// value. If any page is missing, for example because it was evicted, an error
+// wrapping ErrKeyNotFound is returned as well rather than a partial collection.
+func GetAllPaged[T any](ctx context.Context, c *Cache, key string) ([]T, error) {
+	var meta pagedMeta
+	if err := c.Get(ctx, pageMetaKey(key), &meta); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, meta.Pages)
+	for n := range keys {
+		keys[n] = pageKey(key, n)
+	}
+	pages, err := MGet[[]T](ctx, c, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]T, 0, meta.Items)
+	for n, pk := range keys {
+		page, ok := pages[pk]
+		if !ok {
+			return nil, fmt.Errorf("page %d of %d: %w", n, meta.Pages, ErrKeyNotFound)
+		}
+		items = append(items, page...)
+	}
+	return items, nil
+}
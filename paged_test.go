@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPaged(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+
+	items := make([]int, 25)
+	for i := range items {
+		items[i] = i
+	}
+	require.NoError(t, SetPaged(ctx, rdb, "list", items, 10, time.Minute))
+	for _, key := range []string{"list:page:0", "list:page:1", "list:page:2", "list:meta"} {
+		assert.True(t, server.Exists(key), key)
+		assert.Equal(t, time.Minute, server.TTL(key), key)
+	}
+	assert.False(t, server.Exists("list:page:3"))
+
+	var page []int
+	require.NoError(t, GetPage(ctx, rdb, "list", 2, &page))
+	assert.Equal(t, []int{20, 21, 22, 23, 24}, page)
+	assert.ErrorIs(t, GetPage(ctx, rdb, "list", 3, &page), ErrKeyNotFound)
+	assert.ErrorIs(t, GetPage(ctx, rdb, "list", -1, &page), ErrKeyNotFound)
+
+	all, err := GetAllPaged[int](ctx, rdb, "list")
+	require.NoError(t, err)
+	assert.Equal(t, items, all)
+
+	// Overwriting with a smaller collection removes the stale pages
+	require.NoError(t, SetPaged(ctx, rdb, "list", items[:5], 10, time.Minute))
+	assert.False(t, server.Exists("list:page:1"))
+	assert.False(t, server.Exists("list:page:2"))
+	all, err = GetAllPaged[int](ctx, rdb, "list")
+	require.NoError(t, err)
+	assert.Equal(t, items[:5], all)
+
+	require.NoError(t, SetPaged(ctx, rdb, "list", []int{}, 10, time.Minute))
+	assert.False(t, server.Exists("list:page:0"))
+	all, err = GetAllPaged[int](ctx, rdb, "list")
+	require.NoError(t, err)
+	assert.Empty(t, all)
+
+	assert.Error(t, SetPaged(ctx, rdb, "list", items, 0, time.Minute))
+}
+
+func TestGetAllPaged_Missing(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+
+	_, err := GetAllPaged[string](ctx, rdb, "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, SetPaged(ctx, rdb, "list", []string{"a", "b", "c"}, 1, 0))
+	server.Del("list:page:1")
+	_, err = GetAllPaged[string](ctx, rdb, "list")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
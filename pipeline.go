@@ -0,0 +1,155 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// encode marshals and compresses the value of the key for storage in Redis.
+//
+// The context is checked for cancellation before each stage so a cancelled
+// operation doesn't spend CPU on work that will be discarded. Marshallers and
+// Codecs don't accept a context, so a stage that has started always runs to
+// completion, except when streaming into a StreamCodec, which stops at the next
+// write once the context is done.
+func (c *Cache) encode(ctx context.Context, key string, v any) ([]byte, error) {
+	return c.encodeWithMeta(ctx, key, v, nil)
+}
+
+// encodeWithMeta is like encode but stores the metadata in the header of the
+// value if it isn't empty.
+func (c *Cache) encodeWithMeta(ctx context.Context, key string, v any, meta map[string]string) ([]byte, error) {
+	return c.encodeWithHeader(ctx, key, v, metaHeader(meta))
+}
+
+// encodeWithHeader is like encode but stores the optional fields of the provided
+// header in the header of the value.
+func (c *Cache) encodeWithHeader(ctx context.Context, key string, v any, h header) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.bare && h.flags != 0 {
+		return nil, errBareHeader
+	}
+	if err := c.checkType(v); err != nil {
+		return nil, err
+	}
+	if err := c.validate(v); err != nil {
+		return nil, err
+	}
+	if codec, ok := c.streamCodec(); ok {
+		data, err := marshalCompressed(ctx, codec, v)
+		if err != nil {
+			return nil, err
+		}
+		c.recordCodec(&h, "")
+		return c.prefixReadable(v, c.frame(data, true, h)), nil
+	}
+	data, err := c.hooksMixin.current.marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshall value: %w", err)
+	}
+	c.capture(key, StageMarshalled, data)
+	if err := c.inspect(key, data); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, compressed, err := c.compress(c.serialization, data, &h)
+	if err != nil {
+		return nil, err
+	}
+	if compressed {
+		c.capture(key, StageCompressed, data)
+	}
+	data = c.prefixReadable(v, c.frame(data, compressed, h))
+	c.capture(key, StageStored, data)
+	return data, nil
+}
+
+// decode decompresses and unmarshalls a value retrieved from Redis for the given
+// key into v.
+//
+// Like encode, the context is checked for cancellation before each stage. If
+// read validation is enabled and the value is invalid, or the value is older
+// than the configured minimum freshness, the error returned wraps ErrKeyNotFound.
+func (c *Cache) decode(ctx context.Context, key string, data []byte, v any) error {
+	return c.decodeValue(ctx, key, data, v, c.deleteStale)
+}
+
+// recordRead records the read of a key found in Redis as a hit, or as a miss if its
+// value was rejected while decoding, such as failing read validation or being
+// stale, and returns the error decoding the value.
+func (c *Cache) recordRead(key string, err error) error {
+	if errors.Is(err, ErrKeyNotFound) {
+		c.hooksMixin.miss(key)
+	} else {
+		c.hooksMixin.hit(key)
+	}
+	return err
+}
+
+// decodeValue is like decode but allows the caller to control if stale values
+// are deleted.
+func (c *Cache) decodeValue(ctx context.Context, key string, data []byte, v any, deleteStale bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	raw := data
+	c.capture(key, StageRead, raw)
+	data, decompress, err := c.unframe(ctx, key, data, deleteStale)
+	if err != nil {
+		return c.poisoned(key, raw, err)
+	}
+	data, err = c.decompress(key, raw, data, decompress)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.unmarshall(key, raw, data, v)
+}
+
+// decompress decompresses the payload of a value retrieved from Redis with the
+// function returned by unframe, if any.
+func (c *Cache) decompress(key string, raw, data []byte, decompress CompressionHook) ([]byte, error) {
+	if decompress == nil {
+		return data, nil
+	}
+	decompressed, err := decompress(data)
+	// Values written by other tools may not be compressed, so when sniffing a
+	// value that fails to decompress is assumed to be uncompressed.
+	if err != nil {
+		if c.sniffable(raw) {
+			return data, nil
+		}
+		return nil, c.poisoned(key, raw, fmt.Errorf("decompress value: %w", err))
+	}
+	c.capture(key, StageDecompressed, decompressed)
+	return decompressed, nil
+}
+
+// unmarshall unmarshalls the decompressed payload of a value retrieved from Redis
+// into v, applying schema migrations and read validation.
+func (c *Cache) unmarshall(key string, raw, data []byte, v any) error {
+	unmarshall := c.hooksMixin.current.unmarshall
+	if c.sniffable(raw) {
+		unmarshall = c.sniffedUnmarshaller(data)
+	}
+	if err := unmarshall(data, v); err != nil {
+		return c.poisoned(key, raw, fmt.Errorf("unmarshall value to type %T: %w", v, err))
+	}
+	if err := c.schemaMigrations.apply(v); err != nil {
+		return fmt.Errorf("migrate value: %w", err)
+	}
+	if c.validateReads {
+		if err := c.validateRead(v); err != nil {
+			// Invalid data stored in Redis is treated as if the key doesn't exist
+			return fmt.Errorf("%w: %w", ErrKeyNotFound, err)
+		}
+	}
+	return nil
+}
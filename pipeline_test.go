@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingCodec struct {
+	flated   int
+	deflated int
+}
+
+func (c *countingCodec) Flate(data []byte) ([]byte, error) {
+	c.flated++
+	return data, nil
+}
+
+func (c *countingCodec) Deflate(data []byte) ([]byte, error) {
+	c.deflated++
+	return data, nil
+}
+
+func TestCache_EncodeCancelled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	marshalled := 0
+	marshaller := func(v any) ([]byte, error) {
+		marshalled++
+		// Simulate the context being cancelled while marshalling a large value
+		cancel()
+		return DefaultMarshaller()(v)
+	}
+	codec := &countingCodec{}
+	rdb := New(client, Serialization(marshaller, DefaultUnmarshaller()), Compression(codec))
+
+	err := rdb.Set(ctx, "key", "value", 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, marshalled)
+	assert.Equal(t, 0, codec.flated)
+
+	err = rdb.Set(ctx, "key", "value", 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, marshalled)
+
+	exists, err := client.Do(context.Background(), client.B().Exists().Key("key").Build()).AsInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+}
+
+func TestCache_DecodeCancelled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	codec := &countingCodec{}
+	rdb := New(client, Compression(codec))
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var s string
+	err := rdb.decode(ctx, "key", []byte("value"), &s)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, codec.deflated)
+}
+
+func TestCache_CompressWhen(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	codec := &countingCodec{}
+	compressMsgpack := true
+	rdb := New(client, Compression(codec), CompressWhen(func(serialization string) bool {
+		assert.Equal(t, "msgpack", serialization)
+		return compressMsgpack
+	}))
+
+	var s string
+	assert.NoError(t, rdb.Set(ctx, "compressed", "value", 0))
+	assert.NoError(t, rdb.Get(ctx, "compressed", &s))
+	assert.Equal(t, "value", s)
+	assert.Equal(t, 1, codec.flated)
+	assert.Equal(t, 1, codec.deflated)
+
+	compressMsgpack = false
+	assert.NoError(t, rdb.Set(ctx, "uncompressed", "value", 0))
+	assert.NoError(t, rdb.Get(ctx, "uncompressed", &s))
+	assert.Equal(t, "value", s)
+	assert.Equal(t, 1, codec.flated)
+	assert.Equal(t, 1, codec.deflated)
+
+	// Values written before the predicate was configured don't have a header and
+	// are assumed to be compressed
+	legacy := New(client, Compression(codec))
+	assert.NoError(t, legacy.Set(ctx, "legacy", "value", 0))
+	assert.NoError(t, rdb.Get(ctx, "legacy", &s))
+	assert.Equal(t, "value", s)
+	assert.Equal(t, 2, codec.deflated)
+}
+
+func TestCache_CompressOnlyWhenSmaller(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, GZip(), CompressOnlyWhenSmaller())
+
+	// GZip adds more overhead than it saves for short values
+	var s string
+	assert.NoError(t, rdb.Set(ctx, "short", "value", 0))
+	raw, err := server.Get("short")
+	assert.NoError(t, err)
+	h, _, ok, err := parseHeader([]byte(raw))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotZero(t, h.flags&flagUncompressed)
+	assert.NoError(t, rdb.Get(ctx, "short", &s))
+	assert.Equal(t, "value", s)
+
+	long := strings.Repeat("value", 1000)
+	assert.NoError(t, rdb.Set(ctx, "long", long, 0))
+	raw, err = server.Get("long")
+	assert.NoError(t, err)
+	h, _, ok, err = parseHeader([]byte(raw))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, h.flags&flagUncompressed)
+	assert.Less(t, len(raw), len(long))
+	assert.NoError(t, rdb.Get(ctx, "long", &s))
+	assert.Equal(t, long, s)
+}
+
+func TestCache_CompressAbove(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	codec := &countingCodec{}
+	rdb := New(client, JSON(), Compression(codec), CompressAbove(64))
+
+	// Values below the threshold aren't compressed at all
+	var s string
+	assert.NoError(t, rdb.Set(ctx, "short", "value", 0))
+	raw, err := server.Get("short")
+	assert.NoError(t, err)
+	h, payload, ok, err := parseHeader([]byte(raw))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotZero(t, h.flags&flagUncompressed)
+	assert.Equal(t, `"value"`, string(payload))
+	assert.Equal(t, 0, codec.flated)
+	assert.NoError(t, rdb.Get(ctx, "short", &s))
+	assert.Equal(t, "value", s)
+	assert.Equal(t, 0, codec.deflated)
+
+	long := strings.Repeat("value", 100)
+	assert.NoError(t, rdb.Set(ctx, "long", long, 0))
+	raw, err = server.Get("long")
+	assert.NoError(t, err)
+	h, _, ok, err = parseHeader([]byte(raw))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, h.flags&flagUncompressed)
+	assert.Equal(t, 1, codec.flated)
+	assert.NoError(t, rdb.Get(ctx, "long", &s))
+	assert.Equal(t, long, s)
+	assert.Equal(t, 1, codec.deflated)
+
+	assert.Panics(t, func() { CompressAbove(-1) })
+}
+
+func TestCache_GZipLevel(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	value := strings.Repeat("compressible ", 100)
+	rdb := New(client, JSON(), GZipLevel(gzip.BestSpeed))
+	assert.Equal(t, "gzip", rdb.Config().Compression)
+	assert.NoError(t, rdb.Set(ctx, "key", value, 0))
+
+	var val string
+	assert.NoError(t, rdb.Get(ctx, "key", &val))
+	assert.Equal(t, value, val)
+
+	// Values are standard gzip streams readable by other systems
+	raw, _ := server.Get("key")
+	reader, err := gzip.NewReader(strings.NewReader(raw))
+	assert.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, `"`+value+`"`, string(data))
+
+	assert.Panics(t, func() { GZipLevel(gzip.NoCompression) })
+	assert.Panics(t, func() { GZipLevel(gzip.BestCompression + 1) })
+}
@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"errors"
+)
+
+// PoisonHandler is a function type that is invoked with the raw bytes stored in
+// Redis for a key when the value can't be decoded. The error describes why the
+// value couldn't be decoded.
+type PoisonHandler func(key string, raw []byte, err error)
+
+// poisoned invokes the PoisonHandler, if one is configured, for a value that
+// failed to decode and returns err. Errors signalling a miss, such as stale or
+// invalid values, are not treated as poison.
+func (c *Cache) poisoned(key string, raw []byte, err error) error {
+	if c.poisonHandler != nil && !errors.Is(err, ErrKeyNotFound) {
+		c.poisonHandler(key, raw, err)
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_WithPoisonHandler(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	var (
+		poisonKey string
+		poisonRaw []byte
+		poisonErr error
+	)
+	rdb := New(client, LZ4(), WithPoisonHandler(func(key string, raw []byte, err error) {
+		poisonKey = key
+		poisonRaw = raw
+		poisonErr = err
+	}))
+
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("corrupt").Value("garbage").Build()).Error())
+
+	var val string
+	err := rdb.Get(context.Background(), "corrupt", &val)
+	assert.Error(t, err)
+	assert.Equal(t, "corrupt", poisonKey)
+	assert.Equal(t, []byte("garbage"), poisonRaw)
+	assert.Equal(t, err, poisonErr)
+
+	poisonKey = ""
+	_, err = MGet[string](context.Background(), rdb, "corrupt")
+	assert.Error(t, err)
+	assert.Equal(t, "corrupt", poisonKey)
+
+	// Values that decode successfully and misses are not poison
+	poisonKey = ""
+	assert.NoError(t, rdb.Set(context.Background(), "key", "value", 0))
+	assert.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.ErrorIs(t, rdb.Get(context.Background(), "missing", &val), ErrKeyNotFound)
+	assert.Empty(t, poisonKey)
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// rateLimitPrefix is the prefix of the keys used to track requests by
+// RateLimiter.
+const rateLimitPrefix = "rueidis-cache:ratelimit:"
+
+// fixedWindowScript counts a request in the current window, starting a new
+// window if one isn't in progress. Returns the number of requests in the window
+// including this one.
+//
+// KEYS[1] is the counter of the window. ARGV[1] is the window in milliseconds.
+var fixedWindowScript = rueidis.NewLuaScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`)
+
+// slidingWindowScript removes the requests that fell out of the window and
+// records the request if fewer than the limit remain. Returns whether the
+// request was allowed and the number of requests remaining in the window.
+//
+// KEYS[1] is the sorted set of requests scored by time. ARGV[1] is the current
+// time and ARGV[2] the window, both in milliseconds, ARGV[3] the limit, and
+// ARGV[4] a unique member identifying the request.
+var slidingWindowScript = rueidis.NewLuaScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+if count >= limit then
+	return {0, 0}
+end
+redis.call('ZADD', KEYS[1], now, ARGV[4])
+redis.call('PEXPIRE', KEYS[1], window)
+return {1, limit - count - 1}`)
+
+// RateLimitAlgorithm is the algorithm a RateLimiter uses to count requests.
+type RateLimitAlgorithm uint8
+
+const (
+	// FixedWindow counts requests in a window that starts with the first request
+	// and resets once the window elapses. It uses a single counter per key, so it
+	// is cheap in both memory and CPU, but allows bursts of up to twice the limit
+	// across the boundary of two windows.
+	FixedWindow RateLimitAlgorithm = iota
+
+	// SlidingWindow counts the requests made in the window preceding each
+	// request, so the limit is never exceeded within any window. It records each
+	// allowed request in a sorted set, using memory proportional to the limit per
+	// key and more CPU than FixedWindow. Requests are timestamped with the Clock
+	// of the Cache, so the clocks of every instance sharing the limits must be
+	// reasonably synchronized.
+	SlidingWindow
+)
+
+// RateLimiter limits the rate of requests per key using counters stored in
+// Redis, so the limits are shared by every instance of an application.
+//
+// RateLimiter keys are stored as is, without generation prefixes or key
+// compaction, so BumpGeneration doesn't reset the limits.
+type RateLimiter struct {
+	cache     *Cache
+	algorithm RateLimitAlgorithm
+}
+
+// NewRateLimiter creates a RateLimiter backed by the Redis client of the Cache
+// using the provided algorithm. See FixedWindow and SlidingWindow for their
+// tradeoffs.
+func NewRateLimiter(c *Cache, algorithm RateLimitAlgorithm) *RateLimiter {
+	if c == nil {
+		panic(fmt.Errorf("nil Cache not permitted, illegal use of api"))
+	}
+	return &RateLimiter{
+		cache:     c,
+		algorithm: algorithm,
+	}
+}
+
+// Allow reports if a request for the key is allowed given at most limit requests
+// are permitted per window, and how many requests remain in the current window.
+// Requests that aren't allowed don't count against later windows.
+//
+// Limits and windows are tracked per key, so calls for the same key should use
+// the same limit and window. Windows are tracked with millisecond precision.
+// Providing a limit < 1 or a window < 1ms returns an error.
+func (rl *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, remaining int, err error) {
+	if limit < 1 {
+		return false, 0, fmt.Errorf("rate limit requires limit >= 1")
+	}
+	if window < time.Millisecond {
+		return false, 0, fmt.Errorf("rate limit requires window >= 1ms")
+	}
+
+	c := rl.cache
+	redisKey := rateLimitPrefix + key
+	if rl.algorithm == SlidingWindow {
+		member, err := lockToken()
+		if err != nil {
+			return false, 0, err
+		}
+		res, err := slidingWindowScript.Exec(ctx, c.redis, []string{redisKey}, []string{
+			fmt.Sprint(c.clock.Now().UnixMilli()),
+			fmt.Sprint(window.Milliseconds()),
+			fmt.Sprint(limit),
+			member,
+		}).AsIntSlice()
+		if err != nil {
+			return false, 0, fmt.Errorf("redis: %w", err)
+		}
+		return res[0] == 1, int(res[1]), nil
+	}
+
+	count, err := fixedWindowScript.Exec(ctx, c.redis, []string{redisKey}, []string{
+		fmt.Sprint(window.Milliseconds()),
+	}).AsInt64()
+	if err != nil {
+		return false, 0, fmt.Errorf("redis: %w", err)
+	}
+	if count > int64(limit) {
+		return false, 0, nil
+	}
+	return true, limit - int(count), nil
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_FixedWindow(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rl := NewRateLimiter(New(client), FixedWindow)
+	for i := 2; i >= 0; i-- {
+		allowed, remaining, err := rl.Allow(context.Background(), "user:1", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, i, remaining)
+	}
+	allowed, remaining, err := rl.Allow(context.Background(), "user:1", 3, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 0, remaining)
+
+	// Limits are tracked per key
+	allowed, _, err = rl.Allow(context.Background(), "user:2", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	server.FastForward(time.Minute)
+	allowed, remaining, err = rl.Allow(context.Background(), "user:1", 3, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 2, remaining)
+}
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	clock := &fakeClock{now: time.Now()}
+	rl := NewRateLimiter(New(client, WithClock(clock)), SlidingWindow)
+
+	allow := func() (bool, int) {
+		allowed, remaining, err := rl.Allow(context.Background(), "user:1", 2, time.Minute)
+		require.NoError(t, err)
+		return allowed, remaining
+	}
+
+	allowed, remaining := allow()
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining)
+
+	clock.Advance(30 * time.Second)
+	allowed, remaining = allow()
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+	allowed, _ = allow()
+	assert.False(t, allowed)
+
+	// Only the first request has fallen out of the window
+	clock.Advance(31 * time.Second)
+	allowed, remaining = allow()
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+	allowed, _ = allow()
+	assert.False(t, allowed)
+}
+
+func TestRateLimiter_Invalid(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rl := NewRateLimiter(New(client), FixedWindow)
+	_, _, err := rl.Allow(context.Background(), "user:1", 0, time.Minute)
+	assert.Error(t, err)
+	_, _, err = rl.Allow(context.Background(), "user:1", 1, time.Microsecond)
+	assert.Error(t, err)
+
+	assert.Panics(t, func() {
+		NewRateLimiter(nil, FixedWindow)
+	})
+}
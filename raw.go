@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/rueidis"
+)
+
+// MGetRaw retrieves the values of the keys exactly as stored in Redis, including
+// the header and compression, without decompressing or unmarshalling them. It is
+// intended for tools replicating or migrating entries between environments
+// that must preserve the stored representation.
+//
+// The keys are mapped to the keys stored in Redis like any other operation, but
+// the returned map is keyed by the keys as provided. Keys that don't exist are
+// absent from the map. The keys are read with a GET per key in a single
+// pipeline, so the keys may hash to different slots on Redis Cluster. Values
+// with a write buffered by WithWriteBatching are returned from the write buffer.
+// The near cache is never used.
+func (c *Cache) MGetRaw(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make(rueidis.Commands, 0, len(keys))
+	pending := make([]string, 0, len(keys))
+	for i, redisKey := range redisKeys {
+		if data, ok := c.buffered(redisKey); ok {
+			values[keys[i]] = data
+			continue
+		}
+		cmds = append(cmds, c.redis.B().Get().Key(redisKey).Build())
+		pending = append(pending, keys[i])
+	}
+	for i, res := range c.redis.DoMulti(ctx, cmds...) {
+		data, err := res.AsBytes()
+		if errors.Is(err, rueidis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("redis: %w", err)
+		}
+		values[pending[i]] = data
+	}
+	return values, nil
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_MGetRaw(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, LZ4(), WithKeyTransforms(PrefixKeys("app:")))
+	require.NoError(t, rdb.Set(ctx, "key1", "value1", 0))
+	require.NoError(t, rdb.Set(ctx, "key2", "value2", 0))
+
+	values, err := rdb.MGetRaw(ctx, []string{"key1", "key2", "missing"})
+	require.NoError(t, err)
+	require.Len(t, values, 2)
+	for _, key := range []string{"key1", "key2"} {
+		stored, err := server.Get("app:" + key)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(stored), values[key])
+	}
+
+	// The values are replicated verbatim
+	other := New(client, LZ4(), WithKeyTransforms(PrefixKeys("replica:")))
+	for key, data := range values {
+		require.NoError(t, server.Set("replica:"+key, string(data)))
+	}
+	var v string
+	require.NoError(t, other.Get(ctx, "key1", &v))
+	assert.Equal(t, "value1", v)
+
+	values, err = rdb.MGetRaw(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
+
+func TestCache_MGetRaw_WriteBatching(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, WithWriteBatching(100, time.Hour))
+	defer rdb.Close(ctx)
+	require.NoError(t, rdb.Set(ctx, "key", "value", 0))
+
+	values, err := rdb.MGetRaw(ctx, []string{"key"})
+	require.NoError(t, err)
+	assert.Contains(t, values, "key")
+	assert.False(t, server.Exists("key"))
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"bytes"
+)
+
+const (
+	// readableDelimiter separates the readable prefix of a value from the value as
+	// encoded by the Cache.
+	readableDelimiter byte = '|'
+
+	// maxReadablePrefix is the maximum length of a readable prefix, longer
+	// prefixes are truncated.
+	maxReadablePrefix = 64
+)
+
+// readablePrefixByte reports if b may appear in a readable prefix. Prefixes are
+// restricted to printable ASCII excluding the delimiter and double quotes, so a
+// value without a prefix can't be mistaken for one: values with a header or
+// serialized with msgpack start with a byte outside printable ASCII, unless the
+// entire value is a single byte, and the delimiter can only appear in JSON within
+// a string.
+func readablePrefixByte(b byte) bool {
+	return b >= ' ' && b <= '~' && b != readableDelimiter && b != '"'
+}
+
+// prefixReadable prepends the readable prefix of v and the delimiter to the value
+// as stored in Redis. Characters not permitted in a prefix are replaced with an
+// underscore. Values are returned as is if no prefix is configured, the Cache
+// stores bare values, or the prefix is empty.
+func (c *Cache) prefixReadable(v any, data []byte) []byte {
+	if c.readablePrefix == nil || c.bare {
+		return data
+	}
+	prefix := c.readablePrefix(v)
+	if prefix == "" {
+		return data
+	}
+	if len(prefix) > maxReadablePrefix {
+		prefix = prefix[:maxReadablePrefix]
+	}
+	out := make([]byte, 0, len(prefix)+1+len(data))
+	for i := 0; i < len(prefix); i++ {
+		b := prefix[i]
+		if !readablePrefixByte(b) {
+			b = '_'
+		}
+		out = append(out, b)
+	}
+	out = append(out, readableDelimiter)
+	return append(out, data...)
+}
+
+// splitReadable splits a value as stored in Redis into its readable prefix,
+// including the delimiter, and the value as encoded by the Cache. Values without
+// a prefix, such as values written before WithReadablePrefix was configured, are
+// returned as is with an empty prefix.
+func (c *Cache) splitReadable(data []byte) (prefix, rest []byte) {
+	if c.readablePrefix == nil || c.bare {
+		return nil, data
+	}
+	i := bytes.IndexByte(data[:min(len(data), maxReadablePrefix+1)], readableDelimiter)
+	if i < 1 {
+		return nil, data
+	}
+	for _, b := range data[:i] {
+		if !readablePrefixByte(b) {
+			return nil, data
+		}
+	}
+	return data[:i+1], data[i+1:]
+}
+
+// parseValueHeader is like parseHeader but strips the readable prefix of the
+// value first.
+func (c *Cache) parseValueHeader(data []byte) (h header, payload []byte, ok bool, err error) {
+	_, data = c.splitReadable(data)
+	return parseHeader(data)
+}
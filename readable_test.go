@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jkratz55/rueidis-cache/compression/brotli"
+)
+
+func typePrefix(v any) string {
+	return fmt.Sprintf("%T", v)
+}
+
+func TestWithReadablePrefix(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	value := strings.Repeat("compressible ", 100)
+	rdb := New(client, LZ4(), WithWriteTimestamps(), WithReadablePrefix(typePrefix))
+	require.NoError(t, rdb.Set(ctx, "key", value, time.Minute))
+
+	raw, _ := server.Get("key")
+	assert.True(t, strings.HasPrefix(raw, "string|"))
+	assert.Less(t, len(raw), len(value))
+
+	var val string
+	require.NoError(t, rdb.Get(ctx, "key", &val))
+	assert.Equal(t, value, val)
+
+	// Readers don't need the same prefix function
+	other := New(client, LZ4(), WithReadablePrefix(func(any) string { return "" }))
+	val = ""
+	require.NoError(t, other.Get(ctx, "key", &val))
+	assert.Equal(t, value, val)
+
+	// The prefix is kept when values are recompressed
+	n, err := rdb.Recompress(ctx, []string{"key"}, brotli.NewCodec(11))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	raw, _ = server.Get("key")
+	assert.True(t, strings.HasPrefix(raw, "string|"))
+	val = ""
+	require.NoError(t, rdb.Get(ctx, "key", &val))
+	assert.Equal(t, value, val)
+}
+
+func TestWithReadablePrefix_Sanitized(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client, JSON(), WithReadablePrefix(func(any) string {
+		return "a|b\"c\n" + strings.Repeat("x", 100)
+	}))
+	require.NoError(t, rdb.Set(ctx, "key", map[string]string{"k": "v"}, 0))
+
+	raw, _ := server.Get("key")
+	prefix, _, ok := strings.Cut(raw, "|")
+	require.True(t, ok)
+	assert.Len(t, prefix, maxReadablePrefix)
+	assert.True(t, strings.HasPrefix(prefix, "a_b_c_xxx"))
+
+	var v map[string]string
+	require.NoError(t, rdb.Get(ctx, "key", &v))
+	assert.Equal(t, map[string]string{"k": "v"}, v)
+}
+
+func TestWithReadablePrefix_Unprefixed(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	values := []any{"a|b", []string{"x|y"}, 124, true}
+	for _, serialization := range []Option{JSON(), func(c *Cache) {}} {
+		writer := New(client, serialization)
+		reader := New(client, serialization, WithReadablePrefix(typePrefix))
+		for i, value := range values {
+			key := fmt.Sprintf("key%d", i)
+			require.NoError(t, writer.Set(ctx, key, value, 0))
+
+			dst := reflect.New(reflect.TypeOf(value))
+			require.NoError(t, reader.Get(ctx, key, dst.Interface()))
+			assert.Equal(t, value, dst.Elem().Interface())
+		}
+	}
+}
+
+func TestWithReadablePrefix_Nil(t *testing.T) {
+	assert.Panics(t, func() {
+		WithReadablePrefix(nil)
+	})
+}
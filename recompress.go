@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/redis/rueidis"
+
+	"github.com/jkratz55/rueidis-cache/compression/brotli"
+	"github.com/jkratz55/rueidis-cache/compression/flate"
+	"github.com/jkratz55/rueidis-cache/compression/gzip"
+	"github.com/jkratz55/rueidis-cache/compression/lz4"
+	"github.com/jkratz55/rueidis-cache/compression/zstd"
+)
+
+// recompressScript replaces the value at KEYS[1] with ARGV[2], keeping its TTL,
+// only if the value hasn't changed since it was read as ARGV[1]. Returns 1 if
+// the value was replaced and 0 otherwise.
+var recompressScript = rueidis.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'KEEPTTL')
+	return 1
+end
+return 0`)
+
+// Recompress reads the entries for the given keys, compresses their values with
+// the provided Codec, and writes them back keeping their TTL. The number of
+// entries that were recompressed is returned. This supports storing values
+// uncompressed or with a fast Codec on write, for example using CompressWhen,
+// and compressing them with a slower Codec with a better ratio later, such as
+// when memory pressure warrants it.
+//
+// The name of the Codec is stored in the header of the value so the value can be
+// decompressed regardless of the Codec configured for the Cache. The Codecs built
+// into this package are identified by name and can be read by any instance of
+// Cache supporting the header. Other Codecs are identified by their type name
+// unless they implement a Name method, and can only be read by the Cache that
+// recompressed them or instances registering them with WithCodecs.
+// Recompressing with the Codec configured for the Cache stores the value like
+// Set would.
+//
+// Entries that don't exist or are already compressed with the Codec are skipped.
+// An entry is only written back if it hasn't been modified since it was read, so
+// concurrent writes are never overwritten. Errors are aggregated per key rather
+// than failing fast.
+func (c *Cache) Recompress(ctx context.Context, keys []string, compressor Codec) (int64, error) {
+	if c.dryRun {
+		return 0, dryRunUnsupported("Recompress")
+	}
+	if compressor == nil {
+		panic(fmt.Errorf("nil Codec not permitted, illegal use of API"))
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	name := codecName(compressor)
+	if _, ok := builtinCodec(name); !ok {
+		c.codecs.Store(name, compressor)
+	}
+
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys))
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, c.redis.B().Get().Key(redisKey).Build())
+	}
+
+	var (
+		execs = make([]rueidis.LuaExec, 0, len(keys))
+		errs  []error
+	)
+	for i, res := range c.redis.DoMulti(ctx, cmds...) {
+		data, err := res.AsBytes()
+		if errors.Is(err, rueidis.Nil) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: redis: %w", keys[i], err))
+			continue
+		}
+		recompressed, ok, err := c.recompress(data, name, compressor)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: %w", keys[i], err))
+			continue
+		}
+		if ok {
+			execs = append(execs, rueidis.LuaExec{
+				Keys: []string{redisKeys[i]},
+				Args: []string{string(data), string(recompressed)},
+			})
+		}
+	}
+	if len(execs) == 0 {
+		return 0, errors.Join(errs...)
+	}
+
+	var count int64
+	for i, res := range recompressScript.ExecMulti(ctx, c.redis, execs...) {
+		n, err := res.AsInt64()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("key %s: redis: %w", execs[i].Keys[0], err))
+			continue
+		}
+		count += n
+	}
+	return count, errors.Join(errs...)
+}
+
+// recompress decompresses the value as stored in Redis and compresses it with the
+// named Codec. Returns false if the value is already compressed with the Codec.
+func (c *Cache) recompress(data []byte, name string, compressor Codec) ([]byte, bool, error) {
+	prefix, data := c.splitReadable(data)
+	h, payload, _, err := parseHeader(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("parse header: %w", err)
+	}
+	configured := codecName(c.codec)
+	current := configured
+	if h.flags&flagCodec != 0 {
+		current = h.codec
+	}
+	if h.flags&flagUncompressed == 0 && current == name {
+		return nil, false, nil
+	}
+
+	decompress, err := c.decompressor(h)
+	if err != nil {
+		return nil, false, err
+	}
+	if decompress != nil {
+		payload, err = decompress(payload)
+		if err != nil {
+			return nil, false, fmt.Errorf("decompress value: %w", err)
+		}
+	}
+	payload, err = compressor.Flate(payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("compress value: %w", err)
+	}
+
+	h.flags &^= flagUncompressed | flagCodec
+	h.codec = ""
+	if name != configured || c.codecHeader {
+		h.flags |= flagCodec
+		h.codec = name
+	}
+	out := append(make([]byte, 0, len(prefix)+h.len()+len(payload)), prefix...)
+	return h.appendTo(out, payload), true, nil
+}
+
+// decompressor returns the function to decompress a value with the provided
+// header, or nil if the value isn't compressed. Values compressed with a Codec
+// other than the one configured bypass the decompression Hooks.
+func (c *Cache) decompressor(h header) (CompressionHook, error) {
+	if h.flags&flagUncompressed != 0 {
+		return nil, nil
+	}
+	if h.flags&flagCodec == 0 || h.codec == codecName(c.codec) {
+		return c.hooksMixin.current.decompress, nil
+	}
+	if codec, ok := c.codecs.Load(h.codec); ok {
+		return c.reportingCompression(h.codec, "decompress", codec.(Codec).Deflate), nil
+	}
+	if codec, ok := builtinCodec(h.codec); ok {
+		return c.reportingCompression(h.codec, "decompress", codec.Deflate), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCodec, h.codec)
+}
+
+// builtinZstd is the zstd Codec used to decompress values compressed with zstd
+// by a Cache configured with another Codec. Unlike the other built-in Codecs it
+// is shared, as creating a zstd Codec is expensive.
+var builtinZstd = sync.OnceValue(func() *zstd.Codec {
+	return zstd.NewCodec(3)
+})
+
+// builtinCodec returns a Codec capable of decompressing values compressed by the
+// named Codec built into this package.
+func builtinCodec(name string) (Codec, bool) {
+	switch name {
+	case "none":
+		return nopCodec{}, true
+	case "flate":
+		return flate.Codec{}, true
+	case "gzip":
+		return gzip.NewCodec(9), true
+	case "lz4":
+		return lz4.NewCodec(), true
+	case "brotli":
+		return brotli.NewCodec(6), true
+	case "zstd":
+		return builtinZstd(), true
+	}
+	return nil, false
+}
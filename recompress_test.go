@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jkratz55/rueidis-cache/compression/brotli"
+	"github.com/jkratz55/rueidis-cache/compression/zstd"
+)
+
+func TestCache_Recompress(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	value := strings.Repeat("compressible ", 100)
+	rdb := New(client, LZ4(), CompressWhen(func(string) bool { return false }))
+	assert.NoError(t, rdb.Set(context.Background(), "key1", value, time.Minute))
+	assert.NoError(t, rdb.Set(context.Background(), "key2", value, 0))
+	before, _ := server.Get("key1")
+
+	n, err := rdb.Recompress(context.Background(), []string{"key1", "key2", "missing"}, brotli.NewCodec(11))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	after, _ := server.Get("key1")
+	assert.Less(t, len(after), len(before))
+	assert.Equal(t, time.Minute, server.TTL("key1"))
+
+	// Values recompressed with a built-in Codec are readable regardless of the
+	// Codec configured
+	for _, reader := range []*Cache{rdb, New(client), New(client, GZip())} {
+		var val string
+		assert.NoError(t, reader.Get(context.Background(), "key1", &val))
+		assert.Equal(t, value, val)
+	}
+
+	// Already recompressed values are skipped
+	n, err = rdb.Recompress(context.Background(), []string{"key1", "key2"}, brotli.NewCodec(11))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// Recompressing with the configured Codec stores the value like Set
+	n, err = rdb.Recompress(context.Background(), []string{"key1"}, rdb.codec)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	raw, _ := server.Get("key1")
+	h, _, _, err := parseHeader([]byte(raw))
+	assert.NoError(t, err)
+	assert.Zero(t, h.flags&(flagCodec|flagUncompressed))
+
+	var val string
+	assert.NoError(t, rdb.Get(context.Background(), "key1", &val))
+	assert.Equal(t, value, val)
+}
+
+func TestCache_Recompress_Zstd(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	value := strings.Repeat("compressible ", 100)
+	rdb := New(client, Zstd(3))
+	assert.NoError(t, rdb.Set(ctx, "key", value, 0))
+	raw, _ := server.Get("key")
+	assert.Less(t, len(raw), len(value))
+
+	var val string
+	assert.NoError(t, rdb.Get(ctx, "key", &val))
+	assert.Equal(t, value, val)
+
+	// A value compressed with zstd isn't mistaken for an lz4 value
+	lz4 := New(client, LZ4())
+	assert.ErrorContains(t, lz4.Get(ctx, "key", &val), "decompress value")
+
+	// Values recompressed with zstd are readable by a Cache using another Codec
+	assert.NoError(t, lz4.Set(ctx, "key", value, 0))
+	n, err := lz4.Recompress(ctx, []string{"key"}, zstd.NewCodec(19))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	for _, reader := range []*Cache{lz4, New(client)} {
+		val = ""
+		assert.NoError(t, reader.Get(ctx, "key", &val))
+		assert.Equal(t, value, val)
+	}
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+)
+
+// asRedisErr returns the error returned by Redis err wraps, if any. Unlike
+// rueidis.IsRedisErr the error may be wrapped, such as the errors returned by
+// the Cache.
+func asRedisErr(err error) (*rueidis.RedisError, bool) {
+	var re *rueidis.RedisError
+	return re, errors.As(err, &re)
+}
+
+// IsClusterRedirect reports if err is a MOVED or ASK redirection returned by
+// Redis Cluster while slots are migrating or the topology changes. The command
+// wasn't executed, so it is always safe to retry.
+func IsClusterRedirect(err error) bool {
+	re, ok := asRedisErr(err)
+	if !ok {
+		return false
+	}
+	if _, moved := re.IsMoved(); moved {
+		return true
+	}
+	_, ask := re.IsAsk()
+	return ask
+}
+
+// IsLoading reports if err is a LOADING error returned by Redis while it loads
+// the dataset into memory after a restart. The command wasn't executed, so it is
+// always safe to retry.
+func IsLoading(err error) bool {
+	re, ok := asRedisErr(err)
+	return ok && re.IsLoading()
+}
+
+// IsTryAgain reports if err is a TRYAGAIN or CLUSTERDOWN error returned by Redis
+// Cluster while the keys of a command are being resharded or the cluster is
+// failing over. The command wasn't executed, so it is always safe to retry.
+func IsTryAgain(err error) bool {
+	re, ok := asRedisErr(err)
+	return ok && (re.IsTryAgain() || re.IsClusterDown())
+}
+
+// IsTimeout reports if err is a network timeout talking to Redis. Unlike the
+// other predicates the command may have been executed before the timeout, so
+// commands that aren't idempotent, such as INCR, may be applied more than once
+// when retried.
+func IsTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// DefaultRetryable is the predicate used by WithRetry when none is provided. It
+// reports if err is a cluster redirection, a LOADING, TRYAGAIN or CLUSTERDOWN
+// error, or a network timeout. Errors that will never succeed when retried, such
+// as OOM or WRONGTYPE, aren't retryable.
+func DefaultRetryable(err error) bool {
+	return IsClusterRedirect(err) || IsLoading(err) || IsTryAgain(err) || IsTimeout(err)
+}
+
+// retryClient wraps the client so commands failing with an error the retry
+// predicate configured with WithRetry reports as retryable are retried.
+func (c *Cache) retryClient(client rueidis.Client) rueidis.Client {
+	if c.retry == nil {
+		return client
+	}
+	return rueidishook.WithHook(client, *c.retry)
+}
+
+// retryHook is a rueidishook.Hook retrying commands according to a RetryPolicy.
+// Commands are pinned so they aren't recycled by rueidis once sent and can be
+// sent again. Only the commands of a pipeline that failed are retried.
+type retryHook struct {
+	policy    RetryPolicy
+	retryable func(err error) bool
+}
+
+// shouldRetry reports if a command that failed with err on the provided attempt
+// should be retried.
+func (h retryHook) shouldRetry(ctx context.Context, attempt int, err error) bool {
+	return err != nil && attempt < h.policy.MaxAttempts && ctx.Err() == nil && h.retryable(err)
+}
+
+// wait waits for the backoff before the retry following the provided attempt,
+// returning false if the context is done first.
+func (h retryHook) wait(ctx context.Context, attempt int) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(h.policy.backoff(attempt)):
+		return true
+	}
+}
+
+func (h retryHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	cmd = cmd.Pin()
+	for attempt := 1; ; attempt++ {
+		res := client.Do(ctx, cmd)
+		if !h.shouldRetry(ctx, attempt, res.Error()) || !h.wait(ctx, attempt) {
+			return res
+		}
+	}
+}
+
+func (h retryHook) DoMulti(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	pinned := make([]rueidis.Completed, len(multi))
+	for i, cmd := range multi {
+		pinned[i] = cmd.Pin()
+	}
+	results := client.DoMulti(ctx, pinned...)
+	for attempt := 1; ; attempt++ {
+		failed := h.failed(ctx, attempt, results)
+		if len(failed) == 0 {
+			return results
+		}
+		cmds := make([]rueidis.Completed, len(failed))
+		for j, i := range failed {
+			cmds[j] = pinned[i]
+		}
+		for j, res := range client.DoMulti(ctx, cmds...) {
+			results[failed[j]] = res
+		}
+	}
+}
+
+func (h retryHook) DoCache(client rueidis.Client, ctx context.Context, cmd rueidis.Cacheable, ttl time.Duration) rueidis.RedisResult {
+	cmd = cmd.Pin()
+	for attempt := 1; ; attempt++ {
+		res := client.DoCache(ctx, cmd, ttl)
+		if !h.shouldRetry(ctx, attempt, res.Error()) || !h.wait(ctx, attempt) {
+			return res
+		}
+	}
+}
+
+func (h retryHook) DoMultiCache(client rueidis.Client, ctx context.Context, multi ...rueidis.CacheableTTL) []rueidis.RedisResult {
+	pinned := make([]rueidis.CacheableTTL, len(multi))
+	for i, cmd := range multi {
+		pinned[i] = rueidis.CacheableTTL{Cmd: cmd.Cmd.Pin(), TTL: cmd.TTL}
+	}
+	results := client.DoMultiCache(ctx, pinned...)
+	for attempt := 1; ; attempt++ {
+		failed := h.failed(ctx, attempt, results)
+		if len(failed) == 0 {
+			return results
+		}
+		cmds := make([]rueidis.CacheableTTL, len(failed))
+		for j, i := range failed {
+			cmds[j] = pinned[i]
+		}
+		for j, res := range client.DoMultiCache(ctx, cmds...) {
+			results[failed[j]] = res
+		}
+	}
+}
+
+// failed returns the indexes of the results of a pipeline that should be
+// retried, waiting for the backoff if any should be.
+func (h retryHook) failed(ctx context.Context, attempt int, results []rueidis.RedisResult) []int {
+	var failed []int
+	for i, res := range results {
+		if h.shouldRetry(ctx, attempt, res.Error()) {
+			failed = append(failed, i)
+		}
+	}
+	if len(failed) == 0 || !h.wait(ctx, attempt) {
+		return nil
+	}
+	return failed
+}
+
+func (h retryHook) Receive(client rueidis.Client, ctx context.Context, subscribe rueidis.Completed, fn func(msg rueidis.PubSubMessage)) error {
+	return client.Receive(ctx, subscribe, fn)
+}
+
+func (h retryHook) DoStream(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResultStream {
+	return client.DoStream(ctx, cmd)
+}
+
+func (h retryHook) DoMultiStream(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) rueidis.MultiRedisResultStream {
+	return client.DoMultiStream(ctx, multi...)
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redisError returns the error Redis replies with when it's configured to fail
+// every command with msg. A write is sent since rueidis retries reads itself.
+func redisError(t *testing.T, msg string) error {
+	server.SetError(msg)
+	defer server.SetError("")
+	err := client.Do(context.Background(), client.B().Set().Key("key").Value("value").Build()).Error()
+	require.Error(t, err)
+	return err
+}
+
+func TestRetryPredicates(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	moved := redisError(t, "MOVED 3999 127.0.0.1:6381")
+	loading := redisError(t, "LOADING Redis is loading the dataset in memory")
+	tryAgain := redisError(t, "TRYAGAIN Multiple keys request during rehashing of slot")
+	clusterDown := redisError(t, "CLUSTERDOWN The cluster is down")
+	oom := redisError(t, "OOM command not allowed when used memory > 'maxmemory'")
+	wrongType := redisError(t, "WRONGTYPE Operation against a key holding the wrong kind of value")
+
+	assert.True(t, IsClusterRedirect(moved))
+	assert.False(t, IsClusterRedirect(loading))
+	assert.True(t, IsLoading(loading))
+	assert.True(t, IsTryAgain(tryAgain))
+	assert.True(t, IsTryAgain(clusterDown))
+	assert.True(t, IsTimeout(os.ErrDeadlineExceeded))
+	assert.False(t, IsTimeout(context.Canceled))
+
+	for _, err := range []error{moved, loading, tryAgain, clusterDown, os.ErrDeadlineExceeded} {
+		assert.True(t, DefaultRetryable(err), err)
+	}
+	for _, err := range []error{oom, wrongType, ErrKeyNotFound, errors.New("boom")} {
+		assert.False(t, DefaultRetryable(err), err)
+	}
+}
+
+func TestCache_WithRetry(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	// rueidis retries reads failing with LOADING itself, so writes are used to
+	// exercise the retries of the Cache
+	ctx := context.Background()
+	policy := RetryPolicy{MaxAttempts: 20, InitialBackoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	rdb := New(client, WithRetry(policy, nil))
+
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		server.SetError("")
+	}()
+	require.NoError(t, rdb.Set(ctx, "key", "value", 0))
+	assert.True(t, server.Exists("key"))
+
+	// Pipelines are retried
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		server.SetError("")
+	}()
+	res, err := rdb.MSetWithResult(ctx, map[string]any{"key1": "value1", "key2": "value2"})
+	require.NoError(t, err)
+	assert.False(t, res.HasErrors())
+	assert.True(t, server.Exists("key1"))
+	assert.True(t, server.Exists("key2"))
+
+	// Errors that aren't retryable are returned immediately
+	rdb = New(client, WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second}, nil))
+	server.SetError("OOM command not allowed when used memory > 'maxmemory'")
+	start := time.Now()
+	err = rdb.Set(ctx, "key", "value", 0)
+	server.SetError("")
+	assert.ErrorContains(t, err, "OOM")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// Retries give up once the policy is exhausted
+	rdb = New(client, WithRetry(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}, IsLoading))
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	err = rdb.Set(ctx, "key", "value", 0)
+	server.SetError("")
+	assert.True(t, IsLoading(err))
+
+	// Without WithRetry the error is returned
+	rdb = New(client)
+	server.SetError("LOADING Redis is loading the dataset in memory")
+	err = rdb.Set(ctx, "key", "value", 0)
+	server.SetError("")
+	assert.True(t, IsLoading(err))
+}
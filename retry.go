@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy configures how a loader invoked to fill the cache is retried when
+// it fails. Loader retries are independent of any retries performed by the Redis
+// client.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the loader is invoked, including
+	// the first attempt. Values <= 1 disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. The delay doubles for
+	// every subsequent retry.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries. A value <= 0 indicates the delay
+	// is not capped.
+	MaxBackoff time.Duration
+}
+
+// backoff returns the delay before the provided retry, starting at 1.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+// Retry wraps fn so it is retried according to the RetryPolicy when it returns an
+// error. Errors wrapped with NonRetryable, as well as context cancellation, are
+// returned immediately without retrying. When all attempts fail the error from
+// the last attempt is returned.
+//
+// Retry is intended to wrap the functions passed to Cacheable and
+// GetOrComputeDistributed to make cache fills resilient to a flaky source of
+// truth.
+func Retry[T any](policy RetryPolicy, fn func(ctx context.Context) (T, error)) func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		return retry(ctx, policy, fn)
+	}
+}
+
+// RetryLoader wraps the LoaderFunc so it is retried according to the RetryPolicy
+// like Retry. When used with MGetOrLoadBatched each chunk of keys is retried
+// independently.
+func RetryLoader[T any](policy RetryPolicy, loader LoaderFunc[T]) LoaderFunc[T] {
+	return func(ctx context.Context, keys []string) (map[string]T, error) {
+		return retry(ctx, policy, func(ctx context.Context) (map[string]T, error) {
+			return loader(ctx, keys)
+		})
+	}
+}
+
+func retry[T any](ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		val, err := fn(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || !shouldRetry(ctx, err) {
+			return val, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return val, err
+		case <-time.After(policy.backoff(attempt)):
+		}
+	}
+}
+
+// shouldRetry reports if a loader error is retryable. Errors are retried unless
+// they were wrapped with NonRetryable or the context is done.
+func shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var re retryable
+	if errors.As(err, &re) && !re.IsRetryable() {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 40*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, policy.backoff(4))
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	attempts := 0
+	val, err := Retry(policy, func(ctx context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, assert.AnError
+		}
+		return 42, nil
+	})(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 42, val)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	_, err = Retry(policy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, assert.AnError
+	})(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	_, err = Retry(policy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, NonRetryable(assert.AnError)
+	})(context.Background())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryLoader(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	attempts := 0
+	loader := RetryLoader(RetryPolicy{MaxAttempts: 2}, func(ctx context.Context, keys []string) (map[string]string, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, assert.AnError
+		}
+		return map[string]string{"key": "loaded"}, nil
+	})
+
+	res, err := MGetOrLoad(context.Background(), rdb, []string{"key"}, time.Minute, loader)
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[string]{"key": "loaded"}, res)
+	assert.Equal(t, 2, attempts)
+}
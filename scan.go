@@ -0,0 +1,180 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/redis/rueidis"
+)
+
+// DefaultScanConcurrency is the default number of batches of values fetched
+// concurrently by ScanValues.
+const DefaultScanConcurrency = 4
+
+// scanBatch is the number of keys fetched in a single pipeline by ScanValues
+// when MGet batching isn't configured.
+const scanBatch = 100
+
+// ScanEntry is an entry yielded by ScanValues. If Err is non-nil the entry could
+// not be read and Value should not be used. Errors that aren't specific to a key,
+// such as a failed SCAN, are yielded with an empty Key.
+type ScanEntry struct {
+	Key   string
+	Value any
+	Err   error
+}
+
+// ScanValues scans the keys matching the pattern, like ScanKeys, and lazily
+// fetches and decodes their values, yielding each entry on the returned channel.
+// The dst function is invoked to create a fresh destination for each value, and
+// must return a pointer like the value passed to Get. This allows processing all
+// entries of a large cache, for example for exports, without loading everything
+// into memory at once.
+//
+// Values are fetched in pipelined batches, with up to DefaultScanConcurrency
+// batches fetched concurrently unless configured otherwise with
+// WithScanConcurrency. Entries are yielded in no particular order, and like SCAN
+// an entry may be yielded more than once if the keyspace changes during the scan.
+// Keys that are deleted or expire before their value is fetched are skipped.
+//
+// The channel is closed once every entry has been yielded, or the context is
+// done. Callers that stop consuming entries before the channel is closed must
+// cancel the context to release the goroutines fetching values.
+func (c *Cache) ScanValues(ctx context.Context, pattern string, dst func() any) <-chan ScanEntry {
+	if dst == nil {
+		panic(fmt.Errorf("nil dst func not permitted, illegal use of api"))
+	}
+
+	out := make(chan ScanEntry)
+	batches := make(chan []string)
+	var wg sync.WaitGroup
+	for i := 0; i < c.scanConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				c.fetchScanned(ctx, batch, dst, out)
+			}
+		}()
+	}
+	go func() {
+		if err := c.scanBatches(ctx, pattern, batches); err != nil {
+			send(ctx, out, ScanEntry{Err: err})
+		}
+		close(batches)
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// scanBatches scans the keys matching the pattern and sends them to batches.
+func (c *Cache) scanBatches(ctx context.Context, pattern string, batches chan<- []string) error {
+	// Buffered writes are flushed so the entries are visible to SCAN
+	if err := c.FlushWrites(ctx); err != nil {
+		return err
+	}
+	pattern, restore, err := c.pattern(ctx, pattern)
+	if err != nil {
+		return err
+	}
+	batchSize := c.mgetBatch
+	if batchSize <= 0 {
+		batchSize = scanBatch
+	}
+
+	cursor := uint64(0)
+	for {
+		result, err := c.redis.Do(ctx, c.redis.B().Scan().Cursor(cursor).
+			Match(pattern).Count(1000).Build()).AsScanEntry()
+		if err != nil {
+			return fmt.Errorf("redis: %w", err)
+		}
+
+		keys := make([]string, 0, len(result.Elements))
+		for _, redisKey := range result.Elements {
+			if c.migration != nil {
+				// Entries written with the target Encoding are read through the
+				// key they were written for
+				if strings.HasSuffix(redisKey, c.migration.suffix) {
+					continue
+				}
+			}
+			if key, ok := restore(redisKey); ok {
+				keys = append(keys, key)
+			}
+		}
+		for _, batch := range chunk(keys, batchSize) {
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		cursor = result.Cursor
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// fetchScanned fetches and decodes the values of the keys and sends them to out.
+func (c *Cache) fetchScanned(ctx context.Context, keys []string, dst func() any, out chan<- ScanEntry) {
+	if c.migration != nil {
+		for _, key := range keys {
+			v := dst()
+			err := c.Get(ctx, key, v)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			if !send(ctx, out, ScanEntry{Key: key, Value: v, Err: err}) {
+				return
+			}
+		}
+		return
+	}
+
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		send(ctx, out, ScanEntry{Err: err})
+		return
+	}
+	cmds := make(rueidis.Commands, 0, len(redisKeys))
+	for _, redisKey := range redisKeys {
+		cmds = append(cmds, c.redis.B().Get().Key(redisKey).Build())
+	}
+	for i, res := range c.redis.DoMulti(ctx, cmds...) {
+		entry := ScanEntry{Key: keys[i]}
+		data, err := res.AsBytes()
+		switch {
+		case errors.Is(err, rueidis.Nil):
+			continue
+		case err != nil:
+			entry.Err = fmt.Errorf("redis: %w", err)
+		default:
+			entry.Value = dst()
+			err = c.decode(ctx, keys[i], data, entry.Value)
+			if errors.Is(err, ErrKeyNotFound) {
+				continue
+			}
+			entry.Err = err
+		}
+		if !send(ctx, out, entry) {
+			return
+		}
+	}
+}
+
+// send sends the entry to out returning false if the context is done first.
+func send(ctx context.Context, out chan<- ScanEntry, entry ScanEntry) bool {
+	select {
+	case out <- entry:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_ScanValues(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithScanConcurrency(2), BatchMultiGets(7))
+	expected := make(map[string]int)
+	for i := 0; i < 250; i++ {
+		key := fmt.Sprintf("user:%d", i)
+		expected[key] = i
+		require.NoError(t, rdb.Set(context.Background(), key, i, time.Minute))
+	}
+	require.NoError(t, rdb.Set(context.Background(), "other", 1, time.Minute))
+	require.NoError(t, server.Set("user:invalid", "\xc1\x00"))
+
+	actual := make(map[string]int)
+	var failed []string
+	for entry := range rdb.ScanValues(context.Background(), "user:*", func() any { return new(int) }) {
+		if entry.Err != nil {
+			failed = append(failed, entry.Key)
+			continue
+		}
+		actual[entry.Key] = *entry.Value.(*int)
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, []string{"user:invalid"}, failed)
+}
+
+func TestCache_ScanValues_Cancel(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	for i := 0; i < 50; i++ {
+		require.NoError(t, rdb.Set(context.Background(), fmt.Sprintf("key:%d", i), i, time.Minute))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entries := rdb.ScanValues(ctx, "*", func() any { return new(int) })
+	entry := <-entries
+	assert.NoError(t, entry.Err)
+	cancel()
+
+	// The channel is closed once the context is cancelled
+	done := make(chan struct{})
+	go func() {
+		for range entries {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancelling the context")
+	}
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// DefaultSchedulerJitter is the fraction of the interval by which each refresh
+// scheduled by a Scheduler is randomly delayed or advanced, so instances
+// refreshing the same keys don't refresh in lockstep.
+const DefaultSchedulerJitter = 0.1
+
+// Scheduler periodically refreshes registered sets of keys so derived data stays
+// warm in the Cache. Each set of keys is refreshed by loading every key with its
+// loader and writing the values like WarmFromLoader, and refreshes are reported
+// to Hooks implementing RefreshHook so the background work is observable.
+//
+// A Scheduler is safe for concurrent use.
+type Scheduler struct {
+	cache *Cache
+
+	mu     sync.Mutex
+	jobs   []refreshJob
+	ctx    context.Context // nil until the Scheduler is started
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// refreshJob is a set of keys registered with a Scheduler.
+type refreshJob struct {
+	keys     []string
+	interval time.Duration
+	loader   func(ctx context.Context, key string) (any, error)
+}
+
+// NewScheduler creates a Scheduler refreshing keys in the provided Cache.
+func NewScheduler(c *Cache) *Scheduler {
+	if c == nil {
+		panic(fmt.Errorf("nil Cache not permitted, illegal use of api"))
+	}
+	return &Scheduler{cache: c}
+}
+
+// Register schedules the keys to be refreshed every interval by invoking the
+// loader for each key and writing the loaded values. The values are written with
+// a TTL of twice the interval, and at least a second as TTLs are written with
+// second precision, so they survive a single failed refresh but don't outlive
+// the Scheduler for long once it's stopped. Keys for which the
+// loader returns an error wrapping ErrKeyNotFound are skipped.
+//
+// Keys registered after the Scheduler is started are refreshed right away.
+// Providing an interval <= 0 or a nil loader panics.
+func (s *Scheduler) Register(keys []string, interval time.Duration, loader func(ctx context.Context, key string) (any, error)) {
+	if interval <= 0 {
+		panic(fmt.Errorf("refresh interval must be > 0, illegal use of api"))
+	}
+	if loader == nil {
+		panic(fmt.Errorf("nil loader not permitted, illegal use of api"))
+	}
+	job := refreshJob{
+		keys:     append([]string(nil), keys...),
+		interval: interval,
+		loader:   loader,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	if s.ctx != nil {
+		s.start(job)
+	}
+}
+
+// Start starts refreshing the registered keys in the background until Stop is
+// called or the context is done. Each set of keys is refreshed immediately and
+// then every interval, randomly adjusted by up to DefaultSchedulerJitter of the
+// interval. Calling Start on a started Scheduler is a no-op.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, job := range s.jobs {
+		s.start(job)
+	}
+}
+
+// Stop stops refreshing and waits for refreshes in progress, which are
+// cancelled, to return. The Scheduler can be started again afterward.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.ctx == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.cancel()
+	s.ctx, s.cancel = nil, nil
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// start runs the job in the background. The caller must hold s.mu.
+func (s *Scheduler) start(job refreshJob) {
+	s.wg.Add(1)
+	go func(ctx context.Context) {
+		defer s.wg.Done()
+		s.run(ctx, job)
+	}(s.ctx)
+}
+
+func (s *Scheduler) run(ctx context.Context, job refreshJob) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		s.refresh(ctx, job)
+		timer.Reset(jitter(job.interval))
+	}
+}
+
+// refresh loads and writes the keys of the job, reporting the refresh to the
+// RefreshHooks unless it was cancelled by stopping the Scheduler.
+func (s *Scheduler) refresh(ctx context.Context, job refreshJob) {
+	start := time.Now()
+	err := s.cache.WarmFromLoader(ctx, job.keys, job.loader, max(2*job.interval, time.Second))
+	if ctx.Err() != nil {
+		return
+	}
+	s.cache.hooksMixin.refreshed(len(job.keys), time.Since(start), err)
+}
+
+// jitter randomly adjusts the interval by up to DefaultSchedulerJitter of it.
+func jitter(interval time.Duration) time.Duration {
+	delta := (rand.Float64()*2 - 1) * DefaultSchedulerJitter
+	return interval + time.Duration(float64(interval)*delta)
+}
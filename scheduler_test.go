@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refreshRecorder is a Hook recording the refreshes reported to it.
+type refreshRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *refreshRecorder) MarshalHook(next Marshaller) Marshaller        { return next }
+func (r *refreshRecorder) UnmarshallHook(next Unmarshaller) Unmarshaller { return next }
+func (r *refreshRecorder) CompressHook(next CompressionHook) CompressionHook {
+	return next
+}
+func (r *refreshRecorder) DecompressHook(next CompressionHook) CompressionHook {
+	return next
+}
+
+func (r *refreshRecorder) Refreshed(keys int, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func (r *refreshRecorder) refreshes() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.errs...)
+}
+
+func TestScheduler(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	recorder := &refreshRecorder{}
+	rdb.AddHook(recorder)
+
+	var (
+		calls  atomic.Int32
+		failed atomic.Bool
+	)
+	boom := errors.New("boom")
+	scheduler := NewScheduler(rdb)
+	scheduler.Register([]string{"a", "b"}, 500*time.Millisecond, func(ctx context.Context, key string) (any, error) {
+		calls.Add(1)
+		// The first refresh of b fails
+		if key == "b" && failed.CompareAndSwap(false, true) {
+			return nil, boom
+		}
+		return "value:" + key, nil
+	})
+
+	ctx := context.Background()
+	scheduler.Start(ctx)
+	assert.Eventually(t, func() bool {
+		return server.Exists("a") && server.Exists("b")
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return len(recorder.refreshes()) >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+	scheduler.Stop()
+
+	var v string
+	require.NoError(t, rdb.Get(ctx, "b", &v))
+	assert.Equal(t, "value:b", v)
+	assert.Equal(t, time.Second, server.TTL("b"))
+
+	refreshes := recorder.refreshes()
+	assert.ErrorIs(t, refreshes[0], boom)
+	assert.NoError(t, refreshes[len(refreshes)-1])
+
+	// Nothing is refreshed once stopped
+	stopped := calls.Load()
+	time.Sleep(600 * time.Millisecond)
+	assert.Equal(t, stopped, calls.Load())
+}
+
+func TestScheduler_RegisterAfterStart(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	scheduler := NewScheduler(New(client))
+	scheduler.Start(context.Background())
+	defer scheduler.Stop()
+
+	scheduler.Register([]string{"key"}, time.Hour, func(ctx context.Context, key string) (any, error) {
+		return "value", nil
+	})
+	assert.Eventually(t, func() bool {
+		return server.Exists("key")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestScheduler_IllegalUse(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	loader := func(ctx context.Context, key string) (any, error) { return nil, nil }
+	assert.Panics(t, func() { NewScheduler(nil) })
+
+	scheduler := NewScheduler(New(client))
+	assert.Panics(t, func() { scheduler.Register([]string{"key"}, 0, loader) })
+	assert.Panics(t, func() { scheduler.Register([]string{"key"}, time.Second, nil) })
+}
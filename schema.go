@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// SchemaMigration is a function type that upgrades a value read from the cache
+// to the current shape of its type. SchemaMigration is passed a pointer to the
+// unmarshalled value and modifies it in place.
+type SchemaMigration func(v any) error
+
+// schemaMigrations holds the SchemaMigration registered per type. Migrations are
+// registered rarely but looked up on every read, so the map is copied on write.
+type schemaMigrations struct {
+	mu         sync.Mutex
+	migrations atomic.Pointer[map[reflect.Type][]SchemaMigration]
+}
+
+func (sm *schemaMigrations) register(t reflect.Type, fn SchemaMigration) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	migrations := make(map[reflect.Type][]SchemaMigration)
+	if current := sm.migrations.Load(); current != nil {
+		for k, v := range *current {
+			migrations[k] = v
+		}
+	}
+	migrations[t] = append(migrations[t][:len(migrations[t]):len(migrations[t])], fn)
+	sm.migrations.Store(&migrations)
+}
+
+// apply runs the SchemaMigration registered for the type v points to in the
+// order they were registered.
+func (sm *schemaMigrations) apply(v any) error {
+	migrations := sm.migrations.Load()
+	if migrations == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Pointer {
+		return nil
+	}
+	for i, fn := range (*migrations)[t.Elem()] {
+		if err := fn(v); err != nil {
+			return fmt.Errorf("migration %d for type %s: %w", i, t.Elem(), err)
+		}
+	}
+	return nil
+}
+
+// RegisterMigration registers a SchemaMigration for values of type t. After a
+// value of type t is unmarshalled the SchemaMigration registered for the type are
+// invoked in the order they were registered, bringing entries written with an
+// older shape of the type up to date.
+//
+// Entries don't carry a schema version, so migrations run on every read and are
+// responsible for determining if they apply, typically by inspecting a version
+// field or the zero-value of renamed fields. Migrated values are not written back
+// to Redis.
+//
+//	c.RegisterMigration(reflect.TypeOf(Person{}), func(v any) error {
+//		p := v.(*Person)
+//		if p.FullName == "" {
+//			p.FullName = p.FirstName + " " + p.LastName
+//		}
+//		return nil
+//	})
+func (c *Cache) RegisterMigration(t reflect.Type, fn SchemaMigration) {
+	if t == nil || fn == nil {
+		panic(fmt.Errorf("nil type and/or SchemaMigration not permitted, illegal use of api"))
+	}
+	c.schemaMigrations.register(t, fn)
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCache_RegisterMigration(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	type personV1 struct {
+		FirstName string
+		LastName  string
+	}
+	type person struct {
+		Version   int
+		FirstName string
+		LastName  string
+		FullName  string
+	}
+
+	val, _ := msgpack.Marshal(personV1{FirstName: "Billy", LastName: "Bob"})
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("person").Value(string(val)).Build()).Error())
+
+	rdb := New(client)
+	rdb.RegisterMigration(reflect.TypeOf(person{}), func(v any) error {
+		p := v.(*person)
+		if p.Version < 1 {
+			p.FullName = p.FirstName + " " + p.LastName
+			p.Version = 1
+		}
+		return nil
+	})
+	rdb.RegisterMigration(reflect.TypeOf(person{}), func(v any) error {
+		p := v.(*person)
+		if p.Version < 2 {
+			p.Version = 2
+		}
+		return nil
+	})
+
+	var p person
+	assert.NoError(t, rdb.Get(context.Background(), "person", &p))
+	assert.Equal(t, person{Version: 2, FirstName: "Billy", LastName: "Bob", FullName: "Billy Bob"}, p)
+
+	results, err := MGet[person](context.Background(), rdb, "person")
+	assert.NoError(t, err)
+	assert.Equal(t, p, results["person"])
+
+	// Migrations are only applied to the registered type
+	var p1 personV1
+	assert.NoError(t, rdb.Get(context.Background(), "person", &p1))
+	assert.Equal(t, personV1{FirstName: "Billy", LastName: "Bob"}, p1)
+
+	rdb.RegisterMigration(reflect.TypeOf(personV1{}), func(v any) error {
+		return errors.New("boom")
+	})
+	assert.Error(t, rdb.Get(context.Background(), "person", &p1))
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"hash/fnv"
+	"slices"
+	"sync"
+)
+
+// DefaultWriteStripes is the number of locks writes are striped across when
+// WithWriteSerialization is provided a number of stripes <= 0.
+const DefaultWriteStripes = 256
+
+// writeStripes serializes writes of the same key with a fixed set of locks
+// selected by the hash of the key. Keys sharing a stripe are serialized as well,
+// trading some unnecessary contention for bounded memory.
+type writeStripes struct {
+	locks []sync.Mutex
+}
+
+func newWriteStripes(n int) *writeStripes {
+	if n <= 0 {
+		n = DefaultWriteStripes
+	}
+	return &writeStripes{locks: make([]sync.Mutex, n)}
+}
+
+func (ws *writeStripes) stripe(key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(ws.locks)))
+}
+
+// lock acquires the stripes of every key, returning the function to release
+// them. Stripes are acquired in ascending order so concurrent writes of
+// overlapping keys can't deadlock.
+func (ws *writeStripes) lock(keys ...string) func() {
+	stripes := make([]int, 0, len(keys))
+	for _, key := range keys {
+		stripes = append(stripes, ws.stripe(key))
+	}
+	slices.Sort(stripes)
+	stripes = slices.Compact(stripes)
+	for _, i := range stripes {
+		ws.locks[i].Lock()
+	}
+	return func() {
+		for i := len(stripes) - 1; i >= 0; i-- {
+			ws.locks[stripes[i]].Unlock()
+		}
+	}
+}
+
+// serializeWrites locks the stripes of keys if write serialization is enabled,
+// returning the function to release them.
+func (c *Cache) serializeWrites(keys ...string) func() {
+	if c.writeLocks == nil {
+		return func() {}
+	}
+	return c.writeLocks.lock(keys...)
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inflightSetHook is a rueidishook.Hook tracking the maximum number of SET
+// commands in flight at once, holding each for a short delay.
+type inflightSetHook struct {
+	cacheCallCounter
+	inflight atomic.Int32
+	max      atomic.Int32
+}
+
+func (h *inflightSetHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	if cmd.Commands()[0] == "SET" {
+		n := h.inflight.Add(1)
+		defer h.inflight.Add(-1)
+		for {
+			m := h.max.Load()
+			if n <= m || h.max.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return client.Do(ctx, cmd)
+}
+
+func TestCache_WithWriteSerialization(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	tests := []struct {
+		name       string
+		opts       []Option
+		serialized bool
+	}{
+		{name: "serialized", opts: []Option{WithWriteSerialization(0)}, serialized: true},
+		{name: "unserialized"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hook := &inflightSetHook{}
+			rdb := New(rueidishook.WithHook(client, hook), tc.opts...)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					assert.NoError(t, rdb.Set(context.Background(), "key", i, 0))
+				}(i)
+			}
+			wg.Wait()
+			if tc.serialized {
+				assert.Equal(t, int32(1), hook.max.Load())
+			} else {
+				assert.Greater(t, hook.max.Load(), int32(1))
+			}
+		})
+	}
+}
+
+func TestWriteStripes(t *testing.T) {
+	ws := newWriteStripes(4)
+	assert.Len(t, ws.locks, 4)
+	assert.Equal(t, ws.stripe("key"), ws.stripe("key"))
+
+	// Keys sharing a stripe and duplicate keys must not deadlock
+	unlock := ws.lock("a", "b", "c", "d", "e", "a")
+	unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ws.lock("a")()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "stripe not released")
+	}
+}
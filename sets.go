@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/rueidis"
+)
+
+// SInterStore computes the intersection of the sets stored at the given keys and
+// stores the result in the set at dst, overwriting dst if it already exists. The
+// number of members in the resulting set is returned.
+//
+// The set operations are computed by Redis on existing sets and do not involve
+// serialization or compression. When using Redis Cluster dst and all the keys
+// must hash to the same slot, otherwise ErrCrossSlot is returned.
+func (c *Cache) SInterStore(ctx context.Context, dst string, keys ...string) (int64, error) {
+	if c.dryRun {
+		return 0, dryRunUnsupported("SInterStore")
+	}
+	return c.setStore(ctx, dst, keys, func(dst string, keys []string) rueidis.Completed {
+		return c.redis.B().Sinterstore().Destination(dst).Key(keys...).Build()
+	})
+}
+
+// SUnionStore computes the union of the sets stored at the given keys and stores
+// the result in the set at dst, overwriting dst if it already exists. The number
+// of members in the resulting set is returned.
+//
+// When using Redis Cluster dst and all the keys must hash to the same slot,
+// otherwise ErrCrossSlot is returned.
+func (c *Cache) SUnionStore(ctx context.Context, dst string, keys ...string) (int64, error) {
+	if c.dryRun {
+		return 0, dryRunUnsupported("SUnionStore")
+	}
+	return c.setStore(ctx, dst, keys, func(dst string, keys []string) rueidis.Completed {
+		return c.redis.B().Sunionstore().Destination(dst).Key(keys...).Build()
+	})
+}
+
+// SDiffStore computes the difference between the set stored at the first key and
+// the sets stored at the remaining keys and stores the result in the set at dst,
+// overwriting dst if it already exists. The number of members in the resulting
+// set is returned.
+//
+// When using Redis Cluster dst and all the keys must hash to the same slot,
+// otherwise ErrCrossSlot is returned.
+func (c *Cache) SDiffStore(ctx context.Context, dst string, keys ...string) (int64, error) {
+	if c.dryRun {
+		return 0, dryRunUnsupported("SDiffStore")
+	}
+	return c.setStore(ctx, dst, keys, func(dst string, keys []string) rueidis.Completed {
+		return c.redis.B().Sdiffstore().Destination(dst).Key(keys...).Build()
+	})
+}
+
+func (c *Cache) setStore(
+	ctx context.Context,
+	dst string,
+	keys []string,
+	build func(dst string, keys []string) rueidis.Completed) (int64, error) {
+
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("at least one key is required")
+	}
+
+	redisDst, err := c.key(ctx, dst)
+	if err != nil {
+		return 0, err
+	}
+	redisKeys, err := c.keys(ctx, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	// The rueidis command builder panics when building a multi-key command whose
+	// keys hash to different slots, so the slots are checked upfront.
+	if c.cluster && !sameSlot(append([]string{redisDst}, redisKeys...)...) {
+		return 0, ErrCrossSlot
+	}
+	if err := c.flushBuffered(ctx, redisDst); err != nil {
+		return 0, err
+	}
+
+	n, err := c.redis.Do(ctx, build(redisDst, redisKeys)).AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("redis: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_SetStore(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	assert.NoError(t, client.Do(context.Background(), client.B().Sadd().Key("audience:a").Member("1", "2", "3").Build()).Error())
+	assert.NoError(t, client.Do(context.Background(), client.B().Sadd().Key("audience:b").Member("2", "3", "4").Build()).Error())
+
+	rdb := New(client)
+	assert.False(t, rdb.cluster)
+
+	n, err := rdb.SInterStore(context.Background(), "audience:inter", "audience:a", "audience:b")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = rdb.SUnionStore(context.Background(), "audience:union", "audience:a", "audience:b")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), n)
+
+	n, err = rdb.SDiffStore(context.Background(), "audience:diff", "audience:a", "audience:b")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	members, err := client.Do(context.Background(), client.B().Smembers().Key("audience:diff").Build()).AsStrSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, members)
+
+	_, err = rdb.SInterStore(context.Background(), "audience:inter")
+	assert.Error(t, err)
+
+	// Simulate a cluster client to verify keys are checked for the same slot
+	rdb.cluster = true
+	_, err = rdb.SInterStore(context.Background(), "audience:inter", "audience:a", "audience:b")
+	assert.ErrorIs(t, err, ErrCrossSlot)
+}
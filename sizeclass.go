@@ -0,0 +1,46 @@
+package cache
+
+// SizeClassRule selects how values are compressed by their size once
+// marshalled. Rules are configured with WithSizeClassStrategy.
+type SizeClassRule struct {
+	// MaxSize is the inclusive upper bound in bytes of the size of marshalled
+	// values the rule applies to. A value <= 0 indicates the rule applies to
+	// values of any size not covered by a rule with a smaller bound.
+	MaxSize int
+
+	// Codec is the compression Codec values in the size class are compressed
+	// with. A nil Codec stores values in the size class uncompressed.
+	Codec Codec
+}
+
+// sizeClass returns the rule applying to values of the given size, and false if
+// no rule applies. Rules are sorted by MaxSize with unbounded rules last.
+func (c *Cache) sizeClass(size int) (SizeClassRule, bool) {
+	for _, rule := range c.sizeClasses {
+		if rule.MaxSize <= 0 || size <= rule.MaxSize {
+			return rule, true
+		}
+	}
+	return SizeClassRule{}, false
+}
+
+// sizeClassCompressor returns the function to compress a value of the given
+// size with, and the name of its Codec if it differs from the configured Codec
+// and must be recorded in the header. Returns a nil function if the value
+// shouldn't be compressed.
+func (c *Cache) sizeClassCompressor(size int) (CompressionHook, string) {
+	rule, ok := c.sizeClass(size)
+	if !ok {
+		return c.hooksMixin.current.compress, ""
+	}
+	if rule.Codec == nil {
+		return nil, ""
+	}
+	name := codecName(rule.Codec)
+	if name == c.hooksMixin.codec {
+		return c.hooksMixin.current.compress, ""
+	}
+	// Codecs of size classes bypass the Hook chain, as it's bound to the
+	// configured Codec, so their errors are reported separately
+	return c.reportingCompression(name, "compress", rule.Codec.Flate), name
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jkratz55/rueidis-cache/compression/brotli"
+	"github.com/jkratz55/rueidis-cache/compression/lz4"
+)
+
+func TestCache_WithSizeClassStrategy(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, GZip(), WithSizeClassStrategy([]SizeClassRule{
+		{MaxSize: 0, Codec: brotli.NewCodec(6)},
+		{MaxSize: 4096, Codec: lz4.NewCodec()},
+		{MaxSize: 64, Codec: nil},
+	}))
+
+	ctx := context.Background()
+	values := map[string]string{
+		"small":  "tiny",
+		"medium": strings.Repeat("medium ", 100),
+		"large":  strings.Repeat("large ", 10000),
+	}
+	for key, val := range values {
+		require.NoError(t, rdb.Set(ctx, key, val, 0))
+	}
+
+	header := func(key string) header {
+		raw, err := server.Get(key)
+		require.NoError(t, err)
+		h, _, ok, err := parseHeader([]byte(raw))
+		require.NoError(t, err)
+		require.True(t, ok)
+		return h
+	}
+	assert.NotZero(t, header("small").flags&flagUncompressed)
+	assert.Equal(t, "lz4", header("medium").codec)
+	assert.Equal(t, "brotli", header("large").codec)
+
+	// Values are readable regardless of the size class rules of the reader
+	reader := New(client, GZip())
+	for _, c := range []*Cache{rdb, reader} {
+		for key, val := range values {
+			var s string
+			require.NoError(t, c.Get(ctx, key, &s))
+			assert.Equal(t, val, s)
+		}
+	}
+}
+
+func TestCache_WithSizeClassStrategy_ConfiguredCodec(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, LZ4(), WithSizeClassStrategy([]SizeClassRule{
+		{MaxSize: 16, Codec: nil},
+		{MaxSize: 0, Codec: lz4.NewCodec()},
+	}))
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "key", strings.Repeat("value ", 100), 0))
+
+	// The configured Codec isn't recorded in the header
+	raw, err := server.Get("key")
+	require.NoError(t, err)
+	h, _, ok, err := parseHeader([]byte(raw))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Zero(t, h.flags&(flagCodec|flagUncompressed))
+
+	var s string
+	require.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, strings.Repeat("value ", 100), s)
+}
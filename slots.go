@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/redis/rueidis"
+)
+
+const (
+	// slotCount is the number of hash slots in a Redis Cluster.
+	slotCount = 16384
+
+	// noSlot is the flag rueidis sets on the slot of commands built by the
+	// builder of a non-cluster client.
+	noSlot = uint16(1 << 15)
+)
+
+var (
+	// ErrCrossSlot is an error value that signals a multi-key operation was
+	// attempted on keys that hash to different slots while using Redis Cluster.
+	//
+	// Keys can be forced into the same slot using hash tags, for example the
+	// keys {user:123}:followers and {user:123}:following hash to the same slot.
+	ErrCrossSlot = errors.New("keys in request don't hash to the same slot")
+)
+
+// isCluster reports if the client is a Redis Cluster client. The rueidis command
+// builder only tracks key slots for cluster clients, for all other clients the
+// slot of a command is flagged with noSlot.
+func isCluster(client rueidis.Client) bool {
+	cmd := client.B().Get().Key("").Build()
+	return cmd.Slot()&noSlot == 0
+}
+
+// slot returns the Redis Cluster hash slot for the given key, honoring hash tags.
+func slot(key string) uint16 {
+	return crc16(hashTag(key)) % slotCount
+}
+
+// hashTag returns the part of the key Redis Cluster hashes to determine the slot,
+// which is the hash tag if the key has one and otherwise the entire key.
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// sameSlot reports if all the keys hash to the same slot.
+func sameSlot(keys ...string) bool {
+	for i := 1; i < len(keys); i++ {
+		if slot(keys[i]) != slot(keys[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+// crc16 implements the CRC16-XMODEM checksum used by Redis Cluster to compute
+// hash slots.
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlot(t *testing.T) {
+	assert.Equal(t, uint16(12739), slot("123456789"))
+	assert.Equal(t, uint16(12182), slot("foo"))
+	assert.Equal(t, slot("user1000"), slot("{user1000}.following"))
+	assert.Equal(t, slot("{user1000}.followers"), slot("{user1000}.following"))
+
+	// Empty hash tags are ignored and the entire key is hashed
+	assert.Equal(t, crc16("foo{}bar")%slotCount, slot("foo{}bar"))
+	// Only the first hash tag is used
+	assert.Equal(t, slot("bar"), slot("foo{bar}{zap}"))
+
+	assert.True(t, sameSlot("{a}1", "{a}2", "{a}3"))
+	assert.False(t, sameSlot("a", "b"))
+	assert.True(t, sameSlot())
+}
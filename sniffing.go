@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"bytes"
+)
+
+// sniffFormat guesses the serialization of a value from its leading bytes,
+// returning "json", "msgpack", or an empty string if the format can't be
+// determined.
+//
+// JSON objects, arrays, and strings start with a delimiter while msgpack maps,
+// arrays, strings, and most other types start with a byte >= 0x80, which is never
+// the first byte of valid JSON. Single byte values are ambiguous as msgpack
+// encodes small positive integers as a single byte in the ASCII range.
+func sniffFormat(data []byte) string {
+	if len(data) < 2 {
+		return ""
+	}
+	if data[0] >= 0x80 {
+		return "msgpack"
+	}
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 {
+		return ""
+	}
+	switch trimmed[0] {
+	case '{', '[', '"':
+		return "json"
+	}
+	return ""
+}
+
+// sniffable reports if the serialization of the raw value as stored in Redis
+// should be sniffed. Only values without a header are sniffed, as values with a
+// header were written by this package.
+func (c *Cache) sniffable(raw []byte) bool {
+	if !c.formatSniffing {
+		return false
+	}
+	_, _, framed, _ := c.parseValueHeader(raw)
+	return !framed
+}
+
+// sniffedUnmarshaller returns the Unmarshaller for the sniffed format of the
+// value, or the configured Unmarshaller if the format can't be determined or
+// matches the configured serialization.
+func (c *Cache) sniffedUnmarshaller(data []byte) Unmarshaller {
+	format := sniffFormat(data)
+	if format == "" || format == c.serialization {
+		return c.hooksMixin.current.unmarshall
+	}
+	if format == "json" {
+		return c.reportingUnmarshaller(format, JSONEncoding().Unmarshaller)
+	}
+	return c.reportingUnmarshaller(format, DefaultUnmarshaller())
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		{[]byte(`{"name":"Alice"}`), "json"},
+		{[]byte(` [1,2]`), "json"},
+		{[]byte(`"str"`), "json"},
+		{[]byte(`42`), ""},
+		{[]byte{0x05}, ""},
+		{[]byte{0x81, 0xa1, 'a', 0x01}, "msgpack"},
+		{[]byte{0xa3, 'a', 'b', 'c'}, "msgpack"},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, sniffFormat(test.data), "%q", test.data)
+	}
+}
+
+func TestCache_WithFormatSniffing(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	type person struct {
+		Name string
+		Age  int
+	}
+
+	asJSON, _ := json.Marshal(person{Name: "Alice", Age: 30})
+	asMsgpack, _ := msgpack.Marshal(person{Name: "Bob", Age: 40})
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("json").Value(string(asJSON)).Build()).Error())
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("msgpack").Value(string(asMsgpack)).Build()).Error())
+
+	var p person
+	assert.Error(t, New(client).Get(context.Background(), "json", &p))
+
+	for _, rdb := range []*Cache{
+		New(client, WithFormatSniffing()),
+		New(client, JSON(), WithFormatSniffing()),
+		New(client, LZ4(), WithFormatSniffing()),
+	} {
+		assert.NoError(t, rdb.Get(context.Background(), "json", &p))
+		assert.Equal(t, person{Name: "Alice", Age: 30}, p)
+		assert.NoError(t, rdb.Get(context.Background(), "msgpack", &p))
+		assert.Equal(t, person{Name: "Bob", Age: 40}, p)
+	}
+
+	// Values written by the Cache are still readable
+	rdb := New(client, LZ4(), WithFormatSniffing())
+	assert.NoError(t, rdb.Set(context.Background(), "key", person{Name: "Carl", Age: 50}, 0))
+	assert.NoError(t, rdb.Get(context.Background(), "key", &p))
+	assert.Equal(t, person{Name: "Carl", Age: 50}, p)
+}
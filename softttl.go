@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SetSoftHard adds an entry into the cache with two-tier freshness. Once the soft
+// TTL passes the value is stale but still usable, and once the hard TTL passes
+// the value is removed from the cache. Reading the value with GetSoftHard
+// or GetFreshness reports if it is stale, so callers can keep serving it while
+// triggering a refresh in the background.
+//
+// The soft deadline is stored in the header of the value, in the same field used
+// by WithEarlyExpiration, so GetOrComputeDistributed with early expiration
+// enabled refreshes the value once the soft deadline passes. In migration mode
+// the soft deadline isn't stored and the value is stored like Set with the hard
+// TTL.
+//
+// Both TTLs must be greater than zero, and soft must not exceed hard.
+func (c *Cache) SetSoftHard(ctx context.Context, key string, v any, soft, hard time.Duration) error {
+	if soft <= 0 || hard <= 0 {
+		return fmt.Errorf("soft and hard ttl must be greater than zero")
+	}
+	if soft > hard {
+		return fmt.Errorf("soft ttl %s exceeds hard ttl %s", soft, hard)
+	}
+	if c.migration != nil {
+		return c.Set(ctx, key, v, hard)
+	}
+	return c.setWithHeader(ctx, key, v, hard, header{
+		flags:     flagExpiry,
+		expiresAt: c.clock.Now().Add(soft),
+	})
+}
+
+// GetSoftHard retrieves an entry from the Cache for the given key like Get, and
+// reports if the soft deadline of a value stored with SetSoftHard has passed.
+// Values stored without a soft deadline, or read in migration mode, are never
+// reported as stale. GetSoftHard is a shorthand for GetFreshness reporting if
+// the value is in the FreshnessStale state.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+func (c *Cache) GetSoftHard(ctx context.Context, key string, v any, opts ...CallOption) (stale bool, err error) {
+	result, err := c.GetFreshness(ctx, key, v, opts...)
+	if err != nil {
+		return false, err
+	}
+	return result.State == FreshnessStale, nil
+}
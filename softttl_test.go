@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SetSoftHard(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	clock := &fakeClock{now: time.Now()}
+	rdb := New(client, WithClock(clock))
+	require.NoError(t, rdb.SetSoftHard(context.Background(), "key", "value", time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, server.TTL("key"))
+
+	var val string
+	stale, err := rdb.GetSoftHard(context.Background(), "key", &val)
+	require.NoError(t, err)
+	assert.False(t, stale)
+	assert.Equal(t, "value", val)
+
+	// Past the soft deadline the value is still served but reported stale
+	clock.Advance(time.Minute)
+	val = ""
+	stale, err = rdb.GetSoftHard(context.Background(), "key", &val)
+	require.NoError(t, err)
+	assert.True(t, stale)
+	assert.Equal(t, "value", val)
+
+	// Get reads values stored with SetSoftHard like any other value
+	val = ""
+	require.NoError(t, rdb.Get(context.Background(), "key", &val))
+	assert.Equal(t, "value", val)
+
+	// Values stored without a soft deadline are never stale
+	require.NoError(t, rdb.Set(context.Background(), "plain", "value", 0))
+	stale, err = rdb.GetSoftHard(context.Background(), "plain", &val)
+	require.NoError(t, err)
+	assert.False(t, stale)
+
+	_, err = rdb.GetSoftHard(context.Background(), "missing", &val)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	server.FastForward(time.Hour)
+	_, err = rdb.GetSoftHard(context.Background(), "key", &val)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestCache_SetSoftHard_Invalid(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.Error(t, rdb.SetSoftHard(context.Background(), "key", "value", 0, time.Hour))
+	assert.Error(t, rdb.SetSoftHard(context.Background(), "key", "value", time.Minute, 0))
+	assert.Error(t, rdb.SetSoftHard(context.Background(), "key", "value", time.Hour, time.Minute))
+	assert.False(t, server.Exists("key"))
+}
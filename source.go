@@ -0,0 +1,39 @@
+package cache
+
+// Source indicates the layer that served a value read from the Cache.
+type Source int
+
+const (
+	// SourceNone indicates the value wasn't served by any layer, either because
+	// the key wasn't found or the read failed.
+	SourceNone Source = iota
+
+	// SourceNearCache indicates the value was served from the local client side
+	// cache enabled with the NearCache Option without a round trip to Redis.
+	SourceNearCache
+
+	// SourceRedis indicates the value was read from Redis. When near caching is
+	// enabled this indicates the value wasn't in the local cache and was fetched
+	// from Redis, after which it is cached locally.
+	SourceRedis
+
+	// SourceWriteBuffer indicates the value was served from a write buffered by
+	// WithWriteBatching that hasn't been flushed to Redis yet.
+	SourceWriteBuffer
+)
+
+// String returns the name of the source.
+func (s Source) String() string {
+	switch s {
+	case SourceNone:
+		return "none"
+	case SourceNearCache:
+		return "near-cache"
+	case SourceRedis:
+		return "redis"
+	case SourceWriteBuffer:
+		return "write-buffer"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_GetWithSource(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, "key", "value", 0))
+
+	var s string
+	source, err := rdb.GetWithSource(ctx, "key", &s)
+	assert.NoError(t, err)
+	assert.Equal(t, SourceRedis, source)
+	assert.Equal(t, "value", s)
+
+	source, err = rdb.GetWithSource(ctx, "missing", &s)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, SourceNone, source)
+}
+
+func TestSource_String(t *testing.T) {
+	assert.Equal(t, "none", SourceNone.String())
+	assert.Equal(t, "near-cache", SourceNearCache.String())
+	assert.Equal(t, "redis", SourceRedis.String())
+	assert.Equal(t, "unknown", Source(42).String())
+}
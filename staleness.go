@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Freshness is the freshness state of a value reported by GetFreshness.
+//
+// A key moves between the states as follows:
+//
+//	           write                soft deadline             hard TTL
+//	Missing ─────────────► Fresh ─────────────────► Stale ─────────────► Missing
+//	                         ▲                        │
+//	                         └──────── write ─────────┘
+//
+// Any write, including a refresh of a Stale value, makes the value Fresh again,
+// and deleting the key, or the key expiring, makes it Missing from any state. A
+// value only becomes Stale if it was stored with a soft deadline by SetSoftHard.
+// Values stored by other writes are Fresh until they expire, including values
+// stored with early expiration, as their deadline is their TTL. Values a read
+// treats as a miss, such as values older than the minimum freshness configured
+// with WithMinFreshness or values failing read validation, are Missing.
+type Freshness int
+
+const (
+	// FreshnessMissing indicates the key doesn't exist, or its value is treated
+	// as a miss, so no value was read.
+	FreshnessMissing Freshness = iota
+
+	// FreshnessFresh indicates the value was read and its soft deadline, if any,
+	// hasn't passed.
+	FreshnessFresh
+
+	// FreshnessStale indicates the value was read but its soft deadline has
+	// passed. The value is still usable until its hard TTL passes, but callers
+	// should refresh it.
+	FreshnessStale
+)
+
+// String returns the name of the freshness state.
+func (f Freshness) String() string {
+	switch f {
+	case FreshnessMissing:
+		return "missing"
+	case FreshnessFresh:
+		return "fresh"
+	case FreshnessStale:
+		return "stale"
+	default:
+		return "unknown"
+	}
+}
+
+// FreshnessResult describes the freshness of a value read by GetFreshness.
+type FreshnessResult struct {
+	// State is the freshness state of the value.
+	State Freshness
+
+	// Age is how long ago the value was written. It is only known if the time the
+	// value was written was stored, which GetWithMaxAge always does and other
+	// writes do when WithWriteTimestamps is enabled, otherwise Age is zero and
+	// AgeKnown is false.
+	Age time.Duration
+
+	// AgeKnown reports if Age is known.
+	AgeKnown bool
+}
+
+// GetFreshness retrieves an entry from the Cache for the given key like Get, and
+// reports the freshness of the value and its age, so callers serving stale
+// values can decide whether to trigger a refresh or flag the response as stale.
+// See Freshness for how the state of a key changes. Callers requiring a maximum
+// age rather than a soft deadline can compare the reported Age, or use
+// GetWithMaxAge to reload values exceeding it.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value
+// along with a result in the FreshnessMissing state. In migration mode the header
+// of values isn't read, so values are always reported fresh with an unknown
+// age.
+func (c *Cache) GetFreshness(ctx context.Context, key string, v any, opts ...CallOption) (FreshnessResult, error) {
+	_, data, err := c.read(ctx, key, v, newCallOptions(opts))
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return FreshnessResult{State: FreshnessMissing}, err
+		}
+		return FreshnessResult{}, err
+	}
+	return c.freshness(data), nil
+}
+
+// freshness returns the freshness of the value as stored in Redis. Values
+// without a header, or read in migration mode, are fresh with an unknown age.
+func (c *Cache) freshness(data []byte) FreshnessResult {
+	result := FreshnessResult{State: FreshnessFresh}
+	h, _, ok, err := c.parseValueHeader(data)
+	if err != nil || !ok {
+		return result
+	}
+	now := c.clock.Now()
+	if h.flags&flagExpiry != 0 && !now.Before(h.expiresAt) {
+		result.State = FreshnessStale
+	}
+	if h.flags&flagTimestamp != 0 {
+		result.Age = max(now.Sub(h.writtenAt), 0)
+		result.AgeKnown = true
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetFreshness(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	rdb := New(client, WithClock(clock), WithWriteTimestamps())
+	require.NoError(t, rdb.SetSoftHard(ctx, "key", "value", time.Minute, time.Hour))
+
+	var val string
+	result, err := rdb.GetFreshness(ctx, "key", &val)
+	require.NoError(t, err)
+	assert.Equal(t, "value", val)
+	assert.Equal(t, FreshnessResult{State: FreshnessFresh, AgeKnown: true}, result)
+
+	clock.Advance(time.Minute)
+	result, err = rdb.GetFreshness(ctx, "key", &val)
+	require.NoError(t, err)
+	assert.Equal(t, FreshnessResult{State: FreshnessStale, Age: time.Minute, AgeKnown: true}, result)
+
+	// Rewriting the value makes it fresh again
+	require.NoError(t, rdb.Set(ctx, "key", "refreshed", time.Hour))
+	result, err = rdb.GetFreshness(ctx, "key", &val)
+	require.NoError(t, err)
+	assert.Equal(t, "refreshed", val)
+	assert.Equal(t, FreshnessFresh, result.State)
+
+	result, err = rdb.GetFreshness(ctx, "missing", &val)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.Equal(t, FreshnessMissing, result.State)
+	assert.Equal(t, "missing", result.State.String())
+}
+
+func TestCache_GetFreshness_UnknownAge(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	rdb := New(client)
+	require.NoError(t, rdb.Set(ctx, "key", "value", 0))
+
+	var val string
+	result, err := rdb.GetFreshness(ctx, "key", &val)
+	require.NoError(t, err)
+	assert.Equal(t, FreshnessResult{State: FreshnessFresh}, result)
+	assert.Equal(t, "fresh", result.State.String())
+}
@@ -0,0 +1,372 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+const (
+	// DefaultStreamChunkSize is the default number of bytes read and compressed
+	// at a time by SetStream, and read from Redis at a time by GetStream.
+	DefaultStreamChunkSize = 64 << 10
+
+	// streamPrefix is the prefix of the temporary keys SetStream writes to before
+	// the value is complete.
+	streamPrefix = "rueidis-cache:stream:"
+
+	// streamTempTTL is the TTL of the temporary key written by SetStream, which
+	// ensures the temporary key is removed if the process writing it crashes.
+	streamTempTTL = time.Hour
+
+	// streamTokenLen is the length of the token SetStream writes at the start of
+	// the value, which identifies the value so GetStream can detect the entry
+	// being replaced between reads.
+	streamTokenLen = 32
+)
+
+// ErrStreamInterrupted is an error value that signals a stream was interrupted
+// by a connection error that persisted after retrying, or by the entry changing
+// while it was being read. Data returned by the reader before the error must be
+// discarded.
+var ErrStreamInterrupted = errors.New("stream interrupted")
+
+// streamRetryPolicy is the RetryPolicy for commands of streams interrupted by a
+// connection error.
+var streamRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 50 * time.Millisecond,
+}
+
+// commitStreamScript renames the temporary key at KEYS[1] to KEYS[2] and sets
+// the TTL in milliseconds provided as ARGV[1], or persists the key if the TTL is
+// 0, atomically.
+var commitStreamScript = rueidis.NewLuaScript(`
+redis.call('RENAME', KEYS[1], KEYS[2])
+if tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[1])
+else
+	redis.call('PERSIST', KEYS[2])
+end
+return 1`)
+
+// SetStream adds an entry into the cache with the contents of r, or overwrites
+// an entry if the key already existed. If the ttl value is <= 0 the key will be
+// persisted indefinitely.
+//
+// Unlike Set the value is not marshalled. The contents of r are read in chunks
+// of DefaultStreamChunkSize bytes, unless configured otherwise with
+// WithStreamChunkSize, and each chunk is compressed with the configured Codec and
+// appended to Redis as it is read. This bounds the memory used to store large
+// values such as files to roughly the chunk size. The value is written to a
+// temporary key and only replaces the entry once r is fully read, so readers
+// never observe a partial value.
+//
+// Each chunk is written at its offset in the temporary key, so writing a chunk
+// is idempotent and is transparently retried when interrupted by a connection
+// error, such as the client recycling a connection. Replacing the entry with the
+// temporary key isn't retried, as the outcome of an interrupted replace is
+// unknown. If a connection error persists, or interrupts the replace, an error
+// wrapping ErrStreamInterrupted is returned and the entry may or may not have
+// been replaced.
+//
+// Values written with SetStream are stored in a chunked format and must be read
+// using GetStream. On Redis Cluster the temporary key is stored in the same slot
+// as the key using a hash tag. If the key contains braces that don't form a
+// valid hash tag an error wrapping ErrCrossSlot is returned.
+func (c *Cache) SetStream(ctx context.Context, key string, r io.Reader, ttl time.Duration) error {
+	if c.dryRun {
+		return dryRunUnsupported("SetStream")
+	}
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return err
+	}
+	token, err := lockToken()
+	if err != nil {
+		return err
+	}
+	tempKey := streamPrefix + "{" + hashTag(redisKey) + "}:" + token
+	if c.cluster && !sameSlot(tempKey, redisKey) {
+		return fmt.Errorf("temporary key for key %s: %w", key, ErrCrossSlot)
+	}
+
+	// A buffered write to the key would otherwise overwrite the stream when flushed
+	if err := c.FlushWrites(ctx); err != nil {
+		return err
+	}
+
+	if err := c.appendStream(ctx, tempKey, token, r); err != nil {
+		_ = c.redis.Do(context.WithoutCancel(ctx), c.redis.B().Del().Key(tempKey).Build()).Error()
+		return err
+	}
+
+	if ttl < 0 {
+		ttl = 0
+	}
+	err = commitStreamScript.Exec(ctx, c.redis, []string{tempKey, redisKey},
+		[]string{fmt.Sprint(ttl.Milliseconds())}).Error()
+	if err != nil {
+		_ = c.redis.Do(context.WithoutCancel(ctx), c.redis.B().Del().Key(tempKey).Build()).Error()
+		if connectionError(err) {
+			return fmt.Errorf("%w: redis: %w", ErrStreamInterrupted, err)
+		}
+		return fmt.Errorf("redis: %w", err)
+	}
+	return nil
+}
+
+// appendStream writes the token to the temporary key, then reads r in chunks
+// and appends each compressed chunk prefixed with its uvarint length.
+func (c *Cache) appendStream(ctx context.Context, tempKey, token string, r io.Reader) error {
+	if len(token) != streamTokenLen {
+		return fmt.Errorf("invalid stream token length %d", len(token))
+	}
+	if err := c.writeChunk(ctx, tempKey, 0, []byte(token)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, c.streamChunkSize)
+	frame := make([]byte, 0, binary.MaxVarintLen64+c.streamChunkSize)
+	offset := int64(streamTokenLen)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			data, err := c.hooksMixin.current.compress(buf[:n])
+			if err != nil {
+				return fmt.Errorf("compress chunk: %w", err)
+			}
+			frame = binary.AppendUvarint(frame[:0], uint64(len(data)))
+			frame = append(frame, data...)
+			if err := c.writeChunk(ctx, tempKey, offset, frame); err != nil {
+				return err
+			}
+			offset += int64(len(frame))
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("read stream: %w", readErr)
+		}
+	}
+	return nil
+}
+
+// writeChunk writes the framed chunk at the offset of the temporary key,
+// retrying if interrupted by a connection error.
+func (c *Cache) writeChunk(ctx context.Context, tempKey string, offset int64, frame []byte) error {
+	_, err := retry(ctx, streamRetryPolicy, func(ctx context.Context) (struct{}, error) {
+		for _, res := range c.redis.DoMulti(ctx,
+			c.redis.B().Setrange().Key(tempKey).Offset(offset).Value(string(frame)).Build(),
+			c.redis.B().Expire().Key(tempKey).Seconds(int64(streamTempTTL.Seconds())).Build()) {
+			if err := res.Error(); err != nil {
+				return struct{}{}, streamError(err)
+			}
+		}
+		return struct{}{}, nil
+	})
+	return unwrapRetryable(err)
+}
+
+// GetStream retrieves an entry written with SetStream from the Cache for the
+// given key and returns a reader for its contents. The value is read from Redis
+// in ranges of DefaultStreamChunkSize bytes, unless configured otherwise with
+// WithStreamChunkSize, and decompressed one chunk at a time as the reader is
+// consumed.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+// The value is read lazily, so the entry may be overwritten or expire while the
+// reader is being consumed. SetStream writes a random token with every value,
+// which is read along with every range, so the reader returns an error wrapping
+// ErrStreamInterrupted rather than data from a different value, even if both
+// values have the same length.
+//
+// Reading a range is idempotent, so reads interrupted by a connection error,
+// such as the client recycling a connection, are transparently retried. If the
+// connection error persists the reader returns an error wrapping
+// ErrStreamInterrupted.
+func (c *Cache) GetStream(ctx context.Context, key string) (io.Reader, error) {
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := retry(ctx, streamRetryPolicy, func(ctx context.Context) (streamVersion, error) {
+		return c.streamVersion(ctx, redisKey)
+	})
+	if err != nil {
+		return nil, unwrapRetryable(err)
+	}
+	// STRLEN returns 0 for missing keys, and values written by SetStream are
+	// never empty as they start with the token.
+	if v.size == 0 {
+		c.hooksMixin.miss(key)
+		return nil, ErrKeyNotFound
+	}
+	if v.size < streamTokenLen {
+		return nil, fmt.Errorf("invalid stream value for key %s", key)
+	}
+	c.hooksMixin.hit(key)
+	return &streamReader{ctx: ctx, c: c, redisKey: redisKey, version: v, offset: streamTokenLen}, nil
+}
+
+// streamVersion identifies the value of an entry written by SetStream.
+type streamVersion struct {
+	size  int64  // length of the value in Redis
+	token string // token written at the start of the value
+}
+
+// streamVersion reads the length and token of the value at redisKey. Errors are
+// wrapped with streamError so they can be retried.
+func (c *Cache) streamVersion(ctx context.Context, redisKey string) (streamVersion, error) {
+	res := c.redis.DoMulti(ctx,
+		c.redis.B().Strlen().Key(redisKey).Build(),
+		c.redis.B().Getrange().Key(redisKey).Start(0).End(streamTokenLen-1).Build())
+	size, err := res[0].AsInt64()
+	if err != nil {
+		return streamVersion{}, streamError(err)
+	}
+	token, err := res[1].ToString()
+	if err != nil {
+		return streamVersion{}, streamError(err)
+	}
+	return streamVersion{size: size, token: token}, nil
+}
+
+// streamReader reads a value written by SetStream from Redis in ranges and
+// decompresses it one chunk at a time.
+type streamReader struct {
+	ctx      context.Context
+	c        *Cache
+	redisKey string
+	version  streamVersion // version of the value when the reader was opened
+	offset   int64         // offset of the next range to read from Redis
+	raw      []byte        // bytes read from Redis that haven't been parsed
+	chunk    []byte        // decompressed bytes that haven't been returned
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	for len(s.chunk) == 0 {
+		if err := s.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, s.chunk)
+	s.chunk = s.chunk[n:]
+	return n, nil
+}
+
+// next parses and decompresses the next chunk, reading more of the value from
+// Redis as needed.
+func (s *streamReader) next() error {
+	for {
+		l, n := binary.Uvarint(s.raw)
+		if n > 0 && uint64(len(s.raw)-n) >= l {
+			data, err := s.c.hooksMixin.current.decompress(s.raw[n : n+int(l)])
+			if err != nil {
+				return fmt.Errorf("decompress chunk: %w", err)
+			}
+			s.raw = s.raw[n+int(l):]
+			s.chunk = data
+			return nil
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid chunk length")
+		}
+		if s.offset >= s.version.size {
+			if len(s.raw) > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return io.EOF
+		}
+		if err := s.fill(); err != nil {
+			return err
+		}
+	}
+}
+
+// fill reads the next range of the value from Redis, retrying if interrupted by
+// a connection error. The token of the value is read after the range in the same
+// pipeline, so a range read from a value that replaced the entry is detected.
+func (s *streamReader) fill() error {
+	c := s.c
+	end := s.offset + int64(c.streamChunkSize) - 1
+	data, err := retry(s.ctx, streamRetryPolicy, func(ctx context.Context) ([]byte, error) {
+		res := c.redis.DoMulti(ctx,
+			c.redis.B().Getrange().Key(s.redisKey).Start(s.offset).End(end).Build(),
+			c.redis.B().Getrange().Key(s.redisKey).Start(0).End(streamTokenLen-1).Build())
+		data, err := res[0].AsBytes()
+		if err != nil {
+			return nil, streamError(err)
+		}
+		token, err := res[1].ToString()
+		if err != nil {
+			return nil, streamError(err)
+		}
+		if token != s.version.token {
+			return nil, NonRetryable(fmt.Errorf("%w: entry changed while reading", ErrStreamInterrupted))
+		}
+		return data, nil
+	})
+	if err != nil {
+		return unwrapRetryable(err)
+	}
+	if len(data) == 0 {
+		return io.ErrUnexpectedEOF
+	}
+	s.offset += int64(len(data))
+	s.raw = append(s.raw, data...)
+	return nil
+}
+
+// streamError wraps an error of a stream command. Connection errors wrap
+// ErrStreamInterrupted and are retried, all other errors aren't retried.
+func streamError(err error) error {
+	if connectionError(err) {
+		return fmt.Errorf("%w: redis: %w", ErrStreamInterrupted, err)
+	}
+	return NonRetryable(fmt.Errorf("redis: %w", err))
+}
+
+// unwrapRetryable returns the cause of errors wrapped with NonRetryable.
+func unwrapRetryable(err error) error {
+	var re RetryableError
+	if errors.As(err, &re) {
+		return re.cause
+	}
+	return err
+}
+
+// connectionError reports if err is caused by the connection to Redis rather
+// than Redis rejecting the command, so the command may succeed if retried once
+// the client reconnects.
+func connectionError(err error) bool {
+	if err == nil || errors.Is(err, rueidis.Nil) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if _, ok := rueidis.IsRedisErr(err); ok {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, rueidis.ErrClosing)
+}
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SetStream_GetStream(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	const chunkSize = 16
+	sizes := []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, chunkSize * 3, chunkSize*10 + 7}
+
+	for _, rdb := range []*Cache{
+		New(client, WithStreamChunkSize(chunkSize)),
+		New(client, WithStreamChunkSize(chunkSize), LZ4()),
+		New(client, WithStreamChunkSize(chunkSize), GZip()),
+	} {
+		for _, size := range sizes {
+			value := []byte(strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyz", size/36+1)[:size])
+			require.NoError(t, rdb.SetStream(context.Background(), "key", bytes.NewReader(value), time.Minute))
+			assert.Equal(t, time.Minute, server.TTL("key"))
+
+			r, err := rdb.GetStream(context.Background(), "key")
+			require.NoError(t, err)
+			actual, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, value, actual, "size %d", size)
+		}
+	}
+
+	// Temporary keys are renamed once the stream is written
+	assert.Equal(t, []string{"key"}, server.Keys())
+
+	rdb := New(client)
+	_, err := rdb.GetStream(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// Overwriting without a TTL persists the key
+	require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader("value"), 0))
+	assert.Zero(t, server.TTL("key"))
+}
+
+func TestCache_SetStream_ReadError(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithStreamChunkSize(4))
+	require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader("original"), 0))
+
+	r := io.MultiReader(strings.NewReader("partial value"), iotest.ErrReader(io.ErrClosedPipe))
+	assert.Error(t, rdb.SetStream(context.Background(), "key", r, 0))
+
+	// The entry is untouched and the temporary key removed
+	assert.Equal(t, []string{"key"}, server.Keys())
+	stream, err := rdb.GetStream(context.Background(), "key")
+	require.NoError(t, err)
+	actual, err := io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, "original", string(actual))
+}
+
+// interruptingHook is a rueidishook.Hook failing the first commands with the
+// given name with a connection error, by sending them through a closed client.
+type interruptingHook struct {
+	cacheCallCounter
+	dead     rueidis.Client
+	command  string
+	failures atomic.Int32
+}
+
+func (h *interruptingHook) intercept(client rueidis.Client, cmds ...rueidis.Completed) rueidis.Client {
+	for _, cmd := range cmds {
+		if cmd.Commands()[0] == h.command && h.failures.Add(-1) >= 0 {
+			return h.dead
+		}
+	}
+	return client
+}
+
+func (h *interruptingHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	return h.intercept(client, cmd).Do(ctx, cmd)
+}
+
+func (h *interruptingHook) DoMulti(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	return h.intercept(client, multi...).DoMulti(ctx, multi...)
+}
+
+func newInterruptingHook(t *testing.T, command string, failures int32) *interruptingHook {
+	s := mockRedis()
+	t.Cleanup(s.Close)
+	dead, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:       []string{s.Addr()},
+		DisableCache:      true,
+		DisableRetry:      true,
+		ForceSingleClient: true,
+	})
+	require.NoError(t, err)
+	// Closing the client fails every command with a connection error
+	dead.Close()
+
+	h := &interruptingHook{dead: dead, command: command}
+	h.failures.Store(failures)
+	return h
+}
+
+func TestCache_SetStream_GetStream_Interrupted(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	payload := strings.Repeat("stream me ", 1000)
+
+	// Interrupted writes of chunks are retried
+	writes := newInterruptingHook(t, "SETRANGE", 2)
+	rdb := New(rueidishook.WithHook(client, writes), WithStreamChunkSize(1024))
+	require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader(payload), 0))
+	assert.Negative(t, writes.failures.Load())
+
+	// Interrupted reads of the version when opening the stream are retried
+	reads := newInterruptingHook(t, "GETRANGE", 2)
+	rdb = New(rueidishook.WithHook(client, reads), WithStreamChunkSize(1024))
+	r, err := rdb.GetStream(context.Background(), "key")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, payload, string(data))
+	assert.Negative(t, reads.failures.Load())
+
+	// Interrupted reads of ranges are retried
+	reads = newInterruptingHook(t, "GETRANGE", 0)
+	rdb = New(rueidishook.WithHook(client, reads), WithStreamChunkSize(1024))
+	r, err = rdb.GetStream(context.Background(), "key")
+	require.NoError(t, err)
+	reads.failures.Store(2)
+	data, err = io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, payload, string(data))
+	assert.Negative(t, reads.failures.Load())
+
+	// Connection errors that persist after retrying interrupt the stream
+	reads = newInterruptingHook(t, "GETRANGE", 0)
+	rdb = New(rueidishook.WithHook(client, reads), WithStreamChunkSize(1024))
+	r, err = rdb.GetStream(context.Background(), "key")
+	require.NoError(t, err)
+	reads.failures.Store(100)
+	_, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrStreamInterrupted)
+
+	_, err = rdb.GetStream(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrStreamInterrupted)
+
+	writes = newInterruptingHook(t, "SETRANGE", 100)
+	rdb = New(rueidishook.WithHook(client, writes), WithStreamChunkSize(1024))
+	err = rdb.SetStream(context.Background(), "other", strings.NewReader(payload), 0)
+	assert.ErrorIs(t, err, ErrStreamInterrupted)
+	assert.False(t, server.Exists("other"))
+}
+
+func TestCache_GetStream_ChangedWhileInterrupted(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithStreamChunkSize(16))
+	payload := strings.Repeat("stream me ", 100)
+
+	tests := []struct {
+		name        string
+		replacement string
+	}{
+		{name: "DifferentLength", replacement: "short"},
+		// The values have the same length, so only the token tells them apart
+		{name: "SameLength", replacement: strings.Repeat("replaced! ", 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader(payload), 0))
+
+			reads := newInterruptingHook(t, "GETRANGE", 0)
+			interrupted := New(rueidishook.WithHook(client, reads), WithStreamChunkSize(16))
+			r, err := interrupted.GetStream(context.Background(), "key")
+			require.NoError(t, err)
+
+			// The entry is replaced while the connection is down
+			reads.failures.Store(1)
+			require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader(tt.replacement), 0))
+			_, err = io.ReadAll(r)
+			assert.ErrorIs(t, err, ErrStreamInterrupted)
+		})
+	}
+}
+
+func TestCache_GetStream_InvalidValue(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	require.NoError(t, server.Set("key", "not a stream"))
+	_, err := New(client).GetStream(context.Background(), "key")
+	assert.Error(t, err)
+}
+
+func TestCache_GetStream_ChangedWhileReading(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithStreamChunkSize(16))
+	require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader(strings.Repeat("stream me ", 100)), 0))
+
+	r, err := rdb.GetStream(context.Background(), "key")
+	require.NoError(t, err)
+	buf := make([]byte, 1)
+	_, err = r.Read(buf)
+	require.NoError(t, err)
+
+	// The entry is replaced by a value of the same length between two ranges
+	require.NoError(t, rdb.SetStream(context.Background(), "key", strings.NewReader(strings.Repeat("replaced! ", 100)), 0))
+	_, err = io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrStreamInterrupted)
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// streamCodec returns the Codec values are compressed with if values can be
+// marshalled directly into the compressor with StreamingCompression, rather than
+// being buffered once marshalled.
+//
+// Only msgpack is marshalled as a stream, as the JSON encoder buffers the entire
+// value before writing it. Hooks, captures, inspectors, and the Options deciding
+// whether or how to compress a value by its marshalled size need the marshalled
+// value, so values are buffered when any of them are configured.
+func (c *Cache) streamCodec() (StreamCodec, bool) {
+	if !c.streamingCompression || c.bare || c.migration != nil || c.serialization != "msgpack" {
+		return nil, false
+	}
+	if len(c.hooksMixin.hooks) > 0 || c.captureFn != nil || c.inspector != nil ||
+		c.compressSmaller || c.compressAbove > 0 || len(c.sizeClasses) > 0 ||
+		!c.shouldCompress(c.serialization) {
+		return nil, false
+	}
+	codec, ok := c.codec.(StreamCodec)
+	return codec, ok
+}
+
+// marshalCompressed marshals the value with msgpack directly into a compressor
+// writing to a buffer, so the uncompressed value is never held in memory in its
+// entirety. The context is checked every time the encoder writes to the
+// compressor, so large values stop being marshalled once the context is done.
+func marshalCompressed(ctx context.Context, codec StreamCodec, v any) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := codec.NewWriter(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("compress value: %w", err)
+	}
+	if err := msgpack.NewEncoder(ctxWriter{ctx: ctx, w: w}).Encode(v); err != nil {
+		_ = w.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("marshall value: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("compress value: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ctxWriter is an io.Writer failing with the error of the context once it is
+// done, rather than writing to the underlying io.Writer.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w ctxWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
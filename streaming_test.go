@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/jkratz55/rueidis-cache/compression/gzip"
+)
+
+// countingStreamCodec is a StreamCodec counting how values are compressed.
+type countingStreamCodec struct {
+	*gzip.Codec
+	flated  int
+	writers int
+}
+
+func (c *countingStreamCodec) Flate(data []byte) ([]byte, error) {
+	c.flated++
+	return c.Codec.Flate(data)
+}
+
+func (c *countingStreamCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	c.writers++
+	return c.Codec.NewWriter(w)
+}
+
+type largeValue struct {
+	ID    int
+	Items []string
+}
+
+func newLargeValue(items int) largeValue {
+	v := largeValue{ID: 1, Items: make([]string, items)}
+	for i := range v.Items {
+		v.Items[i] = strings.Repeat("item", 16)
+	}
+	return v
+}
+
+func TestCache_StreamingCompression(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	codec := &countingStreamCodec{Codec: gzip.NewCodec(6)}
+	rdb := New(client, Compression(codec), StreamingCompression(), WithWriteTimestamps())
+
+	value := newLargeValue(1000)
+	require.NoError(t, rdb.Set(ctx, "key", value, 0))
+	assert.Equal(t, 1, codec.writers)
+	assert.Equal(t, 0, codec.flated)
+
+	var v largeValue
+	require.NoError(t, rdb.Get(ctx, "key", &v))
+	assert.Equal(t, value, v)
+
+	// Values compressed as a stream are read like any other value
+	v = largeValue{}
+	require.NoError(t, New(client, GZip(), WithWriteTimestamps()).Get(ctx, "key", &v))
+	assert.Equal(t, value, v)
+}
+
+func TestCache_StreamingCompression_Fallback(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	value := newLargeValue(10)
+
+	// The serialization or Codec don't support streaming, or a Hook needs the
+	// marshalled value
+	tests := map[string][]Option{
+		"json":       {JSON()},
+		"hook":       {},
+		"threshold":  {CompressAbove(1)},
+		"size class": {WithSizeClassStrategy([]SizeClassRule{{MaxSize: 1 << 20, Codec: gzip.NewCodec(6)}})},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			codec := &countingStreamCodec{Codec: gzip.NewCodec(6)}
+			rdb := New(client, append([]Option{Compression(codec), StreamingCompression()}, opts...)...)
+			if name == "hook" {
+				rdb.AddHook(&refreshRecorder{})
+			}
+			require.NoError(t, rdb.Set(ctx, name, value, 0))
+			assert.Equal(t, 0, codec.writers)
+
+			var v largeValue
+			require.NoError(t, rdb.Get(ctx, name, &v))
+			assert.Equal(t, value, v)
+		})
+	}
+
+	// Codecs not implementing StreamCodec are used as is
+	rdb := New(client, LZ4(), StreamingCompression())
+	require.NoError(t, rdb.Set(ctx, "lz4", value, 0))
+	var v largeValue
+	require.NoError(t, rdb.Get(ctx, "lz4", &v))
+	assert.Equal(t, value, v)
+}
+
+// BenchmarkEncode_LargeValue compares the memory used to marshal and compress a
+// large value with and without StreamingCompression.
+func BenchmarkEncode_LargeValue(b *testing.B) {
+	setup()
+	defer tearDown()
+
+	ctx := context.Background()
+	value := newLargeValue(100_000)
+	for name, opts := range map[string][]Option{
+		"buffered":  {GZip()},
+		"streaming": {GZip(), StreamingCompression()},
+	} {
+		rdb := New(client, opts...)
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := rdb.encode(ctx, "key", value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// cancelOnEncode cancels the context while the value containing it is marshalled.
+type cancelOnEncode struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelOnEncode) EncodeMsgpack(enc *msgpack.Encoder) error {
+	c.cancel()
+	return enc.EncodeNil()
+}
+
+func TestCache_StreamingCompression_Canceled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	codec := &countingStreamCodec{Codec: gzip.NewCodec(6)}
+	rdb := New(client, Compression(codec), StreamingCompression())
+
+	value := struct {
+		Trigger cancelOnEncode
+		Items   []string
+	}{
+		Trigger: cancelOnEncode{cancel: cancel},
+		Items:   newLargeValue(1000).Items,
+	}
+	err := rdb.Set(ctx, "key", value, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, codec.writers)
+	assert.False(t, server.Exists("key"))
+}
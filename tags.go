@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+)
+
+type tagsKey struct{}
+
+// ContextWithTags returns a copy of ctx carrying the provided tags in addition to
+// any tags already carried by ctx. Tags identify the operation a context is used
+// for, such as a request ID or a classifier label, and are propagated through
+// the Cache to the Redis client so instrumentation at both layers can record the
+// same dimensions. Tags provided here take precedence over existing tags with
+// the same name.
+//
+// Tags recorded as metric attributes should have a low cardinality, so request
+// scoped identifiers are better suited for tracing than for metrics.
+func ContextWithTags(ctx context.Context, tags map[string]string) context.Context {
+	if len(tags) == 0 {
+		return ctx
+	}
+	merged := maps.Clone(TagsFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(ctx, tagsKey{}, merged)
+}
+
+// TagsFromContext returns the tags carried by ctx, or nil if ctx doesn't carry
+// any tags. The returned map must not be modified.
+func TagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey{}).(map[string]string)
+	return tags
+}
+
+// tagClient wraps the client so the context of every command carries the tags
+// configured with WithTags. Contexts provided to the Cache are passed through to
+// the client as is, so tags added with ContextWithTags reach the client without
+// wrapping it.
+func (c *Cache) tagClient(client rueidis.Client) rueidis.Client {
+	if len(c.tags) == 0 {
+		return client
+	}
+	return rueidishook.WithHook(client, tagsHook{tags: c.tags})
+}
+
+// tagsHook is a rueidishook.Hook adding the tags of a Cache to the context of
+// every command. Tags already carried by the context take precedence.
+type tagsHook struct {
+	tags map[string]string
+}
+
+func (h tagsHook) ctx(ctx context.Context) context.Context {
+	tags := maps.Clone(h.tags)
+	maps.Copy(tags, TagsFromContext(ctx))
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+func (h tagsHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	return client.Do(h.ctx(ctx), cmd)
+}
+
+func (h tagsHook) DoMulti(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	return client.DoMulti(h.ctx(ctx), multi...)
+}
+
+func (h tagsHook) DoCache(client rueidis.Client, ctx context.Context, cmd rueidis.Cacheable, ttl time.Duration) rueidis.RedisResult {
+	return client.DoCache(h.ctx(ctx), cmd, ttl)
+}
+
+func (h tagsHook) DoMultiCache(client rueidis.Client, ctx context.Context, multi ...rueidis.CacheableTTL) []rueidis.RedisResult {
+	return client.DoMultiCache(h.ctx(ctx), multi...)
+}
+
+func (h tagsHook) Receive(client rueidis.Client, ctx context.Context, subscribe rueidis.Completed, fn func(msg rueidis.PubSubMessage)) error {
+	return client.Receive(h.ctx(ctx), subscribe, fn)
+}
+
+func (h tagsHook) DoStream(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResultStream {
+	return client.DoStream(h.ctx(ctx), cmd)
+}
+
+func (h tagsHook) DoMultiStream(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) rueidis.MultiRedisResultStream {
+	return client.DoMultiStream(h.ctx(ctx), multi...)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis"
+	"github.com/redis/rueidis/rueidishook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHook struct {
+	tags []map[string]string
+}
+
+func (h *recordingHook) Do(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResult {
+	h.tags = append(h.tags, TagsFromContext(ctx))
+	return client.Do(ctx, cmd)
+}
+
+func (h *recordingHook) DoMulti(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) []rueidis.RedisResult {
+	h.tags = append(h.tags, TagsFromContext(ctx))
+	return client.DoMulti(ctx, multi...)
+}
+
+func (h *recordingHook) DoCache(client rueidis.Client, ctx context.Context, cmd rueidis.Cacheable, ttl time.Duration) rueidis.RedisResult {
+	h.tags = append(h.tags, TagsFromContext(ctx))
+	return client.DoCache(ctx, cmd, ttl)
+}
+
+func (h *recordingHook) DoMultiCache(client rueidis.Client, ctx context.Context, multi ...rueidis.CacheableTTL) []rueidis.RedisResult {
+	h.tags = append(h.tags, TagsFromContext(ctx))
+	return client.DoMultiCache(ctx, multi...)
+}
+
+func (h *recordingHook) Receive(client rueidis.Client, ctx context.Context, subscribe rueidis.Completed, fn func(msg rueidis.PubSubMessage)) error {
+	return client.Receive(ctx, subscribe, fn)
+}
+
+func (h *recordingHook) DoStream(client rueidis.Client, ctx context.Context, cmd rueidis.Completed) rueidis.RedisResultStream {
+	return client.DoStream(ctx, cmd)
+}
+
+func (h *recordingHook) DoMultiStream(client rueidis.Client, ctx context.Context, multi ...rueidis.Completed) rueidis.MultiRedisResultStream {
+	return client.DoMultiStream(ctx, multi...)
+}
+
+func TestContextWithTags(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, TagsFromContext(ctx))
+	assert.Equal(t, ctx, ContextWithTags(ctx, nil))
+
+	ctx = ContextWithTags(ctx, map[string]string{"a": "1", "b": "2"})
+	ctx = ContextWithTags(ctx, map[string]string{"b": "3"})
+	assert.Equal(t, map[string]string{"a": "1", "b": "3"}, TagsFromContext(ctx))
+}
+
+func TestCache_WithTags(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	hook := &recordingHook{}
+	rdb := New(rueidishook.WithHook(client, hook), WithTags(map[string]string{"cache": "users", "op": "default"}))
+	assert.Equal(t, map[string]string{"cache": "users", "op": "default"}, rdb.Config().Tags)
+
+	ctx := ContextWithTags(context.Background(), map[string]string{"op": "profile"})
+	require.NoError(t, rdb.Set(ctx, "key", "value", 0))
+	var val string
+	require.NoError(t, rdb.Get(context.Background(), "key", &val))
+
+	assert.Equal(t, []map[string]string{
+		{"cache": "users", "op": "profile"},
+		{"cache": "users", "op": "default"},
+	}, hook.tags)
+
+	// Without WithTags the tags of the context are passed through as is
+	hook.tags = nil
+	rdb = New(rueidishook.WithHook(client, hook))
+	require.NoError(t, rdb.Set(ctx, "key", "value", 0))
+	assert.Equal(t, []map[string]string{{"op": "profile"}}, hook.tags)
+}
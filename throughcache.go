@@ -32,6 +32,7 @@ func Cacheable[T any](
 	fn func(ctx context.Context) (T, error)) (T, error) {
 
 	var val T
+	ctx, end := c.hooksMixin.startCacheAside(ctx, "Cacheable")
 
 	// Create a context with a timeout for the read operation. The purpose of
 	// this is to bypass the cache if the read from cache is slow.
@@ -40,14 +41,22 @@ func Cacheable[T any](
 
 	// Try to read the value from cache first. If the retrieval is successful
 	// return the value as there is no need to go to the source system.
-	if err := c.Get(readCtx, key, &val); err == nil {
+	readCtx, endRead := c.hooksMixin.startCacheAsidePhase(readCtx, PhaseRead)
+	err := c.Get(readCtx, key, &val)
+	endRead(err)
+	if err == nil {
+		end(CacheAsideResult{Hits: 1})
 		return val, nil
 	}
 
 	// Either we've encountered a cache miss or an error. In either case, we
 	// need to go to the source system to retrieve the value or recompute the
 	// result.
-	val, err := fn(ctx)
+	start := time.Now()
+	loadCtx, endLoad := c.hooksMixin.startCacheAsidePhase(ctx, PhaseLoad)
+	val, err = fn(loadCtx)
+	endLoad(err)
+	end(CacheAsideResult{Misses: 1, LoadDuration: time.Since(start), Err: err})
 	if err != nil {
 		// If func to retrieve or compute the value fails nothing further can
 		// be done. Return the error.
@@ -57,10 +66,12 @@ func Cacheable[T any](
 	// If the value was successfully retrieved or computed, store it in the
 	// cache with the configured TTL in a background goroutine to avoid blocking
 	// returning the result to the caller.
+	writeCtx, endWrite := c.hooksMixin.startCacheAsidePhase(context.WithoutCancel(ctx), PhaseWrite)
 	go func() {
-		setCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		setCtx, cancel := context.WithTimeout(writeCtx, 200*time.Millisecond)
 		defer cancel()
-		_, err = c.SetIfAbsent(setCtx, key, val, ttl)
+		_, err := c.SetIfAbsent(setCtx, key, val, ttl)
+		endWrite(err)
 		if err != nil {
 			slog.Error(fmt.Sprintf("Failed to update cache for key %s", key),
 				slog.Any("err", err))
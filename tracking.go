@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"strings"
+
+	"github.com/redis/rueidis"
+)
+
+// TrackingMode is the mode of server assisted client side caching used to track
+// keys cached locally with the NearCache Option.
+type TrackingMode int
+
+const (
+	// OptIn is the default tracking mode used by rueidis. Redis remembers every
+	// key read by the client with caching enabled and sends an invalidation when
+	// one of those keys is modified. Tracking is precise, but Redis uses memory
+	// for each tracked key and client.
+	OptIn TrackingMode = iota
+
+	// Broadcast tracks keys by prefix. Redis doesn't remember which keys a client
+	// has read and instead sends an invalidation for every modified key matching
+	// a prefix the client subscribed to. This uses no memory in Redis per key,
+	// which suits wide keyspaces, at the cost of the client receiving
+	// invalidations for keys it never read.
+	Broadcast
+)
+
+// String returns the name of the tracking mode.
+func (m TrackingMode) String() string {
+	switch m {
+	case OptIn:
+		return "optin"
+	case Broadcast:
+		return "bcast"
+	default:
+		return "unknown"
+	}
+}
+
+// TrackingOptions returns the CLIENT TRACKING options for the provided mode and
+// prefixes to be used for the ClientTrackingOptions field of rueidis.ClientOption.
+//
+// The tracking mode is a property of the rueidis client and can't be changed by
+// the Cache, so the client must be created with the options returned by
+// TrackingOptions when using the NearCacheMode Option.
+//
+//	client, err := rueidis.NewClient(rueidis.ClientOption{
+//		InitAddress:           []string{"127.0.0.1:6379"},
+//		ClientTrackingOptions: cache.TrackingOptions(cache.Broadcast, "user:"),
+//	})
+//	rdb := cache.New(client, cache.NearCache(time.Minute), cache.NearCacheMode(cache.Broadcast, "user:"))
+func TrackingOptions(mode TrackingMode, prefixes ...string) []string {
+	if mode != Broadcast {
+		return []string{"OPTIN"}
+	}
+	opts := make([]string, 0, len(prefixes)*2+1)
+	for _, prefix := range prefixes {
+		opts = append(opts, "PREFIX", prefix)
+	}
+	return append(opts, "BCAST")
+}
+
+// nearCacheable reports if reads of the keys can be served from the local cache.
+// Keys outside the configured prefixes are always read from Redis as Redis won't
+// send invalidations for them in Broadcast mode.
+func (c *Cache) nearCacheable(redisKeys ...string) bool {
+	if !c.nearCacheEnabled {
+		return false
+	}
+	if len(c.nearCachePrefix) > 0 {
+		for _, key := range redisKeys {
+			if !c.hasNearCachePrefix(key) {
+				return false
+			}
+		}
+	}
+	for _, key := range redisKeys {
+		if c.oversized(key) {
+			c.hooksMixin.nearCacheSkipped(key)
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cache) hasNearCachePrefix(redisKey string) bool {
+	for _, prefix := range c.nearCachePrefix {
+		if strings.HasPrefix(redisKey, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// oversized reports if the value of the key was found to exceed the size
+// configured with NearCacheMaxValueSize.
+func (c *Cache) oversized(redisKey string) bool {
+	if c.nearCacheMaxVal <= 0 {
+		return false
+	}
+	_, ok := c.nearCacheLarge.Load(redisKey)
+	return ok
+}
+
+// observeSize records if the value of the key read from Redis exceeds the size
+// configured with NearCacheMaxValueSize, so later reads of the key bypass the
+// near cache. Keys whose value is within the limit or that don't exist are
+// forgotten.
+func (c *Cache) observeSize(redisKey string, msg rueidis.RedisMessage) {
+	if c.nearCacheMaxVal <= 0 || !c.nearCacheEnabled {
+		return
+	}
+	if len(c.nearCachePrefix) > 0 && !c.hasNearCachePrefix(redisKey) {
+		return
+	}
+	if val, err := msg.ToString(); err == nil && len(val) > c.nearCacheMaxVal {
+		c.nearCacheLarge.Store(redisKey, struct{}{})
+		return
+	}
+	c.nearCacheLarge.Delete(redisKey)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/rueidis/rueidishook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackingOptions(t *testing.T) {
+	assert.Equal(t, []string{"OPTIN"}, TrackingOptions(OptIn))
+	assert.Equal(t, []string{"BCAST"}, TrackingOptions(Broadcast))
+	assert.Equal(t, []string{"PREFIX", "user:", "PREFIX", "order:", "BCAST"},
+		TrackingOptions(Broadcast, "user:", "order:"))
+}
+
+func TestCache_NearCacheable(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	assert.False(t, rdb.nearCacheable("user:1"))
+
+	rdb = New(client, NearCache(time.Minute))
+	assert.True(t, rdb.nearCacheable("user:1", "order:1"))
+
+	rdb = New(client, NearCache(time.Minute), NearCacheMode(Broadcast, "user:"))
+	assert.True(t, rdb.nearCacheable("user:1", "user:2"))
+	assert.False(t, rdb.nearCacheable("user:1", "order:1"))
+	assert.Equal(t, Broadcast, rdb.Config().NearCacheMode)
+	assert.Equal(t, []string{"user:"}, rdb.Config().NearCachePrefixes)
+}
+
+type nearCacheSkippedHook struct {
+	skipped []string
+}
+
+func (h *nearCacheSkippedHook) MarshalHook(next Marshaller) Marshaller            { return next }
+func (h *nearCacheSkippedHook) UnmarshallHook(next Unmarshaller) Unmarshaller     { return next }
+func (h *nearCacheSkippedHook) CompressHook(next CompressionHook) CompressionHook { return next }
+func (h *nearCacheSkippedHook) DecompressHook(next CompressionHook) CompressionHook {
+	return next
+}
+
+func (h *nearCacheSkippedHook) NearCacheSkipped(redisKey string) {
+	h.skipped = append(h.skipped, redisKey)
+}
+
+func TestCache_NearCacheMaxValueSize(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	counter := &cacheCallCounter{}
+	hook := &nearCacheSkippedHook{}
+	rdb := New(rueidishook.WithHook(client, counter), NearCache(time.Minute), NearCacheMaxValueSize(100))
+	assert.Equal(t, 100, rdb.Config().NearCacheMaxValueSize)
+	rdb.AddHook(hook)
+	require.NoError(t, rdb.Set(context.Background(), "small", "value", 0))
+	require.NoError(t, rdb.Set(context.Background(), "large", strings.Repeat("value", 100), 0))
+
+	var val string
+	require.NoError(t, rdb.Get(context.Background(), "small", &val))
+	require.NoError(t, rdb.Get(context.Background(), "large", &val))
+	assert.Equal(t, int32(2), counter.cached.Load())
+
+	// The large value is now known and reads bypass the near cache
+	require.NoError(t, rdb.Get(context.Background(), "large", &val))
+	assert.Equal(t, strings.Repeat("value", 100), val)
+	assert.Equal(t, int32(2), counter.cached.Load())
+	_, err := MGet[string](context.Background(), rdb, "small", "large")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), counter.cached.Load())
+	assert.Equal(t, []string{"large", "large"}, hook.skipped)
+
+	// Once the value is within the limit the key is cached locally again
+	require.NoError(t, rdb.Set(context.Background(), "large", "value", 0))
+	require.NoError(t, rdb.Get(context.Background(), "large", &val))
+	require.NoError(t, rdb.Get(context.Background(), "large", &val))
+	assert.Equal(t, "value", val)
+	assert.Equal(t, int32(3), counter.cached.Load())
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIrreversibleKeys is an error value that signals keys can't be scanned
+// because a KeyTransform configured with WithKeyTransforms can't restore the
+// keys stored in Redis to the keys originally provided.
+var ErrIrreversibleKeys = errors.New("key transforms are irreversible")
+
+// KeyTransform maps keys provided to the Cache to the keys stored in Redis. Key
+// transforms configured with WithKeyTransforms are composed into a pipeline and
+// applied to the key of every operation.
+//
+// Transform must be deterministic, otherwise entries won't be found once
+// written. Restore maps a key returned by Transform back to the key provided,
+// reporting false if the key can't be restored, either because the transform is
+// irreversible such as hashing, or because the key wasn't produced by
+// Transform. Restore is used to map the keys returned by SCAN, so SCAN patterns
+// are only supported when every transform in the pipeline is reversible, in
+// which case the pattern is transformed like a key.
+type KeyTransform interface {
+	Transform(key string) string
+	Restore(key string) (string, bool)
+}
+
+// prefixTransform is a KeyTransform that prepends a prefix to keys.
+type prefixTransform struct {
+	prefix string
+}
+
+// PrefixKeys returns a KeyTransform that prepends prefix to every key, such as a
+// namespace or the name of an environment, so multiple caches or environments
+// can share a Redis keyspace without colliding.
+//
+// PrefixKeys panics if prefix is empty or contains glob characters, as the
+// prefix is prepended to SCAN patterns as well.
+func PrefixKeys(prefix string) KeyTransform {
+	if prefix == "" || strings.ContainsAny(prefix, `*?[]\`) {
+		panic(fmt.Errorf("invalid key prefix %q, illegal use of api", prefix))
+	}
+	return prefixTransform{prefix: prefix}
+}
+
+func (p prefixTransform) Transform(key string) string {
+	return p.prefix + key
+}
+
+func (p prefixTransform) Restore(key string) (string, bool) {
+	return strings.CutPrefix(key, p.prefix)
+}
+
+// HashTagKeys returns a KeyTransform that prepends the hash tag {tag} to every
+// key, so on Redis Cluster every key is stored in the same slot. This allows
+// multi-key operations that require keys to share a slot, such as
+// GetConsistent, at the cost of concentrating the keys on a single node.
+//
+// A hash tag only takes effect if it's the first pair of braces in a key, so
+// HashTagKeys should follow any transform prepending to keys, such as
+// PrefixKeys, and no transform should follow it that rewrites the whole key,
+// such as HashKeys.
+//
+// HashTagKeys panics if tag is empty or contains braces.
+func HashTagKeys(tag string) KeyTransform {
+	if tag == "" || strings.ContainsAny(tag, "{}") {
+		panic(fmt.Errorf("invalid hash tag %q, illegal use of api", tag))
+	}
+	return prefixTransform{prefix: "{" + tag + "}"}
+}
+
+// hashTransform is an irreversible KeyTransform replacing keys with their
+// SHA-256 digest.
+type hashTransform struct{}
+
+// HashKeys returns a KeyTransform that replaces every key with the hex encoded
+// SHA-256 digest of the key. This bounds the length of keys stored in Redis and
+// hides the keys used by the application, for example when keys contain
+// personal data.
+//
+// Hashing is irreversible, so Keys, ScanKeys, and ScanValues return an error
+// wrapping ErrIrreversibleKeys when HashKeys is in the pipeline.
+func HashKeys() KeyTransform {
+	return hashTransform{}
+}
+
+func (hashTransform) Transform(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (hashTransform) Restore(string) (string, bool) {
+	return "", false
+}
+
+// compactTransform adapts a KeyCompactor to a KeyTransform.
+type compactTransform struct {
+	compactor KeyCompactor
+}
+
+// CompactKeys returns a KeyTransform storing keys in the compact form returned
+// by the KeyCompactor. See WithKeyCompaction for the tradeoffs of key
+// compaction.
+//
+// Providing a nil KeyCompactor will panic.
+func CompactKeys(compactor KeyCompactor) KeyTransform {
+	if compactor == nil {
+		panic(fmt.Errorf("nil KeyCompactor not permitted, illegal use of api"))
+	}
+	return compactTransform{compactor: compactor}
+}
+
+func (t compactTransform) Transform(key string) string {
+	return t.compactor.Compact(key)
+}
+
+func (t compactTransform) Restore(key string) (string, bool) {
+	return t.compactor.Expand(key), true
+}
+
+// transformKey applies the key transforms of the Cache to key in order.
+func (c *Cache) transformKey(key string) string {
+	for _, t := range c.keyTransforms {
+		key = t.Transform(key)
+	}
+	return key
+}
+
+// transformPattern applies the key transforms of the Cache to a SCAN pattern in
+// order, returning an error wrapping ErrIrreversibleKeys if any of them can't
+// restore the keys it transforms.
+func (c *Cache) transformPattern(pattern string) (string, error) {
+	for _, t := range c.keyTransforms {
+		transformed := t.Transform(pattern)
+		if _, ok := t.Restore(transformed); !ok {
+			return "", fmt.Errorf("scan %s: %w", pattern, ErrIrreversibleKeys)
+		}
+		pattern = transformed
+	}
+	return pattern, nil
+}
+
+// restoreKey reverses the key transforms of the Cache in reverse order,
+// reporting false if any of them can't restore the key.
+func (c *Cache) restoreKey(key string) (string, bool) {
+	for i := len(c.keyTransforms) - 1; i >= 0; i-- {
+		var ok bool
+		if key, ok = c.keyTransforms[i].Restore(key); !ok {
+			return "", false
+		}
+	}
+	return key, true
+}
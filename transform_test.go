@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyTransforms(t *testing.T) {
+	prefix := PrefixKeys("prod:")
+	assert.Equal(t, "prod:user:1", prefix.Transform("user:1"))
+	key, ok := prefix.Restore("prod:user:1")
+	assert.True(t, ok)
+	assert.Equal(t, "user:1", key)
+	_, ok = prefix.Restore("dev:user:1")
+	assert.False(t, ok)
+
+	tag := HashTagKeys("users")
+	assert.Equal(t, "{users}user:1", tag.Transform("user:1"))
+
+	hash := HashKeys()
+	assert.Len(t, hash.Transform("user:1"), 64)
+	assert.Equal(t, hash.Transform("user:1"), hash.Transform("user:1"))
+	_, ok = hash.Restore(hash.Transform("user:1"))
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { PrefixKeys("") })
+	assert.Panics(t, func() { PrefixKeys("prod*:") })
+	assert.Panics(t, func() { HashTagKeys("{users}") })
+	assert.Panics(t, func() { CompactKeys(nil) })
+	assert.Panics(t, func() { WithKeyTransforms(nil) })
+}
+
+func TestCache_WithKeyTransforms(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client,
+		WithKeyCompaction(SegmentDictionary(":", map[string]string{"user-profile": "up"})),
+		WithKeyTransforms(PrefixKeys("prod:"), HashTagKeys("users")),
+		WithGenerationBusting())
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "user-profile:1", "Bob", 0))
+	require.NoError(t, rdb.Set(ctx, "user-profile:2", "Alice", 0))
+	assert.True(t, server.Exists("g0:{users}prod:~up:1"))
+
+	var s string
+	require.NoError(t, rdb.Get(ctx, "user-profile:1", &s))
+	assert.Equal(t, "Bob", s)
+
+	// Keys that weren't written through the pipeline aren't restored
+	require.NoError(t, server.Set("g0:{users}dev:~up:3", "Eve"))
+
+	keys, err := rdb.Keys(ctx)
+	require.NoError(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"user-profile:1", "user-profile:2"}, keys)
+
+	keys, err = rdb.ScanKeys(ctx, "user-profile:*")
+	require.NoError(t, err)
+	sort.Strings(keys)
+	assert.Equal(t, []string{"user-profile:1", "user-profile:2"}, keys)
+
+	require.NoError(t, rdb.Delete(ctx, "user-profile:1"))
+	assert.False(t, server.Exists("g0:{users}prod:~up:1"))
+}
+
+func TestCache_WithKeyTransforms_Irreversible(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithKeyTransforms(PrefixKeys("prod:"), HashKeys()))
+
+	ctx := context.Background()
+	require.NoError(t, rdb.Set(ctx, "user:1", "Bob", 0))
+	assert.True(t, server.Exists(HashKeys().Transform("prod:user:1")))
+
+	var s string
+	require.NoError(t, rdb.Get(ctx, "user:1", &s))
+	assert.Equal(t, "Bob", s)
+
+	_, err := rdb.Keys(ctx)
+	assert.ErrorIs(t, err, ErrIrreversibleKeys)
+}
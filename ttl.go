@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoTTL is returned by set-like operations when they are called without a
+// TTL and the Cache is configured with the ZeroTTLReject policy.
+var ErrNoTTL = errors.New("a ttl greater than zero is required")
+
+// ZeroTTLPolicy determines how set-like operations such as Set, SetIfAbsent,
+// MSet and SetWithMeta treat a ttl value <= 0. The policy is configured with the
+// WithZeroTTLPolicy Option.
+//
+// InfiniteTTL is an explicit request for an entry that never expires and isn't
+// subject to the policy, so code that intentionally stores unbounded entries can
+// still do so under ZeroTTLDefault and ZeroTTLReject.
+//
+// The policy only applies to operations that write an entry. Operations that
+// update the TTL of existing entries, such as GetAndUpdateTTL and MGetEx, keep
+// treating a ttl value <= 0 as leaving the TTL unchanged. This Cache doesn't
+// provide KeepTTL or SetAt variants of Set, so there is no interaction with
+// them to consider.
+type ZeroTTLPolicy struct {
+	reject bool
+	ttl    time.Duration
+}
+
+var (
+	// ZeroTTLNoExpiry persists entries set with a ttl value <= 0 indefinitely.
+	// This is the default policy.
+	ZeroTTLNoExpiry = ZeroTTLPolicy{}
+
+	// ZeroTTLReject fails set-like operations called with a ttl value <= 0 with
+	// ErrNoTTL, forbidding accidental unbounded entries.
+	ZeroTTLReject = ZeroTTLPolicy{reject: true}
+)
+
+// ZeroTTLDefault returns a ZeroTTLPolicy that sets entries written with a ttl
+// value <= 0 with the provided ttl instead.
+//
+// Providing a ttl <= 0 panics.
+func ZeroTTLDefault(ttl time.Duration) ZeroTTLPolicy {
+	if ttl <= 0 {
+		panic(fmt.Errorf("ZeroTTLDefault requires ttl > 0, illegal use of api"))
+	}
+	return ZeroTTLPolicy{ttl: ttl}
+}
+
+// resolveTTL applies the ZeroTTLPolicy of the Cache to the ttl provided to a
+// set-like operation.
+func (c *Cache) resolveTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl > 0 || ttl == InfiniteTTL {
+		return ttl, nil
+	}
+	switch {
+	case c.zeroTTLPolicy.reject:
+		return 0, ErrNoTTL
+	case c.zeroTTLPolicy.ttl > 0:
+		return c.zeroTTLPolicy.ttl, nil
+	}
+	return ttl, nil
+}
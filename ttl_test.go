@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_ZeroTTLPolicy(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	t.Run("NoExpiry", func(t *testing.T) {
+		rdb := New(client)
+		require.NoError(t, rdb.Set(context.Background(), "no-expiry", "alice", 0))
+		assert.Equal(t, time.Duration(0), server.TTL("no-expiry"))
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		rdb := New(client, WithZeroTTLPolicy(ZeroTTLDefault(time.Minute)))
+		require.NoError(t, rdb.Set(context.Background(), "default", "alice", 0))
+		assert.Equal(t, time.Minute, server.TTL("default"))
+
+		require.NoError(t, rdb.Set(context.Background(), "explicit", "alice", time.Hour))
+		assert.Equal(t, time.Hour, server.TTL("explicit"))
+
+		require.NoError(t, rdb.Set(context.Background(), "infinite", "alice", InfiniteTTL))
+		assert.Equal(t, time.Duration(0), server.TTL("infinite"))
+
+		require.NoError(t, rdb.MSet(context.Background(), map[string]any{"m1": "a", "m2": "b"}))
+		assert.Equal(t, time.Minute, server.TTL("m1"))
+		assert.Equal(t, time.Minute, server.TTL("m2"))
+		var val string
+		require.NoError(t, rdb.Get(context.Background(), "m2", &val))
+		assert.Equal(t, "b", val)
+	})
+
+	t.Run("SubSecondDefault", func(t *testing.T) {
+		rdb := New(client, WithZeroTTLPolicy(ZeroTTLDefault(500*time.Millisecond)))
+		require.NoError(t, rdb.MSet(context.Background(), map[string]any{"ms1": "a", "ms2": "b"}))
+		assert.Equal(t, 500*time.Millisecond, server.TTL("ms1"))
+		assert.Equal(t, 500*time.Millisecond, server.TTL("ms2"))
+
+		server.FastForward(time.Second)
+		assert.False(t, server.Exists("ms1"))
+		assert.False(t, server.Exists("ms2"))
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		rdb := New(client, WithZeroTTLPolicy(ZeroTTLReject))
+		assert.ErrorIs(t, rdb.Set(context.Background(), "reject", "alice", 0), ErrNoTTL)
+		assert.ErrorIs(t, rdb.Set(context.Background(), "reject", "alice", -time.Second), ErrNoTTL)
+		assert.False(t, server.Exists("reject"))
+
+		_, err := rdb.SetIfAbsent(context.Background(), "reject", "alice", 0)
+		assert.ErrorIs(t, err, ErrNoTTL)
+		assert.ErrorIs(t, rdb.MSet(context.Background(), map[string]any{"reject": "alice"}), ErrNoTTL)
+
+		result, err := rdb.MSetWithResult(context.Background(), map[string]any{"reject": "alice"})
+		assert.ErrorIs(t, err, ErrNoTTL)
+		assert.Equal(t, OutcomeError, result.Outcome("reject"))
+		assert.False(t, server.Exists("reject"))
+
+		require.NoError(t, rdb.Set(context.Background(), "reject", "alice", InfiniteTTL))
+		assert.True(t, server.Exists("reject"))
+	})
+}
+
+func TestZeroTTLDefault_Invalid(t *testing.T) {
+	assert.Panics(t, func() {
+		ZeroTTLDefault(0)
+	})
+}
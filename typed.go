@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TypedCache is a wrapper around Cache that stores and retrieves values of a
+// single type T, removing the need for callers to provide a destination for
+// reads and preventing values of the wrong type from being written.
+//
+// TypedCache should be instantiated using NewTypedCache, which verifies T can be
+// stored by the Cache up front.
+type TypedCache[T any] struct {
+	cache *Cache
+}
+
+// NewTypedCache creates a TypedCache for values of type T backed by the provided
+// Cache. An error is returned if T can't be stored by the Cache, so wiring
+// mistakes fail fast at startup rather than on the first Get or Set.
+//
+// T is serializable if the zero value of T can be marshalled and unmarshalled by
+// the serialization configured for the Cache. With msgpack, the default, and
+// JSON this rejects types such as channels and functions, and with JSON also
+// complex numbers and maps with keys that aren't strings or integers. With a
+// custom Serialization the requirements are whatever the Marshaller and
+// Unmarshaller impose, such as a type implementing a specific interface. Values
+// of T holding data the serialization can't represent, such as an unsupported
+// type stored in an interface field, can still fail when written. If types are
+// restricted with WithAllowedTypes, T must also be allowed.
+func NewTypedCache[T any](c *Cache) (*TypedCache[T], error) {
+	if c == nil {
+		panic(fmt.Errorf("nil Cache not permitted, illegal use of api"))
+	}
+	var zero T
+	if err := c.checkType(&zero); err != nil {
+		return nil, err
+	}
+	data, err := c.marshaller(zero)
+	if err != nil {
+		return nil, fmt.Errorf("type %T is not serializable: marshall value: %w", zero, err)
+	}
+	if err := c.unmarshaller(data, &zero); err != nil {
+		return nil, fmt.Errorf("type %T is not serializable: unmarshall value: %w", zero, err)
+	}
+	return &TypedCache[T]{cache: c}, nil
+}
+
+// Cache returns the underlying Cache.
+func (tc *TypedCache[T]) Cache() *Cache {
+	return tc.cache
+}
+
+// Get retrieves the value from the Cache for the given key.
+//
+// If the key does not exist ErrKeyNotFound will be returned as the error value.
+func (tc *TypedCache[T]) Get(ctx context.Context, key string, opts ...CallOption) (T, error) {
+	var val T
+	err := tc.cache.Get(ctx, key, &val, opts...)
+	return val, err
+}
+
+// MGet retrieves the values from the Cache for the given keys. Keys that don't
+// exist are not included in the result.
+func (tc *TypedCache[T]) MGet(ctx context.Context, keys ...string) (MultiResult[T], error) {
+	return MGet[T](ctx, tc.cache, keys...)
+}
+
+// Set adds an entry into the cache, or overwrites an entry if the key already
+// existed. If the ttl value is <= 0 the key will be persisted indefinitely.
+func (tc *TypedCache[T]) Set(ctx context.Context, key string, v T, ttl time.Duration) error {
+	return tc.cache.Set(ctx, key, v, ttl)
+}
+
+// Delete removes entries from the cache for a given set of keys.
+func (tc *TypedCache[T]) Delete(ctx context.Context, keys ...string) error {
+	return tc.cache.Delete(ctx, keys...)
+}
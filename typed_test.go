@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedUser struct {
+	ID   int
+	Name string
+}
+
+func TestNewTypedCache(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	users, err := NewTypedCache[typedUser](New(client))
+	require.NoError(t, err)
+
+	require.NoError(t, users.Set(context.Background(), "user:1", typedUser{ID: 1, Name: "alice"}, time.Minute))
+	user, err := users.Get(context.Background(), "user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, typedUser{ID: 1, Name: "alice"}, user)
+
+	results, err := users.MGet(context.Background(), "user:1", "user:2")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[typedUser]{"user:1": user}, results)
+
+	require.NoError(t, users.Delete(context.Background(), "user:1"))
+	_, err = users.Get(context.Background(), "user:1")
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// Types that can't be serialized are rejected up front
+	_, err = NewTypedCache[chan int](New(client))
+	assert.ErrorContains(t, err, "not serializable")
+	_, err = NewTypedCache[complex128](New(client, JSON()))
+	assert.ErrorContains(t, err, "not serializable")
+
+	_, err = NewTypedCache[string](New(client, WithAllowedTypes(reflect.TypeOf(typedUser{}))))
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrValidation is an error value that signals a value was rejected by the
+	// Validator configured for the Cache.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrTypeNotAllowed is an error value that signals a value was rejected
+	// because its type wasn't registered using the WithAllowedTypes Option.
+	ErrTypeNotAllowed = errors.New("type not allowed")
+)
+
+// Validator is a function type that validates a value against business rules
+// before it is written to the cache, and optionally after it is read. A non-nil
+// error signals the value is invalid.
+type Validator func(v any) error
+
+// validate invokes the Validator returning an error wrapping ErrValidation when
+// the value is invalid.
+func (c *Cache) validate(v any) error {
+	if c.validator == nil {
+		return nil
+	}
+	if err := c.validator(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+	return nil
+}
+
+// validateRead invokes the Validator on a value read from the cache. Values are
+// read into a pointer, so the Validator is invoked with the value it points to,
+// matching the value as it was written.
+func (c *Cache) validateRead(v any) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		v = rv.Elem().Interface()
+	}
+	return c.validate(v)
+}
+
+// checkType returns an error wrapping ErrTypeNotAllowed if types are restricted
+// using WithAllowedTypes and the type of the value, or the type it points to,
+// isn't allowed.
+func (c *Cache) checkType(v any) error {
+	if c.allowedTypes == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if _, ok := c.allowedTypes[t]; ok {
+		return nil
+	}
+	if t != nil && t.Kind() == reflect.Pointer {
+		if _, ok := c.allowedTypes[t.Elem()]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrTypeNotAllowed, t)
+}
+
+// PreCompressInspector is a function type that is invoked with the size in bytes
+// of a marshalled value before it is compressed. A non-nil error aborts the
+// write.
+type PreCompressInspector func(key string, size int) error
+
+// inspect invokes the PreCompressInspector, if one is configured, with the size
+// of the marshalled value.
+func (c *Cache) inspect(key string, data []byte) error {
+	if c.inspector == nil {
+		return nil
+	}
+	if err := c.inspector(key, len(data)); err != nil {
+		return fmt.Errorf("inspect value: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCache_WithValidator(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	validator := func(v any) error {
+		var age int
+		switch val := v.(type) {
+		case int:
+			age = val
+		case *int:
+			age = *val
+		}
+		if age < 0 {
+			return errors.New("age must not be negative")
+		}
+		return nil
+	}
+
+	rdb := New(client, WithValidator(validator))
+
+	err := rdb.Set(context.Background(), "age", -1, 0)
+	assert.ErrorIs(t, err, ErrValidation)
+	exists, err := client.Do(context.Background(), client.B().Exists().Key("age").Build()).AsInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+
+	assert.NoError(t, rdb.Set(context.Background(), "age", 42, 0))
+
+	// Invalid data written by another client is returned when read validation
+	// isn't enabled.
+	val, _ := msgpack.Marshal(-10)
+	assert.NoError(t, client.Do(context.Background(), client.B().Set().Key("invalid").Value(string(val)).Build()).Error())
+
+	var age int
+	assert.NoError(t, rdb.Get(context.Background(), "invalid", &age))
+	assert.Equal(t, -10, age)
+
+	rdb = New(client, WithValidator(validator), WithReadValidation())
+	err = rdb.Get(context.Background(), "invalid", &age)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+	assert.ErrorIs(t, err, ErrValidation)
+
+	results, err := MGet[int](context.Background(), rdb, "age", "invalid")
+	assert.NoError(t, err)
+	assert.Equal(t, MultiResult[int]{"age": 42}, results)
+}
+
+func TestCache_WithPreCompressInspector(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	sizes := make(map[string]int)
+	rdb := New(client, LZ4(), WithPreCompressInspector(func(key string, size int) error {
+		sizes[key] = size
+		if size > 100 {
+			return assert.AnError
+		}
+		return nil
+	}))
+
+	assert.NoError(t, rdb.Set(context.Background(), "small", "value", 0))
+	assert.Equal(t, 6, sizes["small"])
+
+	err := rdb.Set(context.Background(), "large", strings.Repeat("a", 200), 0)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.False(t, server.Exists("large"))
+
+	err = rdb.MSet(context.Background(), map[string]any{"key1": "value", "key2": strings.Repeat("a", 200)})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.False(t, server.Exists("key1"))
+}
+
+func TestCache_WithAllowedTypes(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	type user struct {
+		Name string
+	}
+
+	rdb := New(client, WithAllowedTypes(reflect.TypeOf(user{}), reflect.TypeOf("")))
+
+	assert.NoError(t, rdb.Set(context.Background(), "user", user{Name: "Alice"}, 0))
+	assert.NoError(t, rdb.Set(context.Background(), "user-ptr", &user{Name: "Bob"}, 0))
+	assert.NoError(t, rdb.Set(context.Background(), "string", "value", 0))
+
+	err := rdb.Set(context.Background(), "slice", []byte("value"), 0)
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+	assert.False(t, server.Exists("slice"))
+
+	err = rdb.MSet(context.Background(), map[string]any{"int": 42})
+	assert.ErrorIs(t, err, ErrTypeNotAllowed)
+
+	// All types are allowed by default
+	assert.NoError(t, New(client).Set(context.Background(), "int", 42, 0))
+}
+
+// accessRecorder is a Hook recording the hits and misses reported to it.
+type accessRecorder struct {
+	hits   []string
+	misses []string
+}
+
+func (r *accessRecorder) MarshalHook(next Marshaller) Marshaller        { return next }
+func (r *accessRecorder) UnmarshallHook(next Unmarshaller) Unmarshaller { return next }
+func (r *accessRecorder) CompressHook(next CompressionHook) CompressionHook {
+	return next
+}
+func (r *accessRecorder) DecompressHook(next CompressionHook) CompressionHook {
+	return next
+}
+
+func (r *accessRecorder) Hit(key string)  { r.hits = append(r.hits, key) }
+func (r *accessRecorder) Miss(key string) { r.misses = append(r.misses, key) }
+
+func TestCache_WithReadValidation(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	// The Validator only accepts values, never pointers to them
+	validator := func(v any) error {
+		age, ok := v.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", v)
+		}
+		if age < 0 {
+			return errors.New("age must not be negative")
+		}
+		return nil
+	}
+
+	ctx := context.Background()
+	val, _ := msgpack.Marshal(-10)
+	assert.NoError(t, client.Do(ctx, client.B().Set().Key("invalid").Value(string(val)).Build()).Error())
+
+	for name, opts := range map[string][]Option{
+		"Default":   nil,
+		"Migration": {WithMigrationMode(MsgpackEncoding(), JSONEncoding())},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recorder := &accessRecorder{}
+			rdb := New(client, append([]Option{WithValidator(validator), WithReadValidation()}, opts...)...)
+			rdb.AddHook(recorder)
+			assert.NoError(t, rdb.Set(ctx, "age", 42, 0))
+
+			var age int
+			assert.NoError(t, rdb.Get(ctx, "age", &age))
+			assert.Equal(t, 42, age)
+
+			err := rdb.Get(ctx, "invalid", &age)
+			assert.ErrorIs(t, err, ErrKeyNotFound)
+			assert.ErrorIs(t, err, ErrValidation)
+
+			results, err := MGet[int](ctx, rdb, "age", "invalid")
+			assert.NoError(t, err)
+			assert.Equal(t, MultiResult[int]{"age": 42}, results)
+
+			// Values rejected on read are misses
+			assert.Equal(t, []string{"age", "age"}, recorder.hits)
+			assert.Equal(t, []string{"invalid", "invalid"}, recorder.misses)
+		})
+	}
+}
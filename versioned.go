@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/rueidis"
+)
+
+// sequencePrefix is the prefix of the keys used to record the sequence of the
+// last write made with SetVersionedTime.
+const sequencePrefix = "rueidis-cache:sequence:"
+
+// setVersionedScript records the sequence and writes the value only if the
+// sequence is greater than the sequence recorded by the previous write. Returns
+// 1 if the value was written and 0 if the write is stale.
+//
+// KEYS[1] is the sequence record, and KEYS[2] the key of the value if the value
+// is to be written by the script. ARGV[1] is the sequence zero padded so
+// sequences compare as strings, avoiding the loss of precision of Lua numbers,
+// ARGV[2] the TTL in milliseconds, or 0 to persist, and ARGV[3] the value. If
+// ARGV[3] isn't provided the key of the value is deleted instead.
+var setVersionedScript = rueidis.NewLuaScript(`
+local current = redis.call('GET', KEYS[1])
+if current and current >= ARGV[1] then
+	return 0
+end
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+if #KEYS > 1 then
+	if #ARGV < 3 then
+		redis.call('DEL', KEYS[2])
+	elseif ttl > 0 then
+		redis.call('SET', KEYS[2], ARGV[3], 'PX', ttl)
+	else
+		redis.call('SET', KEYS[2], ARGV[3])
+	end
+end
+return 1`)
+
+// SetVersionedTime adds an entry into the cache like Set, but only if seq is
+// greater than the sequence of the last write of the key made with
+// SetVersionedTime. This resolves concurrent and out-of-order writes by logical
+// time rather than arrival, so a stale write, such as an event delivered late,
+// can't overwrite a newer value. SetVersionedTime returns true if the value was
+// written, and false if the write was rejected as stale.
+//
+// The sequence is recorded in a separate key that expires with the value.
+// Deleting the key with DeleteVersionedTime records the sequence of the delete,
+// so a stale write arriving after the delete is rejected rather than
+// resurrecting the key. Sequences must be monotonically increasing for each key,
+// such as event offsets or timestamps in nanoseconds, and must be >= 0.
+// Recording the sequence and writing the value happen atomically.
+//
+// On Redis Cluster the sequence record is stored in the same slot as the key
+// using a hash tag. If the key contains braces that don't form a valid hash tag
+// the record can't be placed in the same slot and an error wrapping ErrCrossSlot
+// is returned. While migrating between Encodings the target Encoding can be
+// stored in a different slot, so the sequence is recorded before the value is
+// written rather than atomically.
+func (c *Cache) SetVersionedTime(ctx context.Context, key string, v any, seq int64, ttl time.Duration) (bool, error) {
+	if c.dryRun {
+		return false, dryRunUnsupported("SetVersionedTime")
+	}
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return false, err
+	}
+	redisKey, recordKey, err := c.sequenceKeys(ctx, key, seq)
+	if err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	args := []string{fmt.Sprintf("%019d", seq), fmt.Sprint(ttl.Milliseconds())}
+
+	if c.migration != nil {
+		applied, err := setVersionedScript.Exec(ctx, c.redis, []string{recordKey}, args).AsInt64()
+		if err != nil {
+			return false, fmt.Errorf("redis: %w", err)
+		}
+		if applied == 0 {
+			return false, nil
+		}
+		return true, c.setMigrating(ctx, key, redisKey, v, ttl, nil)
+	}
+
+	data, err := c.encode(ctx, key, v)
+	if err != nil {
+		return false, err
+	}
+	// Buffered writes must be flushed first or they would overwrite the value
+	if err := c.FlushWrites(ctx); err != nil {
+		return false, err
+	}
+	applied, err := setVersionedScript.Exec(ctx, c.redis, []string{recordKey, redisKey},
+		append(args, string(data))).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return applied == 1, nil
+}
+
+// DeleteVersionedTime removes the entry for the key from the cache, but only if
+// seq is greater than the sequence of the last write of the key made with
+// SetVersionedTime or DeleteVersionedTime. The sequence of the delete is
+// remembered for the provided TTL, so writes with an older sequence arriving
+// within the TTL are rejected instead of resurrecting the key. The TTL should
+// therefore cover the maximum delay of out-of-order writes. If the ttl value is
+// <= 0 the sequence is remembered indefinitely. DeleteVersionedTime returns true
+// if the key was deleted, and false if the delete was rejected as stale.
+//
+// See SetVersionedTime for the requirements of sequences and keys.
+func (c *Cache) DeleteVersionedTime(ctx context.Context, key string, seq int64, ttl time.Duration) (bool, error) {
+	if c.dryRun {
+		return false, dryRunUnsupported("DeleteVersionedTime")
+	}
+	redisKey, recordKey, err := c.sequenceKeys(ctx, key, seq)
+	if err != nil {
+		return false, err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	args := []string{fmt.Sprintf("%019d", seq), fmt.Sprint(ttl.Milliseconds())}
+
+	if c.migration != nil {
+		applied, err := setVersionedScript.Exec(ctx, c.redis, []string{recordKey}, args).AsInt64()
+		if err != nil {
+			return false, fmt.Errorf("redis: %w", err)
+		}
+		if applied == 0 {
+			return false, nil
+		}
+		return true, c.deleteMigrating(ctx, []string{redisKey})
+	}
+
+	// Buffered writes must be flushed first or they would recreate the key
+	if err := c.FlushWrites(ctx); err != nil {
+		return false, err
+	}
+	applied, err := setVersionedScript.Exec(ctx, c.redis, []string{recordKey, redisKey}, args).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("redis: %w", err)
+	}
+	return applied == 1, nil
+}
+
+// sequenceKeys validates the sequence and returns the key stored in Redis for
+// the key and the key of its sequence record, which is stored in the same slot.
+func (c *Cache) sequenceKeys(ctx context.Context, key string, seq int64) (string, string, error) {
+	if seq < 0 {
+		return "", "", fmt.Errorf("sequence must be >= 0, got %d", seq)
+	}
+	redisKey, err := c.key(ctx, key)
+	if err != nil {
+		return "", "", err
+	}
+	recordKey := sequencePrefix + "{" + hashTag(redisKey) + "}:" + redisKey
+	if c.cluster && !sameSlot(recordKey, redisKey) {
+		return "", "", fmt.Errorf("sequence record for key %s: %w", key, ErrCrossSlot)
+	}
+	return redisKey, recordKey, nil
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_SetVersionedTime(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	ctx := context.Background()
+
+	applied, err := rdb.SetVersionedTime(ctx, "key", "v2", 2, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	// Writes with an older or equal sequence are stale
+	for _, seq := range []int64{1, 2} {
+		applied, err = rdb.SetVersionedTime(ctx, "key", "stale", seq, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, applied)
+	}
+	var s string
+	require.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, "v2", s)
+
+	// Sequences are compared as integers rather than floating point numbers
+	applied, err = rdb.SetVersionedTime(ctx, "key", "v3", 1<<62, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	applied, err = rdb.SetVersionedTime(ctx, "key", "v4", 1<<62+1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	assert.Equal(t, time.Minute, server.TTL("key"))
+
+	_, err = rdb.SetVersionedTime(ctx, "key", "value", -1, time.Minute)
+	assert.Error(t, err)
+}
+
+func TestCache_DeleteVersionedTime(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+	ctx := context.Background()
+
+	applied, err := rdb.SetVersionedTime(ctx, "key", "v1", 1, 0)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	applied, err = rdb.DeleteVersionedTime(ctx, "key", 3, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	assert.False(t, server.Exists("key"))
+
+	// A stale write delivered after the delete doesn't resurrect the key
+	applied, err = rdb.SetVersionedTime(ctx, "key", "v2", 2, 0)
+	require.NoError(t, err)
+	assert.False(t, applied)
+	assert.False(t, server.Exists("key"))
+
+	applied, err = rdb.DeleteVersionedTime(ctx, "key", 3, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	applied, err = rdb.SetVersionedTime(ctx, "key", "v4", 4, 0)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	var s string
+	require.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, "v4", s)
+}
+
+func TestCache_SetVersionedTime_Migrating(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithMigrationMode(MsgpackEncoding(), JSONEncoding()))
+	ctx := context.Background()
+
+	applied, err := rdb.SetVersionedTime(ctx, "key", "v2", 2, 0)
+	require.NoError(t, err)
+	assert.True(t, applied)
+	applied, err = rdb.SetVersionedTime(ctx, "key", "v1", 1, 0)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	var s string
+	require.NoError(t, rdb.Get(ctx, "key", &s))
+	assert.Equal(t, "v2", s)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultWarmConcurrency is the maximum number of keys loaded concurrently by
+// WarmFromLoader.
+const DefaultWarmConcurrency = 16
+
+// WarmFromLoader populates the Cache with the values for keys returned by the
+// loader, typically at startup so the cache is primed before serving traffic.
+// Up to DefaultWarmConcurrency keys are loaded concurrently, and the loaded
+// values are written with the provided TTL using a single pipeline once every
+// key has been loaded.
+//
+// Errors are aggregated rather than failing fast. If loading a key fails the
+// error is joined into the returned error, but the values loaded successfully are
+// still written. Keys for which the loader returns an error wrapping
+// ErrKeyNotFound are skipped without an error. If the context is done before
+// every key is loaded no further keys are loaded, nothing is written, and the
+// context error is joined into the returned error, so a slow warmup can be
+// aborted.
+//
+// Callers gating readiness on the cache being primed should treat a nil error as
+// primed, as keys skipped because they don't exist are not errors.
+func (c *Cache) WarmFromLoader(
+	ctx context.Context,
+	keys []string,
+	loader func(ctx context.Context, key string) (any, error),
+	ttl time.Duration) error {
+
+	if loader == nil {
+		panic(fmt.Errorf("nil loader not permitted, illegal use of api"))
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		loaded = make(map[string]any, len(keys))
+		errs   []error
+		sem    = make(chan struct{}, DefaultWarmConcurrency)
+	)
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			val, err := loader(ctx, key)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrKeyNotFound):
+			case err != nil:
+				errs = append(errs, fmt.Errorf("load key %s: %w", key, err))
+			default:
+				loaded[key] = val
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	if err := setMany(ctx, c, loaded, ttl); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_WarmFromLoader(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+
+	keys := make([]string, 50)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%d", i)
+	}
+	var active, maxActive atomic.Int32
+	loader := func(ctx context.Context, key string) (any, error) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		switch key {
+		case "key:1":
+			return nil, ErrKeyNotFound
+		case "key:2":
+			return nil, errors.New("boom")
+		}
+		return key + ":value", nil
+	}
+
+	ctx := context.Background()
+	err := rdb.WarmFromLoader(ctx, keys, loader, time.Minute)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "key:2")
+	assert.LessOrEqual(t, maxActive.Load(), int32(DefaultWarmConcurrency))
+
+	var s string
+	require.NoError(t, rdb.Get(ctx, "key:0", &s))
+	assert.Equal(t, "key:0:value", s)
+	assert.ErrorIs(t, rdb.Get(ctx, "key:1", &s), ErrKeyNotFound)
+	assert.ErrorIs(t, rdb.Get(ctx, "key:2", &s), ErrKeyNotFound)
+	assert.Equal(t, time.Minute, server.TTL("key:49"))
+}
+
+func TestCache_WarmFromLoader_Canceled(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	loader := func(ctx context.Context, key string) (any, error) {
+		calls.Add(1)
+		cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%d", i)
+	}
+	err := rdb.WarmFromLoader(ctx, keys, loader, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, calls.Load(), int32(DefaultWarmConcurrency))
+	assert.False(t, server.Exists("key:0"))
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// expiresEarly reports if the value as stored in Redis should be treated as
+// expired ahead of its TTL using the XFetch algorithm. The closer the value is
+// to expiring and the longer it took to compute, the more likely it is to
+// expire early. Values stored without the time it took to compute them never
+// expire early.
+func (c *Cache) expiresEarly(data []byte) bool {
+	if c.earlyExpiration <= 0 || data == nil {
+		return false
+	}
+	h, _, ok, err := c.parseValueHeader(data)
+	if err != nil || !ok || h.flags&flagExpiry == 0 {
+		return false
+	}
+	// -ln(u) for u in (0, 1] is exponentially distributed, so the gap is usually
+	// a small multiple of delta but occasionally much larger.
+	gap := time.Duration(float64(h.delta) * c.earlyExpiration * -math.Log(1-rand.Float64()))
+	return !c.clock.Now().Add(gap).Before(h.expiresAt)
+}
+
+// setComputed adds an entry into the cache like Set, and stores the time it took
+// to compute the value and when it expires in the header of the value so reads
+// can expire it early. If early expiration is disabled, the ttl value is <= 0,
+// or in migration mode the value is stored like Set.
+func (c *Cache) setComputed(ctx context.Context, key string, v any, ttl, delta time.Duration) error {
+	ttl, err := c.resolveTTL(ttl)
+	if err != nil {
+		return err
+	}
+	if c.earlyExpiration <= 0 || ttl <= 0 || c.migration != nil {
+		return c.Set(ctx, key, v, ttl)
+	}
+	return c.setWithHeader(ctx, key, v, ttl, header{
+		flags:     flagExpiry,
+		delta:     delta,
+		expiresAt: c.clock.Now().Add(ttl),
+	})
+}
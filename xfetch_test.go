@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_expiresEarly(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithEarlyExpiration(1))
+
+	expiring := header{flags: flagExpiry, delta: time.Hour, expiresAt: time.Now().Add(time.Millisecond)}
+	assert.True(t, rdb.expiresEarly(expiring.appendTo(nil, nil)))
+
+	fresh := header{flags: flagExpiry, delta: time.Nanosecond, expiresAt: time.Now().Add(time.Hour)}
+	assert.False(t, rdb.expiresEarly(fresh.appendTo(nil, nil)))
+
+	// Values without the compute time never expire early
+	assert.False(t, rdb.expiresEarly(header{flags: flagTimestamp}.appendTo(nil, nil)))
+	assert.False(t, rdb.expiresEarly([]byte("legacy")))
+	assert.False(t, New(client).expiresEarly(expiring.appendTo(nil, nil)))
+}
+
+func TestCache_GetOrComputeDistributed_EarlyExpiration(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	rdb := New(client, WithEarlyExpiration(1))
+	var calls int
+	fn := func(ctx context.Context) (any, error) {
+		calls++
+		return calls, nil
+	}
+
+	var val int
+	require.NoError(t, rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, fn))
+	assert.Equal(t, 1, val)
+
+	raw, err := server.Get("key")
+	require.NoError(t, err)
+	h, payload, ok, err := parseHeader([]byte(raw))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.NotZero(t, h.flags&flagExpiry)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), h.expiresAt, time.Second)
+
+	// Fresh values are served from the cache
+	require.NoError(t, rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, fn))
+	assert.Equal(t, 1, val)
+	assert.Equal(t, 1, calls)
+
+	// A value close to expiring that took long to compute is refreshed early,
+	// unless another process holds the lock
+	h.delta = time.Hour
+	h.expiresAt = time.Now().Add(time.Millisecond)
+	require.NoError(t, server.Set("key", string(h.appendTo(nil, payload))))
+
+	require.NoError(t, server.Set(computeLockPrefix+"key", "held"))
+	require.NoError(t, rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, fn))
+	assert.Equal(t, 1, val)
+	assert.Equal(t, 1, calls)
+
+	server.Del(computeLockPrefix + "key")
+	require.NoError(t, rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute, fn))
+	assert.Equal(t, 2, val)
+	assert.Equal(t, 2, calls)
+	assert.False(t, server.Exists(computeLockPrefix+"key"))
+
+	// A failed refresh keeps the cached value
+	h.expiresAt = time.Now().Add(time.Millisecond)
+	require.NoError(t, server.Set("key", string(h.appendTo(nil, payload))))
+	require.NoError(t, rdb.GetOrComputeDistributed(context.Background(), "key", &val, time.Minute,
+		func(ctx context.Context) (any, error) {
+			return nil, assert.AnError
+		}))
+	assert.Equal(t, 1, val)
+}